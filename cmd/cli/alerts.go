@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	grpcclient "github.com/rp4ri/quantacode/internal/grpc/client"
+)
+
+// parseAlertFlags validates --alert and --alert-move values and turns them into
+// alert rules. Unnamed alerts get sequential ids ("alert-1", "alert-2", ...).
+func parseAlertFlags(exprs, moves []string, cooldown time.Duration) ([]grpcclient.AlertRule, error) {
+	if cooldown < 0 {
+		return nil, fmt.Errorf("invalid --alert-cooldown: must not be negative")
+	}
+
+	var out []grpcclient.AlertRule
+	seen := make(map[string]bool)
+	nextID := func(name string) (string, error) {
+		if name == "" {
+			name = fmt.Sprintf("alert-%d", len(out)+1)
+		}
+		if seen[name] {
+			return "", fmt.Errorf("duplicate alert name %q", name)
+		}
+		seen[name] = true
+		return name, nil
+	}
+
+	for _, text := range exprs {
+		rule, err := rules.ParseNamed(text)
+		if err != nil {
+			return nil, ruleError("--alert", err)
+		}
+		// Without a "name:" prefix the rule is named after its expression
+		name := rule.Name
+		if name == rule.Source() {
+			name = ""
+		}
+		id, err := nextID(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --alert: %w", err)
+		}
+		out = append(out, grpcclient.AlertRule{ID: id, Expression: rule.Source(), Cooldown: cooldown})
+	}
+	for _, text := range moves {
+		move, err := alerts.ParseMove(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --alert-move: %w", err)
+		}
+		id, err := nextID("")
+		if err != nil {
+			return nil, fmt.Errorf("invalid --alert-move: %w", err)
+		}
+		out = append(out, grpcclient.AlertRule{ID: id, MovePercent: move.Percent, MoveWindow: move.Window, Cooldown: cooldown})
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rp4ri/quantacode/internal/backtest"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+func newBacktestCmd() *cobra.Command {
+	var (
+		file     string
+		entry    string
+		exit     string
+		asJSON   bool
+		defaults = backtest.DefaultConfig()
+		cfg      = defaults
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backtest",
+		Short: "Run a strategy over historical klines offline",
+		Long: `Replays a CSV or JSON kline file through the indicators and simulates a
+long-only strategy. Signals are evaluated on closed bars and filled at the next
+bar's open, with fees and slippage applied to every fill.
+
+Entry and exit are rule expressions, for example:
+  quantacode backtest --file btc-1h.csv --entry "rsi(14) < 30 and close > ema(50)" --exit "rsi crosses_above 70"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				return fmt.Errorf("--file is required")
+			}
+			entryRule, err := rules.Parse(entry)
+			if err != nil {
+				return ruleError("--entry", err)
+			}
+			exitRule, err := rules.Parse(exit)
+			if err != nil {
+				return ruleError("--exit", err)
+			}
+
+			klines, err := backtest.LoadKlines(file)
+			if err != nil {
+				return err
+			}
+
+			report, err := backtest.Run(klines, backtest.Strategy{Entry: entryRule, Exit: exitRule}, cfg)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			report.Print(os.Stdout)
+			return nil
+		},
+	}
+
+	addReplayFlags(cmd, &file, &cfg, defaults)
+	cmd.Flags().StringVar(&entry, "entry", "rsi < 30", "Entry rule")
+	cmd.Flags().StringVar(&exit, "exit", "rsi > 70", "Exit rule")
+	cmd.Flags().Float64Var(&cfg.InitialCapital, "capital", defaults.InitialCapital, "Initial capital in quote currency")
+	cmd.Flags().Float64Var(&cfg.FeeRate, "fee", defaults.FeeRate, "Fee per fill as a fraction of notional")
+	cmd.Flags().Float64Var(&cfg.Slippage, "slippage", defaults.Slippage, "Slippage per fill as a fraction of price")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the report as JSON")
+
+	return cmd
+}
+
+// addReplayFlags registers the kline file and indicator flags shared by backtest and signals.
+func addReplayFlags(cmd *cobra.Command, file *string, cfg *backtest.Config, defaults backtest.Config) {
+	cmd.Flags().StringVar(file, "file", "", "Kline file (.csv in Binance dump format or .json)")
+	cmd.Flags().IntVar(&cfg.WarmupBars, "warmup", defaults.WarmupBars, "Bars used to warm up indicators before evaluating rules")
+	cmd.Flags().IntVar(&cfg.RSIPeriod, "rsi", defaults.RSIPeriod, "RSI period")
+	cmd.Flags().IntVar(&cfg.SMAPeriod, "sma", defaults.SMAPeriod, "SMA period")
+	cmd.Flags().IntVar(&cfg.EMAPeriod, "ema", defaults.EMAPeriod, "EMA period")
+}
+
+// ruleError formats a rule parse error, pointing at the offending column when known.
+func ruleError(flag string, err error) error {
+	var perr *rules.ParseError
+	if errors.As(err, &perr) {
+		return fmt.Errorf("invalid %s: %w\n  %s", flag, err, strings.ReplaceAll(perr.Caret(), "\n", "\n  "))
+	}
+	return fmt.Errorf("invalid %s: %w", flag, err)
+}
@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	domainindicators "github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
 	"github.com/rp4ri/quantacode/internal/ui/chat"
 )
 
@@ -26,6 +30,8 @@ func newRootCmd() *cobra.Command {
 	}
 
 	root.AddCommand(newChatCmd())
+	root.AddCommand(newBacktestCmd())
+	root.AddCommand(newSignalsCmd())
 	return root
 }
 
@@ -33,6 +39,14 @@ func newChatCmd() *cobra.Command {
 	var (
 		serverAddr string
 		symbol     string
+		interval   string
+		indicators string
+		ruleFlags  []string
+		alertFlags []string
+		moveFlags  []string
+		cooldown   time.Duration
+		depth      int
+		exchange   string
 		keyFlag    string
 	)
 
@@ -50,12 +64,50 @@ func newChatCmd() *cobra.Command {
 				return fmt.Errorf("OpenRouter API key not provided (use --openrouter-key or set OPENROUTER_API_KEY)")
 			}
 
+			specs, err := domainindicators.ParseSpecs(indicators)
+			if err != nil {
+				return fmt.Errorf("invalid --indicators: %w", err)
+			}
+			var specNames []string
+			for _, spec := range specs {
+				if _, _, err := domainindicators.DefaultRegistry().Resolve(spec); err != nil {
+					return fmt.Errorf("invalid --indicators: %w", err)
+				}
+				specNames = append(specNames, spec.Key())
+			}
+
+			for _, text := range ruleFlags {
+				if _, err := rules.ParseNamed(text); err != nil {
+					return ruleError("--rule", err)
+				}
+			}
+
+			alertRules, err := parseAlertFlags(alertFlags, moveFlags, cooldown)
+			if err != nil {
+				return err
+			}
+			if depth < 0 || depth > 20 {
+				return fmt.Errorf("invalid --depth %d: must be between 0 and 20", depth)
+			}
+			// Only Binance streams order books, so other exchanges hide the
+			// ladder unless --depth asks for it
+			exchange = strings.ToLower(strings.TrimSpace(exchange))
+			if exchange != "" && exchange != "binance" && !cmd.Flags().Changed("depth") {
+				depth = 0
+			}
+
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer cancel()
 
 			cfg := chat.Config{
 				ServerAddr:    serverAddr,
 				Symbol:        symbol,
+				Interval:      interval,
+				Indicators:    specNames,
+				Rules:         ruleFlags,
+				Alerts:        alertRules,
+				DepthLevels:   depth,
+				Exchange:      exchange,
 				OpenRouterKey: keyFlag,
 			}
 			return chat.Run(ctx, cfg)
@@ -64,6 +116,14 @@ func newChatCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&serverAddr, "server", "localhost:50051", "gRPC server address")
 	cmd.Flags().StringVar(&symbol, "symbol", "BTCUSDT", "Trading symbol to subscribe to")
+	cmd.Flags().StringVar(&interval, "interval", "1h", "Candle interval for indicators (1m, 5m, 15m, 30m, 1h, 4h, 1d)")
+	cmd.Flags().StringVar(&indicators, "indicators", "", "Extra indicators as name:params, comma separated (e.g. \"ema:50,ema:200,rsi:7\")")
+	cmd.Flags().StringArrayVar(&ruleFlags, "rule", nil, "Signal rule evaluated by the server, optionally prefixed with \"name:\" (repeatable, e.g. \"oversold: rsi(14) < 30\")")
+	cmd.Flags().StringArrayVar(&alertFlags, "alert", nil, "Alert condition evaluated by the server, optionally prefixed with \"name:\" (repeatable, e.g. \"rsi-high: rsi crosses_above 70\")")
+	cmd.Flags().StringArrayVar(&moveFlags, "alert-move", nil, "Alert on a price move within a window as percent/window (repeatable, e.g. \"2.5%/15m\")")
+	cmd.Flags().DurationVar(&cooldown, "alert-cooldown", 0, "Minimum time between two alerts of the same rule (default 5m on the server)")
+	cmd.Flags().IntVar(&depth, "depth", 5, "Order book levels per side shown in the depth ladder (0 hides it, max 20)")
+	cmd.Flags().StringVar(&exchange, "exchange", "", "Exchange to stream from (binance or kraken; default: the server's)")
 	cmd.Flags().StringVar(&keyFlag, "openrouter-key", "", "OpenRouter API key (fallback to OPENROUTER_KEY env var)")
 
 	return cmd
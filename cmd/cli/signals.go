@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/rp4ri/quantacode/internal/backtest"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+func newSignalsCmd() *cobra.Command {
+	var (
+		file     string
+		exprs    []string
+		everyBar bool
+		defaults = backtest.DefaultConfig()
+		cfg      = defaults
+	)
+
+	cmd := &cobra.Command{
+		Use:   "signals",
+		Short: "Evaluate rule expressions over historical klines",
+		Long: `Replays a CSV or JSON kline file through the indicators and prints every
+closed bar on which a rule becomes true. Rules may be named with a "name:" prefix:
+
+  quantacode signals --file btc-1h.csv \
+    --rule "oversold: rsi(14) < 30 and close > ema(200)" \
+    --rule "golden: ema(50) crosses_above ema(200)"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" {
+				return fmt.Errorf("--file is required")
+			}
+			if len(exprs) == 0 {
+				return fmt.Errorf("at least one --rule is required")
+			}
+
+			var rs []*rules.Rule
+			for _, expr := range exprs {
+				rule, err := rules.ParseNamed(expr)
+				if err != nil {
+					return ruleError("--rule", err)
+				}
+				rs = append(rs, rule)
+			}
+
+			klines, err := backtest.LoadKlines(file)
+			if err != nil {
+				return err
+			}
+			signals, err := backtest.Scan(klines, rs, cfg, everyBar)
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "TIME\tRULE\tCLOSE")
+			for _, s := range signals {
+				fmt.Fprintf(tw, "%s\t%s\t%.2f\n", s.Time.UTC().Format(time.DateTime), s.Rule, s.Close)
+			}
+			tw.Flush()
+			fmt.Fprintf(os.Stderr, "%d signals over %d bars\n", len(signals), len(klines))
+			return nil
+		},
+	}
+
+	addReplayFlags(cmd, &file, &cfg, defaults)
+	cmd.Flags().StringArrayVar(&exprs, "rule", nil, "Rule expression, optionally prefixed with \"name:\" (repeatable)")
+	cmd.Flags().BoolVar(&everyBar, "every-bar", false, "Report every bar on which a rule holds, not only when it becomes true")
+
+	return cmd
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/notify"
+)
+
+// alertConfig is the file named by ALERTS_FILE. String values in webhooks are
+// expanded with environment variables, so secrets can stay out of the file:
+//
+//	{
+//	  "interval": "15m",
+//	  "rules": [
+//	    {"id": "rsi-high", "symbol": "btcusdt", "expression": "rsi crosses_above 70", "cooldown": "30m"},
+//	    {"id": "eth-pump", "symbol": "ethusdt", "move": "3%/1h"}
+//	  ],
+//	  "webhooks": [{"url": "${SLACK_WEBHOOK_URL}", "format": "slack"}],
+//	  "commands": ["notify-send \"$QUANTACODE_ALERT_SYMBOL\" \"$QUANTACODE_ALERT_MESSAGE\""]
+//	}
+type alertConfig struct {
+	Interval string            `json:"interval"`
+	Rules    []alertRuleConfig `json:"rules"`
+	Webhooks []webhookConfig   `json:"webhooks"`
+	Commands []string          `json:"commands"`
+}
+
+type alertRuleConfig struct {
+	ID         string `json:"id"`
+	Symbol     string `json:"symbol"`
+	Expression string `json:"expression"`
+	Move       string `json:"move"` // e.g. "2%/15m"
+	Cooldown   string `json:"cooldown"`
+	Message    string `json:"message"`
+}
+
+type webhookConfig struct {
+	URL      string `json:"url"`
+	Secret   string `json:"secret"`
+	Format   string `json:"format"` // json, slack or discord
+	Attempts int    `json:"attempts"`
+}
+
+// loadAlerts reads an alert file and builds its rule book and delivery sinks.
+func loadAlerts(path string) (cfg alertConfig, book *alerts.Book, sinks []notify.Sink, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, nil, nil, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, nil, nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var rs []alerts.Rule
+	for _, rc := range cfg.Rules {
+		rule, err := rc.rule()
+		if err != nil {
+			return cfg, nil, nil, err
+		}
+		rs = append(rs, rule)
+	}
+	if book, err = alerts.NewBook(rs...); err != nil {
+		return cfg, nil, nil, err
+	}
+
+	for _, wc := range cfg.Webhooks {
+		format, err := notify.ParseFormat(wc.Format)
+		if err != nil {
+			return cfg, nil, nil, err
+		}
+		opts := []notify.WebhookOption{notify.WithFormat(format)}
+		if secret := os.ExpandEnv(wc.Secret); secret != "" {
+			opts = append(opts, notify.WithSecret(secret))
+		}
+		if wc.Attempts > 0 {
+			opts = append(opts, notify.WithRetry(wc.Attempts, 500*time.Millisecond))
+		}
+		hook, err := notify.NewWebhook(os.ExpandEnv(wc.URL), opts...)
+		if err != nil {
+			return cfg, nil, nil, err
+		}
+		sinks = append(sinks, hook)
+	}
+	for _, command := range cfg.Commands {
+		sink, err := notify.NewCommand(command)
+		if err != nil {
+			return cfg, nil, nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(rs) > 0 && len(sinks) == 0 {
+		return cfg, nil, nil, fmt.Errorf("%s: alert rules need at least one webhook or command", path)
+	}
+	return cfg, book, sinks, nil
+}
+
+func (rc alertRuleConfig) rule() (alerts.Rule, error) {
+	rule := alerts.Rule{ID: rc.ID, Symbol: rc.Symbol, Message: rc.Message}
+	if rc.Expression != "" {
+		cond, err := rules.Parse(rc.Expression)
+		if err != nil {
+			return rule, fmt.Errorf("alert %q: %w", rc.ID, err)
+		}
+		rule.Condition = cond
+	}
+	if rc.Move != "" {
+		move, err := alerts.ParseMove(rc.Move)
+		if err != nil {
+			return rule, fmt.Errorf("alert %q: %w", rc.ID, err)
+		}
+		rule.Move = &move
+	}
+	if rc.Cooldown != "" {
+		d, err := time.ParseDuration(rc.Cooldown)
+		if err != nil {
+			return rule, fmt.Errorf("alert %q: invalid cooldown: %w", rc.ID, err)
+		}
+		rule.Cooldown = d
+	}
+	return rule, nil
+}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -14,6 +15,12 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/rp4ri/quantacode/internal/grpc/server"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	"github.com/rp4ri/quantacode/internal/infra/kraken"
+	"github.com/rp4ri/quantacode/internal/infra/notify"
+	"github.com/rp4ri/quantacode/internal/infra/simulator"
+	"github.com/rp4ri/quantacode/internal/infra/store"
 	pb "github.com/rp4ri/quantacode/proto"
 )
 
@@ -29,14 +36,76 @@ func main() {
 	}
 	symbol = strings.ToLower(symbol)
 
+	linger := server.DefaultLinger
+	if v := os.Getenv("HUB_LINGER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("invalid HUB_LINGER %q: %v", v, err)
+		}
+		linger = d
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Create gRPC server (Binance connections are created per-stream)
-	handler := server.NewHandler(symbol)
+	// Create gRPC server (Binance connections are shared per symbol by the hub)
+	newBinance, closeSource := binanceSource()
+	defer closeSource()
+	hub := server.NewExchangeHub(server.DefaultExchange, newBinance, linger)
+	defer hub.Close()
+
+	// Persist ticks and candles so restarts backfill without refetching history
+	if dir := os.Getenv("DATA_DIR"); dir != "" {
+		// Synthetic ticks would be served as real history by later live runs
+		if simulating() || os.Getenv("REPLAY_FILE") != "" {
+			log.Fatal("DATA_DIR cannot be used with simulated or replayed markets")
+		}
+		st, err := store.Open(dir, store.Options{
+			TickRetention:   durationEnv("TICK_RETENTION"),
+			CandleRetention: durationEnv("CANDLE_RETENTION"),
+		})
+		if err != nil {
+			log.Fatalf("open store: %v", err)
+		}
+		defer st.Close()
+		hub.SetStore(st)
+		go pruneStore(ctx, st)
+		log.Printf("storing ticks and candles in %s", dir)
+	}
+	handler := server.NewHandler(symbol, hub)
+
+	// Kraken is selectable per request; its ticks are not recorded in the store
+	krakenHub := server.NewExchangeHub("kraken", func(symbol string) exchange.MarketDataSource {
+		return kraken.NewClient(symbol)
+	}, linger)
+	defer krakenHub.Close()
+	handler.AddExchange(krakenHub)
+
 	grpcServer := grpc.NewServer()
 	pb.RegisterMarketDataServiceServer(grpcServer, handler)
 
+	// Server-side alerts are delivered to webhooks and commands even with no client connected
+	if path := os.Getenv("ALERTS_FILE"); path != "" {
+		alertCfg, book, sinks, err := loadAlerts(path)
+		if err != nil {
+			log.Fatalf("invalid ALERTS_FILE: %v", err)
+		}
+		dispatcher := notify.NewDispatcher(sinks...)
+		defer func() {
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer flushCancel()
+			dispatcher.Close(flushCtx)
+		}()
+		go func() {
+			if err := handler.WatchAlerts(ctx, book, alertCfg.Interval, dispatcher.Notify); err != nil && ctx.Err() == nil {
+				// Shut down gracefully so queued notifications and the store are flushed
+				log.Printf("alert watch: %v", err)
+				cancel()
+			}
+		}()
+		log.Printf("delivering server alerts to %d sinks", len(sinks))
+	}
+
 	// Start listening
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
@@ -72,3 +141,115 @@ func main() {
 		log.Printf("server error: %v", err)
 	}
 }
+
+// simulating reports whether SIMULATE or SCENARIO_FILE asks for simulated markets.
+func simulating() bool {
+	simulate, _ := strconv.ParseBool(os.Getenv("SIMULATE"))
+	return simulate || os.Getenv("SCENARIO_FILE") != ""
+}
+
+// binanceSource returns the Binance sources of the hub: live connections,
+// recorded to RECORD_FILE when set, a replay of REPLAY_FILE at REPLAY_SPEED
+// (1 by default, "10x" or "max"), or simulated markets with SIMULATE or
+// SCENARIO_FILE. The returned function closes the recording.
+func binanceSource() (func(symbol string) exchange.MarketDataSource, func()) {
+	recordPath, replayPath := os.Getenv("RECORD_FILE"), os.Getenv("REPLAY_FILE")
+	simulate := simulating()
+	switch {
+	case recordPath != "" && replayPath != "":
+		log.Fatal("RECORD_FILE and REPLAY_FILE cannot be used together")
+	case simulate && (recordPath != "" || replayPath != ""):
+		log.Fatal("simulated markets cannot be recorded or replayed")
+	case simulate:
+		return simulatedSource(), func() {}
+	case replayPath != "":
+		speed := 1.0
+		if v := os.Getenv("REPLAY_SPEED"); v != "" {
+			var err error
+			if speed, err = binance.ParseSpeed(v); err != nil {
+				log.Fatalf("invalid REPLAY_SPEED: %v", err)
+			}
+		}
+		log.Printf("replaying Binance frames from %s instead of connecting", replayPath)
+		return func(symbol string) exchange.MarketDataSource {
+			return binance.NewReplayClient(symbol, replayPath, speed)
+		}, func() {}
+	case recordPath != "":
+		rec, err := binance.NewRecorder(recordPath)
+		if err != nil {
+			log.Fatalf("open RECORD_FILE: %v", err)
+		}
+		log.Printf("recording Binance frames to %s", recordPath)
+		newSource := func(symbol string) exchange.MarketDataSource {
+			return binance.NewClient(symbol, binance.WithRecorder(rec))
+		}
+		return newSource, func() {
+			if err := rec.Close(); err != nil {
+				log.Printf("close recording: %v", err)
+			}
+		}
+	}
+	return func(symbol string) exchange.MarketDataSource {
+		return binance.NewClient(symbol)
+	}, func() {}
+}
+
+// simulatedSource returns simulated Binance sources. Each symbol's market is
+// deterministic, shifted by SIM_SEED, and follows SCENARIO_FILE from the
+// moment the hub first streams the symbol.
+func simulatedSource() func(symbol string) exchange.MarketDataSource {
+	var seed int64
+	if v := os.Getenv("SIM_SEED"); v != "" {
+		var err error
+		if seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			log.Fatalf("invalid SIM_SEED %q: %v", v, err)
+		}
+	}
+	var scenario *simulator.Scenario
+	if path := os.Getenv("SCENARIO_FILE"); path != "" {
+		var err error
+		if scenario, err = simulator.LoadScenario(path); err != nil {
+			log.Fatalf("invalid SCENARIO_FILE: %v", err)
+		}
+		log.Printf("simulating markets with %d scenario events from %s", len(scenario.Events), path)
+	} else {
+		log.Print("simulating markets instead of connecting to Binance")
+	}
+	return func(symbol string) exchange.MarketDataSource {
+		cfg := simulator.DefaultConfig(symbol)
+		cfg.Seed += seed
+		cfg.Scenario = scenario
+		return binance.NewSimulatedClient(symbol, binance.WithSimulation(cfg))
+	}
+}
+
+// durationEnv parses a duration environment variable; unset yields zero.
+func durationEnv(name string) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("invalid %s %q: %v", name, v, err)
+	}
+	return d
+}
+
+// pruneStore applies the store's retention now and then hourly until ctx is done.
+func pruneStore(ctx context.Context, st *store.Store) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if removed, err := st.Prune(time.Now()); err != nil {
+			log.Printf("store retention: %v", err)
+		} else if removed > 0 {
+			log.Printf("store retention removed %d expired segments", removed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
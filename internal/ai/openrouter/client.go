@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -87,20 +88,78 @@ func (c *Client) WithModel(model string) *Client {
 
 // IndicatorHistory contains historical values for indicators
 type IndicatorHistory struct {
-	RSI []float64
-	SMA []float64
-	EMA []float64
+	Interval      string // candle interval of each history entry (e.g. "1h")
+	RSI           []float64
+	SMA           []float64
+	EMA           []float64
+	MACD          []float64
+	MACDSignal    []float64
+	MACDHistogram []float64
+	BBPercentB    []float64
 }
 
-func (c *Client) buildSystemPrompt(symbol string, price float64, rsi, sma, ema float64, history *IndicatorHistory) string {
+// MACDValues contains the current MACD line, signal line and histogram
+type MACDValues struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// bandPosition describes where %B places the price relative to the bands.
+func bandPosition(percentB float64) string {
+	switch {
+	case percentB > 1:
+		return "por encima de la banda superior"
+	case percentB < 0:
+		return "por debajo de la banda inferior"
+	case percentB >= 0.5:
+		return "entre la media y la banda superior"
+	default:
+		return "entre la banda inferior y la media"
+	}
+}
+
+// BollingerValues contains the current Bollinger Bands and %B
+type BollingerValues struct {
+	Upper    float64
+	Middle   float64
+	Lower    float64
+	PercentB float64
+}
+
+func (c *Client) buildSystemPrompt(symbol string, price float64, rsi, sma, ema float64, macd *MACDValues, bands *BollingerValues, custom map[string]float64, history *IndicatorHistory) string {
 	historyStr := ""
+	timeframeStr := ""
+	macdStr := ""
+	bandsStr := ""
+	customStr := ""
+	if history != nil && history.Interval != "" {
+		timeframeStr = fmt.Sprintf("- Temporalidad de las velas: %s\n", history.Interval)
+	}
+	if macd != nil {
+		macdStr = fmt.Sprintf("- MACD (12, 26, 9): %.2f | Señal: %.2f | Histograma: %.2f\n", macd.MACD, macd.Signal, macd.Histogram)
+	}
+	if bands != nil && bands.Middle != 0 {
+		bandsStr = fmt.Sprintf("- Bandas de Bollinger (20, 2): superior %.2f | media %.2f | inferior %.2f | %%B: %.2f (%s)\n",
+			bands.Upper, bands.Middle, bands.Lower, bands.PercentB, bandPosition(bands.PercentB))
+	}
+	if len(custom) > 0 {
+		keys := make([]string, 0, len(custom))
+		for key := range custom {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			customStr += fmt.Sprintf("- %s: %.2f\n", strings.ToUpper(key), custom[key])
+		}
+	}
 	if history != nil && len(history.RSI) > 0 {
-		historyStr = "\n\nHistorial de indicadores (últimas velas, de más antigua a más reciente):\n"
-		historyStr += "| # | RSI | SMA | EMA |\n"
-		historyStr += "|---|-----|-----|-----|\n"
-		for i := 0; i < len(history.RSI); i++ {
-			historyStr += fmt.Sprintf("| %d | %.2f | %.2f | %.2f |\n", i+1, history.RSI[i], history.SMA[i], history.EMA[i])
+		candles := "últimas velas"
+		if history.Interval != "" {
+			candles = fmt.Sprintf("últimas velas de %s", history.Interval)
 		}
+		historyStr = fmt.Sprintf("\n\nHistorial de indicadores (%s, de más antigua a más reciente):\n", candles)
+		historyStr += historyTable(history)
 	}
 
 	// Get current time in UTC and common trading timezones
@@ -111,11 +170,11 @@ func (c *Client) buildSystemPrompt(symbol string, price float64, rsi, sma, ema f
 Hora actual: %s UTC (%s hora del Este, %s hora del Pacífico)
 
 Datos actuales del mercado:
-- Precio: $%.2f
+%s- Precio: $%.2f
 - RSI (14): %.2f
 - SMA (14): %.2f  
 - EMA (14): %.2f
-%s
+%s%s%s%s
 IMPORTANTE: 
 - Solo proporciona análisis técnico cuando el usuario lo solicite explícitamente (palabras como "analiza", "análisis", "qué opinas del mercado", "señales", etc.)
 - Si el usuario hace una pregunta general o saluda, responde normalmente sin dar análisis no solicitado.
@@ -126,11 +185,49 @@ IMPORTANTE:
 		now.Format("2006-01-02 15:04:05"),
 		now.Add(-5*time.Hour).Format("15:04"),
 		now.Add(-8*time.Hour).Format("15:04"),
-		price, rsi, sma, ema, historyStr)
+		timeframeStr, price, rsi, sma, ema, macdStr, bandsStr, customStr, historyStr)
+}
+
+// historyTable renders the indicator history as a markdown table. Optional
+// columns are only included when their history lines up with the RSI history.
+func historyTable(history *IndicatorHistory) string {
+	columns := []struct {
+		name   string
+		values []float64
+	}{
+		{"RSI", history.RSI},
+		{"SMA", history.SMA},
+		{"EMA", history.EMA},
+		{"MACD", history.MACD},
+		{"Hist. MACD", history.MACDHistogram},
+		{"%B Bollinger", history.BBPercentB},
+	}
+
+	header := "| #"
+	divider := "|---"
+	var included []int
+	for i, col := range columns {
+		if len(col.values) != len(history.RSI) {
+			continue
+		}
+		included = append(included, i)
+		header += " | " + col.name
+		divider += "|" + strings.Repeat("-", len(col.name)+2)
+	}
+
+	table := header + " |\n" + divider + "|\n"
+	for row := range history.RSI {
+		table += fmt.Sprintf("| %d", row+1)
+		for _, i := range included {
+			table += fmt.Sprintf(" | %.2f", columns[i].values[row])
+		}
+		table += " |\n"
+	}
+	return table
 }
 
-func (c *Client) StreamAnalysis(ctx context.Context, userPrompt, symbol string, price, rsi, sma, ema float64, history *IndicatorHistory) (<-chan StreamChunk, error) {
-	systemPrompt := c.buildSystemPrompt(symbol, price, rsi, sma, ema, history)
+func (c *Client) StreamAnalysis(ctx context.Context, userPrompt, symbol string, price, rsi, sma, ema float64, macd *MACDValues, bands *BollingerValues, custom map[string]float64, history *IndicatorHistory) (<-chan StreamChunk, error) {
+	systemPrompt := c.buildSystemPrompt(symbol, price, rsi, sma, ema, macd, bands, custom, history)
 	
 	messages := []Message{
 		{Role: "system", Content: systemPrompt},
@@ -35,6 +35,9 @@ func TestBuildSystemPrompt(t *testing.T) {
 		rsi         float64
 		sma         float64
 		ema         float64
+		macd        *MACDValues
+		bands       *BollingerValues
+		custom      map[string]float64
 		history     *IndicatorHistory
 		wantContain []string
 	}{
@@ -74,6 +77,79 @@ func TestBuildSystemPrompt(t *testing.T) {
 				"70.00",
 			},
 		},
+		{
+			name:   "prompt with timeframe",
+			symbol: "SOLUSDT",
+			price:  150.0,
+			rsi:    45.0,
+			sma:    148.0,
+			ema:    149.0,
+			history: &IndicatorHistory{
+				Interval: "5m",
+				RSI:      []float64{44.0, 45.0},
+				SMA:      []float64{147.0, 148.0},
+				EMA:      []float64{148.5, 149.0},
+			},
+			wantContain: []string{
+				"Temporalidad de las velas: 5m",
+				"últimas velas de 5m",
+			},
+		},
+		{
+			name:   "prompt with MACD",
+			symbol: "BTCUSDT",
+			price:  50000.0,
+			rsi:    60.0,
+			sma:    49800.0,
+			ema:    49900.0,
+			macd:   &MACDValues{MACD: 120.5, Signal: 100.25, Histogram: 20.25},
+			history: &IndicatorHistory{
+				RSI:           []float64{58.0, 60.0},
+				SMA:           []float64{49700.0, 49800.0},
+				EMA:           []float64{49850.0, 49900.0},
+				MACD:          []float64{110.0, 120.5},
+				MACDSignal:    []float64{95.0, 100.25},
+				MACDHistogram: []float64{15.0, 20.25},
+			},
+			wantContain: []string{
+				"MACD (12, 26, 9): 120.50 | Señal: 100.25 | Histograma: 20.25",
+				"| # | RSI | SMA | EMA | MACD | Hist. MACD |",
+				"| 2 | 60.00 | 49800.00 | 49900.00 | 120.50 | 20.25 |",
+			},
+		},
+		{
+			name:   "prompt with Bollinger Bands",
+			symbol: "ETHUSDT",
+			price:  2600.0,
+			rsi:    72.0,
+			sma:    2500.0,
+			ema:    2520.0,
+			bands:  &BollingerValues{Upper: 2580.0, Middle: 2500.0, Lower: 2420.0, PercentB: 1.15},
+			history: &IndicatorHistory{
+				RSI:        []float64{70.0, 72.0},
+				SMA:        []float64{2490.0, 2500.0},
+				EMA:        []float64{2510.0, 2520.0},
+				BBPercentB: []float64{0.95, 1.15},
+			},
+			wantContain: []string{
+				"superior 2580.00 | media 2500.00 | inferior 2420.00 | %B: 1.15",
+				"por encima de la banda superior",
+				"| # | RSI | SMA | EMA | %B Bollinger |",
+				"| 2 | 72.00 | 2500.00 | 2520.00 | 1.15 |",
+			},
+		},
+		{
+			name:   "prompt with custom indicators",
+			symbol: "BTCUSDT",
+			price:  50000.0,
+			rsi:    50.0,
+			sma:    49000.0,
+			ema:    49500.0,
+			custom: map[string]float64{"ema:200": 47000.0, "rsi:7": 61.5},
+			wantContain: []string{
+				"- EMA:200: 47000.00\n- RSI:7: 61.50",
+			},
+		},
 		{
 			name:   "prompt with empty history",
 			symbol: "XRPUSDT",
@@ -95,7 +171,7 @@ func TestBuildSystemPrompt(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := client.buildSystemPrompt(tt.symbol, tt.price, tt.rsi, tt.sma, tt.ema, tt.history)
+			got := client.buildSystemPrompt(tt.symbol, tt.price, tt.rsi, tt.sma, tt.ema, tt.macd, tt.bands, tt.custom, tt.history)
 			for _, want := range tt.wantContain {
 				if !strings.Contains(got, want) {
 					t.Errorf("buildSystemPrompt() missing %q in output:\n%s", want, got)
@@ -107,7 +183,7 @@ func TestBuildSystemPrompt(t *testing.T) {
 
 func TestBuildSystemPromptNoAutoAnalysis(t *testing.T) {
 	client := NewClient("test-key")
-	prompt := client.buildSystemPrompt("BTCUSDT", 50000, 50, 49000, 49500, nil)
+	prompt := client.buildSystemPrompt("BTCUSDT", 50000, 50, 49000, 49500, nil, nil, nil, nil)
 
 	// Verify the prompt instructs AI not to auto-analyze
 	mustContain := []string{
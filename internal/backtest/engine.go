@@ -0,0 +1,222 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+// Strategy is a long-only strategy: enter when Entry fires while flat and exit
+// when Exit fires while holding. Both rules are evaluated on every bar so their
+// crossovers stay in step with the data.
+type Strategy struct {
+	Entry *rules.Rule
+	Exit  *rules.Rule
+}
+
+// Config controls the simulation.
+type Config struct {
+	InitialCapital float64 // starting cash in quote currency
+	FeeRate        float64 // fee per fill as a fraction of notional (0.001 = 0.1%)
+	Slippage       float64 // adverse price move per fill as a fraction of price
+	WarmupBars     int     // bars fed to the indicators before rules are evaluated
+	RSIPeriod      int
+	SMAPeriod      int
+	EMAPeriod      int
+	// PeriodsPerYear annualizes the Sharpe ratio; zero infers it from the kline spacing.
+	PeriodsPerYear float64
+}
+
+// DefaultConfig returns a configuration with Binance spot taker fees and the
+// same indicator periods as the live server.
+func DefaultConfig() Config {
+	return Config{
+		InitialCapital: 10000,
+		FeeRate:        0.001,
+		Slippage:       0.0005,
+		WarmupBars:     50,
+		RSIPeriod:      14,
+		SMAPeriod:      14,
+		EMAPeriod:      14,
+	}
+}
+
+// Trade is a completed round trip.
+type Trade struct {
+	EntryTime  time.Time
+	ExitTime   time.Time
+	EntryPrice float64 // fill price including slippage
+	ExitPrice  float64
+	Quantity   float64
+	Fees       float64
+	PnL        float64 // net of fees
+	Return     float64 // PnL relative to the capital committed at entry
+}
+
+// Run replays klines through the indicators, evaluating the strategy on every
+// closed bar. Signals fill at the next bar's open so rules never see the price
+// they trade at; a position still open at the end is closed at the last close.
+func Run(klines []binance.Kline, strategy Strategy, cfg Config) (*Report, error) {
+	if strategy.Entry == nil || strategy.Exit == nil {
+		return nil, fmt.Errorf("strategy needs both entry and exit rules")
+	}
+	if cfg.InitialCapital <= 0 {
+		return nil, fmt.Errorf("initial capital must be positive")
+	}
+
+	sim := &simulation{cfg: cfg, cash: cfg.InitialCapital}
+	pending := 0 // +1 buy or -1 sell at the next open
+
+	err := replay(klines, cfg, []*rules.Rule{strategy.Entry, strategy.Exit}, func(i int, k binance.Kline, results []rules.Result, warm bool) {
+		switch pending {
+		case 1:
+			sim.buy(k.OpenTime, k.Open)
+		case -1:
+			sim.sell(k.OpenTime, k.Open)
+		}
+		pending = 0
+		sim.equity = append(sim.equity, sim.cash+sim.quantity*k.Close)
+
+		if !warm || i == len(klines)-1 {
+			return
+		}
+		if sim.quantity == 0 && results[0].Value {
+			pending = 1
+		} else if sim.quantity > 0 && results[1].Value {
+			pending = -1
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	last := klines[len(klines)-1]
+	if sim.quantity > 0 {
+		sim.sell(last.CloseTime, last.Close)
+		sim.equity[len(sim.equity)-1] = sim.cash
+	}
+
+	periods := cfg.PeriodsPerYear
+	if periods <= 0 {
+		periods = inferPeriodsPerYear(klines)
+	}
+	return newReport(cfg.InitialCapital, sim, klines, periods), nil
+}
+
+// replay feeds klines through a fresh aggregator and calls fn after each bar with
+// the rules' results. warm is false while the indicators are still warming up.
+func replay(klines []binance.Kline, cfg Config, rs []*rules.Rule, fn func(i int, k binance.Kline, results []rules.Result, warm bool)) error {
+	agg, err := indicators.NewAggregator(cfg.RSIPeriod, cfg.SMAPeriod, cfg.EMAPeriod,
+		indicators.WithSpecs(rules.SpecsOf(rs)...))
+	if err != nil {
+		return fmt.Errorf("create indicators: %w", err)
+	}
+
+	warmup := max(cfg.WarmupBars, agg.SpecWarmup())
+	if len(klines) <= warmup {
+		return fmt.Errorf("need more than %d klines to cover the warmup, got %d", warmup, len(klines))
+	}
+
+	results := make([]rules.Result, len(rs))
+	for i, k := range klines {
+		bar := indicators.Bar{Open: k.Open, High: k.High, Low: k.Low, Close: k.Close, Volume: k.Volume}
+		agg.AddTrade((k.High+k.Low+k.Close)/3, k.Volume, k.OpenTime)
+		snap := rules.Snapshot{Bar: bar, Values: agg.AddBar(bar), Indicators: agg.SpecValues()}
+		for j, r := range rs {
+			results[j] = r.Evaluate(snap)
+		}
+		fn(i, k, results, i >= warmup)
+	}
+	return nil
+}
+
+// simulation tracks a single all-in long position.
+type simulation struct {
+	cfg      Config
+	cash     float64
+	quantity float64
+	open     Trade
+	trades   []Trade
+	equity   []float64
+}
+
+func (s *simulation) buy(ts time.Time, price float64) {
+	fill := price * (1 + s.cfg.Slippage)
+	committed := s.cash
+	s.quantity = committed / (fill * (1 + s.cfg.FeeRate))
+	fee := s.quantity * fill * s.cfg.FeeRate
+	s.cash = 0
+	s.open = Trade{EntryTime: ts, EntryPrice: fill, Quantity: s.quantity, Fees: fee, PnL: -committed}
+}
+
+func (s *simulation) sell(ts time.Time, price float64) {
+	fill := price * (1 - s.cfg.Slippage)
+	proceeds := s.quantity * fill
+	fee := proceeds * s.cfg.FeeRate
+	s.cash += proceeds - fee
+
+	trade := s.open
+	committed := -trade.PnL
+	trade.ExitTime = ts
+	trade.ExitPrice = fill
+	trade.Fees += fee
+	trade.PnL = proceeds - fee - committed
+	trade.Return = trade.PnL / committed
+	s.trades = append(s.trades, trade)
+	s.quantity = 0
+}
+
+// inferPeriodsPerYear estimates how many bars make a year from the first two klines.
+func inferPeriodsPerYear(klines []binance.Kline) float64 {
+	if len(klines) < 2 {
+		return 0
+	}
+	step := klines[1].OpenTime.Sub(klines[0].OpenTime)
+	if step <= 0 {
+		return 0
+	}
+	return float64(365*24*time.Hour) / float64(step)
+}
+
+// sharpe returns the annualized Sharpe ratio of per-bar equity returns with a zero risk-free rate.
+func sharpe(equity []float64, periodsPerYear float64) float64 {
+	if len(equity) < 3 || periodsPerYear <= 0 {
+		return 0
+	}
+	returns := make([]float64, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		returns = append(returns, equity[i]/equity[i-1]-1)
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(periodsPerYear)
+}
+
+// maxDrawdown returns the largest peak-to-trough decline of the equity curve as a fraction.
+func maxDrawdown(equity []float64) float64 {
+	var peak, worst float64
+	for _, e := range equity {
+		peak = math.Max(peak, e)
+		if peak > 0 {
+			worst = math.Max(worst, (peak-e)/peak)
+		}
+	}
+	return worst
+}
@@ -0,0 +1,181 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+// flatThenRamp returns klines that stay at 100 for n bars, then rise by 1 per bar for m bars.
+func flatThenRamp(n, m int) []binance.Kline {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]binance.Kline, n+m)
+	for i := range klines {
+		price := 100.0
+		if i >= n {
+			price += float64(i - n + 1)
+		}
+		klines[i] = binance.Kline{
+			OpenTime:  start.Add(time.Duration(i) * time.Hour),
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+			Volume:    1,
+			CloseTime: start.Add(time.Duration(i+1)*time.Hour - time.Millisecond),
+		}
+	}
+	return klines
+}
+
+func mustRule(t *testing.T, text string) *rules.Rule {
+	t.Helper()
+	rule, err := rules.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", text, err)
+	}
+	return rule
+}
+
+func TestRunFillsAtNextOpen(t *testing.T) {
+	klines := flatThenRamp(10, 10)
+	cfg := Config{InitialCapital: 1000, WarmupBars: 5, RSIPeriod: 3, SMAPeriod: 3, EMAPeriod: 3}
+	strategy := Strategy{
+		Entry: mustRule(t, "close >= 102 and close < 104"),
+		Exit:  mustRule(t, "close >= 106"),
+	}
+
+	report, err := Run(klines, strategy, cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(report.Trades))
+	}
+
+	trade := report.Trades[0]
+	// Signal on the bar closing at 102 fills at the next open (103); exit at 106 fills at 107
+	if trade.EntryPrice != 103 || trade.ExitPrice != 107 {
+		t.Errorf("fills = %.2f -> %.2f, want 103 -> 107", trade.EntryPrice, trade.ExitPrice)
+	}
+	wantPnL := 1000.0/103*107 - 1000
+	if math.Abs(trade.PnL-wantPnL) > 1e-9 || math.Abs(report.PnL-wantPnL) > 1e-9 {
+		t.Errorf("PnL = %.4f (report %.4f), want %.4f", trade.PnL, report.PnL, wantPnL)
+	}
+	if report.WinRate != 1 {
+		t.Errorf("WinRate = %v, want 1", report.WinRate)
+	}
+	if report.MaxDrawdown > 1e-12 {
+		t.Errorf("MaxDrawdown = %v, want 0", report.MaxDrawdown)
+	}
+	if report.Sharpe <= 0 {
+		t.Errorf("Sharpe = %v, want positive", report.Sharpe)
+	}
+}
+
+func TestRunAppliesFeesAndSlippage(t *testing.T) {
+	klines := flatThenRamp(10, 10)
+	cfg := Config{InitialCapital: 1000, FeeRate: 0.001, Slippage: 0.01, WarmupBars: 5, RSIPeriod: 3, SMAPeriod: 3, EMAPeriod: 3}
+	strategy := Strategy{Entry: mustRule(t, "close >= 102 and close < 104"), Exit: mustRule(t, "close >= 106")}
+
+	report, err := Run(klines, strategy, cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	trade := report.Trades[0]
+	if math.Abs(trade.EntryPrice-103*1.01) > 1e-9 || math.Abs(trade.ExitPrice-107*0.99) > 1e-9 {
+		t.Errorf("fills = %.4f -> %.4f, want slippage applied", trade.EntryPrice, trade.ExitPrice)
+	}
+
+	qty := 1000 / (103 * 1.01 * 1.001)
+	want := qty*107*0.99*0.999 - 1000
+	if math.Abs(trade.PnL-want) > 1e-9 {
+		t.Errorf("PnL = %.6f, want %.6f", trade.PnL, want)
+	}
+	if trade.Fees <= 0 {
+		t.Errorf("Fees = %v, want positive", trade.Fees)
+	}
+}
+
+func TestRunClosesOpenPositionAtEnd(t *testing.T) {
+	klines := flatThenRamp(10, 5)
+	cfg := Config{InitialCapital: 1000, WarmupBars: 5, RSIPeriod: 3, SMAPeriod: 3, EMAPeriod: 3}
+	strategy := Strategy{Entry: mustRule(t, "close > 100"), Exit: mustRule(t, "close > 1000")}
+
+	report, err := Run(klines, strategy, cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(report.Trades))
+	}
+	if got := report.Trades[0].ExitPrice; got != 105 {
+		t.Errorf("ExitPrice = %v, want last close 105", got)
+	}
+	if report.FinalEquity != report.Equity[len(report.Equity)-1] {
+		t.Errorf("FinalEquity = %v, want last equity point %v", report.FinalEquity, report.Equity[len(report.Equity)-1])
+	}
+}
+
+func TestRunRequiresWarmup(t *testing.T) {
+	strategy := Strategy{Entry: mustRule(t, "rsi < 30"), Exit: mustRule(t, "rsi > 70")}
+	if _, err := Run(flatThenRamp(5, 0), strategy, DefaultConfig()); err == nil {
+		t.Error("expected error when klines do not cover the warmup")
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	if got := maxDrawdown([]float64{100, 120, 90, 110, 60, 130}); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("maxDrawdown() = %v, want 0.5", got)
+	}
+}
+
+func TestRunWithCrossoverAndRegistryIndicators(t *testing.T) {
+	klines := flatThenRamp(30, 20)
+	cfg := Config{InitialCapital: 1000, WarmupBars: 5, RSIPeriod: 3, SMAPeriod: 3, EMAPeriod: 3}
+	strategy := Strategy{
+		Entry: mustRule(t, "close crosses_above sma(10)"),
+		Exit:  mustRule(t, "close > sma(10) + 5"),
+	}
+
+	report, err := Run(klines, strategy, cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(report.Trades))
+	}
+	// The crossover fires on the first rising bar (close 101) and fills at the next open
+	if got := report.Trades[0].EntryPrice; got != 102 {
+		t.Errorf("EntryPrice = %v, want 102", got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	klines := flatThenRamp(10, 10)
+	cfg := Config{WarmupBars: 5, RSIPeriod: 3, SMAPeriod: 3, EMAPeriod: 3}
+	rule, err := rules.ParseNamed("breakout: close > 105")
+	if err != nil {
+		t.Fatalf("ParseNamed() error = %v", err)
+	}
+
+	signals, err := Scan(klines, []*rules.Rule{rule}, cfg, false)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(signals) != 1 || signals[0].Rule != "breakout" || signals[0].Close != 106 {
+		t.Errorf("signals = %+v, want one breakout at 106", signals)
+	}
+
+	rule.Reset()
+	signals, err = Scan(klines, []*rules.Rule{rule}, cfg, true)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(signals) != 5 {
+		t.Errorf("signals on every bar = %d, want 5", len(signals))
+	}
+}
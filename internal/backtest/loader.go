@@ -0,0 +1,190 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+// LoadKlines reads a kline file, choosing the format from its extension (.csv or .json).
+func LoadKlines(path string) ([]binance.Kline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open kline file: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(f)
+	case ".json":
+		return ReadJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported kline file %q: expected .csv or .json", path)
+	}
+}
+
+// ReadCSV parses klines in the column order of Binance's public data dumps:
+// open_time, open, high, low, close, volume, close_time, ... A header row is skipped.
+// Timestamps may be in milliseconds or microseconds.
+func ReadCSV(r io.Reader) ([]binance.Kline, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var klines []binance.Kline
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		if line == 1 && !isNumber(record[0]) {
+			continue // header
+		}
+		if len(record) < 6 {
+			return nil, fmt.Errorf("csv line %d: expected at least 6 columns, got %d", line, len(record))
+		}
+
+		fields := make([]any, len(record))
+		for i, v := range record {
+			fields[i] = v
+		}
+		k, err := klineFromFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf("csv line %d: %w", line, err)
+		}
+		klines = append(klines, k)
+	}
+	return sortKlines(klines), nil
+}
+
+// ReadJSON parses a JSON array of klines. Each element is either a Binance REST
+// kline array ([openTime, "open", "high", "low", "close", "volume", closeTime, ...])
+// or an object with open_time, open, high, low, close, volume and close_time keys.
+func ReadJSON(r io.Reader) ([]binance.Kline, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode json klines: %w", err)
+	}
+
+	klines := make([]binance.Kline, 0, len(raw))
+	for i, item := range raw {
+		var fields []any
+		if strings.HasPrefix(strings.TrimSpace(string(item)), "[") {
+			if err := json.Unmarshal(item, &fields); err != nil {
+				return nil, fmt.Errorf("kline %d: %w", i, err)
+			}
+		} else {
+			var obj map[string]any
+			if err := json.Unmarshal(item, &obj); err != nil {
+				return nil, fmt.Errorf("kline %d: %w", i, err)
+			}
+			fields = []any{
+				lookup(obj, "open_time", "openTime"),
+				lookup(obj, "open"),
+				lookup(obj, "high"),
+				lookup(obj, "low"),
+				lookup(obj, "close"),
+				lookup(obj, "volume"),
+				lookup(obj, "close_time", "closeTime"),
+			}
+		}
+
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("kline %d: expected at least 6 fields, got %d", i, len(fields))
+		}
+		k, err := klineFromFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf("kline %d: %w", i, err)
+		}
+		klines = append(klines, k)
+	}
+	return sortKlines(klines), nil
+}
+
+// klineFromFields converts open_time, open, high, low, close, volume[, close_time].
+func klineFromFields(fields []any) (binance.Kline, error) {
+	names := []string{"open_time", "open", "high", "low", "close", "volume"}
+	values := make([]float64, len(names))
+	for i, name := range names {
+		v, err := toFloat(fields[i])
+		if err != nil {
+			return binance.Kline{}, fmt.Errorf("%s: %w", name, err)
+		}
+		values[i] = v
+	}
+
+	k := binance.Kline{
+		OpenTime: parseTimestamp(values[0]),
+		Open:     values[1],
+		High:     values[2],
+		Low:      values[3],
+		Close:    values[4],
+		Volume:   values[5],
+	}
+	if len(fields) > 6 && fields[6] != nil {
+		closeTime, err := toFloat(fields[6])
+		if err != nil {
+			return binance.Kline{}, fmt.Errorf("close_time: %w", err)
+		}
+		k.CloseTime = parseTimestamp(closeTime)
+	}
+	return k, nil
+}
+
+// parseTimestamp accepts Unix milliseconds or microseconds.
+func parseTimestamp(v float64) time.Time {
+	ts := int64(v)
+	if ts > 1e15 {
+		return time.UnixMicro(ts)
+	}
+	return time.UnixMilli(ts)
+}
+
+func toFloat(v any) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", x)
+		}
+		return f, nil
+	case nil:
+		return 0, fmt.Errorf("missing value")
+	default:
+		return 0, fmt.Errorf("unexpected value %v", v)
+	}
+}
+
+func lookup(obj map[string]any, keys ...string) any {
+	for _, key := range keys {
+		if v, ok := obj[key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return err == nil
+}
+
+func sortKlines(klines []binance.Kline) []binance.Kline {
+	sort.SliceStable(klines, func(i, j int) bool { return klines[i].OpenTime.Before(klines[j].OpenTime) })
+	return klines
+}
@@ -0,0 +1,58 @@
+package backtest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := `open_time,open,high,low,close,volume,close_time
+1700003600000,101,103,100,102,5,1700007199999
+1700000000000,100,102,99,101,4,1700003599999
+`
+	klines, err := ReadCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	if len(klines) != 2 {
+		t.Fatalf("len(klines) = %d, want 2", len(klines))
+	}
+	if !klines[0].OpenTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("klines not sorted by open time: first = %v", klines[0].OpenTime)
+	}
+	if k := klines[1]; k.Open != 101 || k.High != 103 || k.Low != 100 || k.Close != 102 || k.Volume != 5 {
+		t.Errorf("klines[1] = %+v", k)
+	}
+}
+
+func TestReadCSVMicroseconds(t *testing.T) {
+	klines, err := ReadCSV(strings.NewReader("1735689600000000,1,1,1,1,1,1735693199999999\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	if want := time.UnixMilli(1735689600000); !klines[0].OpenTime.Equal(want) {
+		t.Errorf("OpenTime = %v, want %v", klines[0].OpenTime, want)
+	}
+}
+
+func TestReadCSVRejectsShortRows(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("1700000000000,1,2,3\n")); err == nil {
+		t.Error("expected error for row with too few columns")
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	rest := `[[1700000000000,"100","102","99","101","4",1700003599999]]`
+	objects := `[{"open_time":1700000000000,"open":100,"high":102,"low":99,"close":101,"volume":4,"close_time":1700003599999}]`
+
+	for name, input := range map[string]string{"rest": rest, "objects": objects} {
+		klines, err := ReadJSON(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: ReadJSON() error = %v", name, err)
+		}
+		if len(klines) != 1 || klines[0].Close != 101 || klines[0].High != 102 {
+			t.Errorf("%s: klines = %+v", name, klines)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+// Report summarizes a backtest.
+type Report struct {
+	Start            time.Time
+	End              time.Time
+	Bars             int
+	InitialCapital   float64
+	FinalEquity      float64
+	PnL              float64
+	TotalReturn      float64
+	BuyAndHoldReturn float64
+	Trades           []Trade
+	WinRate          float64
+	MaxDrawdown      float64
+	Sharpe           float64
+	TotalFees        float64
+	Equity           []float64 `json:"-"`
+}
+
+func newReport(initial float64, sim *simulation, klines []binance.Kline, periodsPerYear float64) *Report {
+	first, last := klines[0], klines[len(klines)-1]
+	final := sim.equity[len(sim.equity)-1]
+
+	r := &Report{
+		Start:            first.OpenTime,
+		End:              last.OpenTime,
+		Bars:             len(klines),
+		InitialCapital:   initial,
+		FinalEquity:      final,
+		PnL:              final - initial,
+		TotalReturn:      final/initial - 1,
+		BuyAndHoldReturn: last.Close/first.Open - 1,
+		Trades:           sim.trades,
+		MaxDrawdown:      maxDrawdown(sim.equity),
+		Sharpe:           sharpe(sim.equity, periodsPerYear),
+		Equity:           sim.equity,
+	}
+
+	wins := 0
+	for _, t := range sim.trades {
+		if t.PnL > 0 {
+			wins++
+		}
+		r.TotalFees += t.Fees
+	}
+	if len(sim.trades) > 0 {
+		r.WinRate = float64(wins) / float64(len(sim.trades))
+	}
+	return r
+}
+
+// Print writes a human-readable summary followed by the trade list.
+func (r *Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Period\t%s → %s (%d bars)\n", r.Start.UTC().Format(time.DateTime), r.End.UTC().Format(time.DateTime), r.Bars)
+	fmt.Fprintf(tw, "Initial capital\t%.2f\n", r.InitialCapital)
+	fmt.Fprintf(tw, "Final equity\t%.2f\n", r.FinalEquity)
+	fmt.Fprintf(tw, "PnL\t%.2f (%.2f%%)\n", r.PnL, r.TotalReturn*100)
+	fmt.Fprintf(tw, "Buy & hold\t%.2f%%\n", r.BuyAndHoldReturn*100)
+	fmt.Fprintf(tw, "Trades\t%d\n", len(r.Trades))
+	fmt.Fprintf(tw, "Win rate\t%.2f%%\n", r.WinRate*100)
+	fmt.Fprintf(tw, "Max drawdown\t%.2f%%\n", r.MaxDrawdown*100)
+	fmt.Fprintf(tw, "Sharpe\t%.2f\n", r.Sharpe)
+	fmt.Fprintf(tw, "Fees\t%.2f\n", r.TotalFees)
+	tw.Flush()
+
+	if len(r.Trades) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\tEntry\tEntry price\tExit\tExit price\tPnL\tReturn\t")
+	for i, t := range r.Trades {
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%.2f\t%.2f\t%.2f%%\t\n", i+1,
+			t.EntryTime.UTC().Format(time.DateTime), t.EntryPrice,
+			t.ExitTime.UTC().Format(time.DateTime), t.ExitPrice,
+			t.PnL, t.Return*100)
+	}
+	tw.Flush()
+}
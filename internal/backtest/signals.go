@@ -0,0 +1,36 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+// Signal is a rule firing on a closed bar.
+type Signal struct {
+	Time  time.Time // bar open time
+	Rule  string
+	Close float64
+}
+
+// Scan evaluates rules over klines and returns a signal for every closed bar
+// after the warmup where a rule became true. With everyBar set, every bar on
+// which a rule holds is reported instead of only the transitions.
+func Scan(klines []binance.Kline, rs []*rules.Rule, cfg Config, everyBar bool) ([]Signal, error) {
+	var signals []Signal
+	err := replay(klines, cfg, rs, func(i int, k binance.Kline, results []rules.Result, warm bool) {
+		if !warm {
+			return
+		}
+		for j, res := range results {
+			if res.Triggered || (everyBar && res.Value) {
+				signals = append(signals, Signal{Time: k.OpenTime, Rule: rs[j].Name, Close: k.Close})
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
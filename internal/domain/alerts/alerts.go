@@ -0,0 +1,115 @@
+// Package alerts evaluates per-symbol alert rules on a live price stream with
+// edge-triggering and per-rule cooldowns.
+package alerts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+// DefaultCooldown is the minimum time between two alerts of a rule when none is set.
+const DefaultCooldown = 5 * time.Minute
+
+// Move is a price move of at least Percent, up or down, within Window.
+type Move struct {
+	Percent float64
+	Window  time.Duration
+}
+
+// ParseMove parses a price move such as "2.5%/15m" or "3/1h". Windows are whole
+// minutes, the resolution alerts travel at over gRPC.
+func ParseMove(text string) (Move, error) {
+	pct, window, ok := strings.Cut(strings.TrimSpace(text), "/")
+	if !ok {
+		return Move{}, fmt.Errorf("move %q: expected percent/window, e.g. 2%%/15m", text)
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(pct), "%"), 64)
+	if err != nil || percent <= 0 {
+		return Move{}, fmt.Errorf("move %q: invalid percent %q", text, pct)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(window))
+	if err != nil || d < time.Minute || d%time.Minute != 0 {
+		return Move{}, fmt.Errorf("move %q: window must be a whole number of minutes, at least 1m", text)
+	}
+	return Move{Percent: percent, Window: d}, nil
+}
+
+// Rule is an alert definition. Exactly one of Condition and Move must be set.
+type Rule struct {
+	ID string
+	// Symbol to watch, case-insensitive; empty watches every symbol.
+	Symbol    string
+	Condition *rules.Rule
+	Move      *Move
+	// Cooldown is the minimum time between alerts; zero uses DefaultCooldown.
+	Cooldown time.Duration
+	// Message replaces the condition description in alerts.
+	Message string
+}
+
+// Describe returns the rule's condition in text form.
+func (r Rule) Describe() string {
+	if r.Condition != nil {
+		return r.Condition.Source()
+	}
+	if r.Move != nil {
+		return fmt.Sprintf("move >= %g%% in %s", r.Move.Percent, formatWindow(r.Move.Window))
+	}
+	return ""
+}
+
+func (r Rule) validate() error {
+	if strings.TrimSpace(r.ID) == "" {
+		return fmt.Errorf("alert rule needs an id")
+	}
+	switch {
+	case r.Condition != nil && r.Move != nil:
+		return fmt.Errorf("alert %q: set either a condition or a move, not both", r.ID)
+	case r.Condition == nil && r.Move == nil:
+		return fmt.Errorf("alert %q: missing condition", r.ID)
+	case r.Move != nil && r.Move.Percent <= 0:
+		return fmt.Errorf("alert %q: move percent must be positive", r.ID)
+	case r.Move != nil && r.Move.Window <= 0:
+		return fmt.Errorf("alert %q: move window must be positive", r.ID)
+	case r.Cooldown < 0:
+		return fmt.Errorf("alert %q: cooldown cannot be negative", r.ID)
+	}
+	return nil
+}
+
+func (r Rule) cooldown() time.Duration {
+	if r.Cooldown == 0 {
+		return DefaultCooldown
+	}
+	return r.Cooldown
+}
+
+func (r Rule) matches(symbol string) bool {
+	return r.Symbol == "" || strings.EqualFold(r.Symbol, symbol)
+}
+
+// Alert is a rule firing on a symbol.
+type Alert struct {
+	RuleID    string
+	Symbol    string
+	Message   string
+	Condition string
+	Price     float64
+	Time      time.Time
+}
+
+// formatWindow prints whole minutes and hours without trailing zero units.
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,296 @@
+package alerts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+// Book holds the alert rules of one client and their per-symbol state. It is
+// safe for concurrent use by the pipelines of different symbols.
+type Book struct {
+	mu     sync.Mutex
+	rules  []Rule
+	states map[stateKey]*state
+}
+
+type stateKey struct {
+	rule   string
+	symbol string
+}
+
+// state tracks one rule on one symbol.
+type state struct {
+	cond      *rules.Rule // private copy so crossovers are tracked per symbol
+	primed    bool
+	armed     bool
+	lastFired time.Time
+	window    priceWindow
+}
+
+// NewBook creates a Book with the given rules.
+func NewBook(rs ...Rule) (*Book, error) {
+	b := &Book{states: make(map[stateKey]*state)}
+	if err := b.Add(rs...); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add registers rules. A rule with the id of an existing one replaces it and
+// starts from a fresh state.
+func (b *Book) Add(rs ...Rule) error {
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		if err := r.validate(); err != nil {
+			return err
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate alert id %q", r.ID)
+		}
+		seen[r.ID] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, r := range rs {
+		b.dropStates(r.ID)
+		if i := b.index(r.ID); i >= 0 {
+			b.rules[i] = r
+		} else {
+			b.rules = append(b.rules, r)
+		}
+	}
+	return nil
+}
+
+// Remove deletes rules by id. Unknown ids are reported after removing the known ones.
+func (b *Book) Remove(ids ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missing []string
+	for _, id := range ids {
+		i := b.index(id)
+		if i < 0 {
+			missing = append(missing, id)
+			continue
+		}
+		b.rules = append(b.rules[:i], b.rules[i+1:]...)
+		b.dropStates(id)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unknown alert ids: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Rules returns the registered rules in registration order.
+func (b *Book) Rules() []Rule {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Rule(nil), b.rules...)
+}
+
+// Watching reports whether any rule applies to symbol.
+func (b *Book) Watching(symbol string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, r := range b.rules {
+		if r.matches(symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+// Specs returns the registry indicators used by the conditions that apply to symbol.
+func (b *Book) Specs(symbol string) []indicators.Spec {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var conds []*rules.Rule
+	for _, r := range b.rules {
+		if r.Condition != nil && r.matches(symbol) {
+			conds = append(conds, r.Condition)
+		}
+	}
+	return rules.SpecsOf(conds)
+}
+
+// CloseBar records a closed bar for the crossover conditions that apply to symbol.
+func (b *Book) CloseBar(symbol string, closed rules.Env) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, r := range b.rules {
+		if r.Condition == nil || !r.matches(symbol) {
+			continue
+		}
+		st := b.state(r, symbol)
+		st.cond.Evaluate(closed)
+		st.primed = true
+	}
+}
+
+// Tick evaluates every rule that applies to symbol at a new price. Conditions
+// are evaluated against live, the values including the in-progress bar; closed
+// holds the last closed bar and primes crossovers of rules added mid-bar.
+// It returns the alerts that fired, in rule order.
+func (b *Book) Tick(symbol string, price float64, ts time.Time, closed, live rules.Env) []Alert {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var fired []Alert
+	for _, r := range b.rules {
+		if !r.matches(symbol) {
+			continue
+		}
+		st := b.state(r, symbol)
+
+		var active bool
+		detail := ""
+		if r.Condition != nil {
+			if !st.primed {
+				st.cond.Evaluate(closed)
+				st.primed = true
+			}
+			active = st.cond.Peek(live).Value
+		} else {
+			st.window.add(price, ts, r.Move.Window)
+			var change float64
+			active, change = st.window.moved(price, r.Move.Percent)
+			detail = fmt.Sprintf("%+.2f%% in %s", change, formatWindow(r.Move.Window))
+		}
+
+		if !active {
+			st.armed = true
+			continue
+		}
+		if !st.armed {
+			continue
+		}
+		// Edge consumed even when the cooldown suppresses the alert
+		st.armed = false
+		if !st.lastFired.IsZero() && ts.Sub(st.lastFired) < r.cooldown() {
+			continue
+		}
+		st.lastFired = ts
+
+		message := r.Message
+		if message == "" {
+			message = r.Describe()
+			if detail != "" {
+				message = "price moved " + detail
+			}
+		}
+		fired = append(fired, Alert{
+			RuleID:    r.ID,
+			Symbol:    strings.ToUpper(symbol),
+			Message:   message,
+			Condition: r.Describe(),
+			Price:     price,
+			Time:      ts,
+		})
+	}
+	return fired
+}
+
+// state returns the state of r on symbol, creating it on first use. Must be called with b.mu held.
+func (b *Book) state(r Rule, symbol string) *state {
+	key := stateKey{rule: r.ID, symbol: strings.ToUpper(symbol)}
+	st, ok := b.states[key]
+	if !ok {
+		st = &state{armed: true}
+		if r.Condition != nil {
+			st.cond = r.Condition.Clone()
+			st.cond.Reset()
+		}
+		b.states[key] = st
+	}
+	return st
+}
+
+// dropStates forgets the state of a rule on every symbol. Must be called with b.mu held.
+func (b *Book) dropStates(id string) {
+	for key := range b.states {
+		if key.rule == id {
+			delete(b.states, key)
+		}
+	}
+}
+
+func (b *Book) index(id string) int {
+	for i, r := range b.rules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// priceWindow keeps the low and high of each second within a trailing window.
+type priceWindow struct {
+	buckets []bucket
+}
+
+type bucket struct {
+	second    int64
+	low, high float64
+}
+
+func (w *priceWindow) add(price float64, ts time.Time, window time.Duration) {
+	sec := ts.Unix()
+	if n := len(w.buckets); n > 0 && w.buckets[n-1].second == sec {
+		last := &w.buckets[n-1]
+		last.low = min(last.low, price)
+		last.high = max(last.high, price)
+	} else {
+		w.buckets = append(w.buckets, bucket{second: sec, low: price, high: price})
+	}
+
+	cutoff := ts.Add(-window).Unix()
+	drop := sort.Search(len(w.buckets), func(i int) bool { return w.buckets[i].second >= cutoff })
+	w.buckets = w.buckets[drop:]
+}
+
+// moved reports whether price is at least percent above the window's low or
+// below its high, and the larger of the two changes in percent.
+func (w *priceWindow) moved(price, percent float64) (bool, float64) {
+	if len(w.buckets) == 0 {
+		return false, 0
+	}
+	low, high := w.buckets[0].low, w.buckets[0].high
+	for _, b := range w.buckets[1:] {
+		low = min(low, b.low)
+		high = max(high, b.high)
+	}
+
+	var up, down float64
+	if low > 0 {
+		up = (price/low - 1) * 100
+	}
+	if high > 0 {
+		down = (price/high - 1) * 100
+	}
+	change := up
+	if -down > up {
+		change = down
+	}
+	return change >= percent || -change >= percent, change
+}
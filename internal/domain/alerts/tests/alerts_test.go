@@ -0,0 +1,173 @@
+package alerts_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func condition(t *testing.T, src string) *rules.Rule {
+	t.Helper()
+	r, err := rules.Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", src, err)
+	}
+	return r
+}
+
+func env(close, rsi float64) rules.Snapshot {
+	return rules.Snapshot{Bar: indicators.Bar{Close: close}, Values: indicators.AggregatedValues{RSI: rsi}}
+}
+
+func TestThresholdIsEdgeTriggered(t *testing.T) {
+	book, err := alerts.NewBook(alerts.Rule{ID: "hot", Condition: condition(t, "rsi > 70"), Cooldown: time.Second})
+	if err != nil {
+		t.Fatalf("NewBook() error = %v", err)
+	}
+
+	var fired []int
+	for i, rsi := range []float64{65, 72, 75, 71, 60, 73} {
+		ts := t0.Add(time.Duration(i) * time.Minute)
+		if got := book.Tick("btcusdt", 100, ts, env(100, 65), env(100, rsi)); len(got) > 0 {
+			fired = append(fired, i)
+		}
+	}
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 5 {
+		t.Errorf("fired at steps %v, want [1 5]", fired)
+	}
+}
+
+func TestCooldownSuppressesRepeatedEdges(t *testing.T) {
+	book, _ := alerts.NewBook(alerts.Rule{ID: "hot", Condition: condition(t, "rsi > 70"), Cooldown: 10 * time.Minute})
+
+	count := 0
+	for i, rsi := range []float64{75, 60, 75, 60, 75} {
+		ts := t0.Add(time.Duration(i) * 4 * time.Minute)
+		count += len(book.Tick("btcusdt", 100, ts, env(100, 50), env(100, rsi)))
+	}
+	// Fires at 0m and 16m; the edge at 8m falls inside the cooldown
+	if count != 2 {
+		t.Errorf("alerts = %d, want 2", count)
+	}
+}
+
+func TestCrossoverUsesClosedBarAndPrimesMidBar(t *testing.T) {
+	book, _ := alerts.NewBook(alerts.Rule{ID: "cross", Symbol: "BTCUSDT", Condition: condition(t, "rsi crosses_above 70"), Message: "RSI above 70"})
+
+	// Added mid-bar: the closed bar (rsi 65) primes the crossover
+	got := book.Tick("btcusdt", 100, t0, env(100, 65), env(100, 72))
+	if len(got) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(got))
+	}
+	a := got[0]
+	if a.RuleID != "cross" || a.Symbol != "BTCUSDT" || a.Message != "RSI above 70" || a.Condition != "rsi crosses_above 70" || a.Price != 100 {
+		t.Errorf("alert = %+v", a)
+	}
+
+	// Once the bar closes above 70 the crossover is over
+	book.CloseBar("btcusdt", env(100, 72))
+	if got := book.Tick("btcusdt", 100, t0.Add(time.Hour), env(100, 72), env(100, 74)); len(got) != 0 {
+		t.Errorf("alerts after close = %v, want none", got)
+	}
+
+	if got := book.Tick("ethusdt", 100, t0, env(100, 65), env(100, 72)); len(got) != 0 {
+		t.Errorf("rule for BTCUSDT fired on ETHUSDT: %v", got)
+	}
+}
+
+func TestCrossoverStateIsPerSymbol(t *testing.T) {
+	book, _ := alerts.NewBook(alerts.Rule{ID: "cross", Condition: condition(t, "close crosses_above 100")})
+	book.CloseBar("btcusdt", env(99, 0))
+	book.CloseBar("ethusdt", env(101, 0))
+
+	if got := book.Tick("btcusdt", 101, t0, env(99, 0), env(101, 0)); len(got) != 1 {
+		t.Errorf("btcusdt alerts = %d, want 1", len(got))
+	}
+	if got := book.Tick("ethusdt", 102, t0, env(101, 0), env(102, 0)); len(got) != 0 {
+		t.Errorf("ethusdt alerts = %d, want 0", len(got))
+	}
+}
+
+func TestPriceMove(t *testing.T) {
+	book, _ := alerts.NewBook(alerts.Rule{ID: "pump", Move: &alerts.Move{Percent: 2, Window: 15 * time.Minute}, Cooldown: time.Second})
+
+	tick := func(price float64, after time.Duration) []alerts.Alert {
+		return book.Tick("solusdt", price, t0.Add(after), nil, nil)
+	}
+	if got := tick(100, 0); len(got) != 0 {
+		t.Fatalf("first tick fired: %v", got)
+	}
+	if got := tick(101.5, 5*time.Minute); len(got) != 0 {
+		t.Fatalf("1.5%% move fired: %v", got)
+	}
+	got := tick(102.5, 10*time.Minute)
+	if len(got) != 1 {
+		t.Fatalf("alerts = %d, want 1", len(got))
+	}
+	if !strings.Contains(got[0].Message, "+2.50% in 15m") || got[0].Condition != "move >= 2% in 15m" {
+		t.Errorf("alert = %+v", got[0])
+	}
+
+	// The low of 100 ages out of the window, so the move ends and re-arms
+	if got := tick(102.5, 20*time.Minute); len(got) != 0 {
+		t.Fatalf("alerts = %v, want none once the low left the window", got)
+	}
+	if got := tick(100.3, 24*time.Minute); len(got) != 1 || !strings.Contains(got[0].Message, "-2.15%") {
+		t.Errorf("drop alerts = %+v, want one -2.15%% move", got)
+	}
+}
+
+func TestAddReplaceRemove(t *testing.T) {
+	book, _ := alerts.NewBook()
+	if err := book.Add(alerts.Rule{ID: "a"}); err == nil {
+		t.Error("Add() should reject a rule without condition")
+	}
+	if err := book.Add(alerts.Rule{ID: "m", Move: &alerts.Move{Percent: 1}}); err == nil {
+		t.Error("Add() should reject a move without window")
+	}
+
+	if err := book.Add(
+		alerts.Rule{ID: "a", Condition: condition(t, "rsi(7) > 70")},
+		alerts.Rule{ID: "b", Symbol: "ETHUSDT", Condition: condition(t, "close > ema(50)")},
+	); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := book.Add(alerts.Rule{ID: "a", Condition: condition(t, "rsi < 30")}); err != nil {
+		t.Fatalf("Add() replace error = %v", err)
+	}
+	if rs := book.Rules(); len(rs) != 2 || rs[0].Describe() != "rsi < 30" {
+		t.Errorf("Rules() = %+v, want a replaced in place", rs)
+	}
+
+	if specs := book.Specs("ethusdt"); len(specs) != 1 || specs[0].Key() != "ema:50" {
+		t.Errorf("Specs(ethusdt) = %v, want [ema:50]", specs)
+	}
+	if specs := book.Specs("btcusdt"); len(specs) != 0 {
+		t.Errorf("Specs(btcusdt) = %v, want none", specs)
+	}
+
+	if err := book.Remove("a", "missing"); err == nil {
+		t.Error("Remove() should report unknown ids")
+	}
+	if !book.Watching("ETHUSDT") || book.Watching("btcusdt") {
+		t.Error("Watching() should only match the remaining ETHUSDT rule")
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	m, err := alerts.ParseMove("2.5%/15m")
+	if err != nil || m.Percent != 2.5 || m.Window != 15*time.Minute {
+		t.Errorf("ParseMove(2.5%%/15m) = %+v, %v", m, err)
+	}
+	for _, bad := range []string{"2%", "0%/15m", "x/15m", "2%/10s", "2%/90s", "2%/1m30s", "2%/soon"} {
+		if _, err := alerts.ParseMove(bad); err == nil {
+			t.Errorf("ParseMove(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package candles
+
+import (
+	"fmt"
+	"time"
+)
+
+// Builder turns price ticks into OHLCV bars of a fixed interval. Bars are
+// aligned to multiples of the interval since the Unix epoch, matching Binance klines.
+type Builder struct {
+	interval time.Duration
+	live     Candle
+	hasLive  bool
+	last     Candle
+	hasLast  bool
+}
+
+// NewBuilder creates a Builder for the given bar interval.
+func NewBuilder(interval time.Duration) (*Builder, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	return &Builder{interval: interval}, nil
+}
+
+// Interval returns the configured bar interval.
+func (b *Builder) Interval() time.Duration {
+	return b.interval
+}
+
+// Seed continues from historical bars (oldest first). Bars that closed before
+// now are returned as closed; a bar still open at now becomes the live bar so
+// that subsequent ticks extend it instead of starting a new one.
+func (b *Builder) Seed(history []Candle, now time.Time) []Candle {
+	closed := make([]Candle, 0, len(history))
+	for _, c := range history {
+		if b.hasLast && !c.OpenTime.After(b.last.OpenTime) {
+			continue
+		}
+		if c.CloseTime.Before(now) {
+			closed = append(closed, c)
+			b.last = c
+			b.hasLast = true
+			continue
+		}
+		b.live = c
+		b.hasLive = true
+	}
+	return closed
+}
+
+// Add ingests a tick and returns any bars closed by it. volume should be the
+// traded quantity of the tick, or zero for quote-only updates.
+func (b *Builder) Add(price, volume float64, ts time.Time) []Candle {
+	openTime := OpenTime(ts, b.interval)
+
+	if !b.hasLive {
+		if b.hasLast && openTime.Before(b.last.CloseTime) {
+			// Late tick for a bar that has already closed
+			return nil
+		}
+		b.startBar(openTime, price, volume)
+		return nil
+	}
+
+	if openTime.Before(b.live.OpenTime) {
+		return nil
+	}
+
+	if openTime.Equal(b.live.OpenTime) {
+		if price > b.live.High {
+			b.live.High = price
+		}
+		if price < b.live.Low {
+			b.live.Low = price
+		}
+		b.live.Close = price
+		b.live.Volume += volume
+		return nil
+	}
+
+	closed := b.live
+	b.last = closed
+	b.hasLast = true
+	b.startBar(openTime, price, volume)
+	return []Candle{closed}
+}
+
+// Live returns the in-progress bar, if any.
+func (b *Builder) Live() (Candle, bool) {
+	return b.live, b.hasLive
+}
+
+// Last returns the most recently closed bar, if any.
+func (b *Builder) Last() (Candle, bool) {
+	return b.last, b.hasLast
+}
+
+func (b *Builder) startBar(openTime time.Time, price, volume float64) {
+	b.live = Candle{
+		OpenTime:  openTime,
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Volume:    volume,
+		CloseTime: openTime.Add(b.interval - time.Millisecond),
+	}
+	b.hasLive = true
+}
@@ -0,0 +1,64 @@
+package candles
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Candle is a single OHLCV bar.
+type Candle struct {
+	OpenTime  time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	CloseTime time.Time
+}
+
+// ParseInterval converts a Binance-style interval (1m, 5m, 1h, 4h, 1d, 1w) to a duration.
+func ParseInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+
+	var unit time.Duration
+	switch interval[len(interval)-1] {
+	case 's':
+		unit = time.Second
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	case 'w':
+		unit = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid interval %q", interval)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+const week = 7 * 24 * time.Hour
+
+// OpenTime returns the open time of the bar of the given length containing ts.
+// Bars are aligned to the Unix epoch like Binance klines, except weekly ones,
+// which open on Monday 00:00 UTC.
+func OpenTime(ts time.Time, length time.Duration) time.Time {
+	origin := time.Unix(0, 0)
+	if length%week == 0 {
+		origin = origin.Add(4 * 24 * time.Hour) // the epoch is a Thursday
+	}
+	offset := ts.Sub(origin) % length
+	if offset < 0 {
+		offset += length
+	}
+	return ts.Add(-offset).Round(0).In(ts.Location())
+}
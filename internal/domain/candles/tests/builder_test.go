@@ -0,0 +1,164 @@
+package candles_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"1m", time.Minute, false},
+		{"5m", 5 * time.Minute, false},
+		{"1h", time.Hour, false},
+		{"4h", 4 * time.Hour, false},
+		{"1d", 24 * time.Hour, false},
+		{"1w", 7 * 24 * time.Hour, false},
+		{"", 0, true},
+		{"h", 0, true},
+		{"0m", 0, true},
+		{"3x", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := candles.ParseInterval(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInterval(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseInterval(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenTime(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name   string
+		ts     time.Time
+		length time.Duration
+		want   time.Time
+	}{
+		{"hour", time.Date(2024, 3, 6, 12, 34, 5, 0, time.UTC), time.Hour, time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)},
+		{"three days from the epoch", time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC), 3 * day, time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"week from Wednesday", time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC), 7 * day, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"week from Sunday", time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC), 7 * day, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"week from Monday", time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), 7 * day, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := candles.OpenTime(tt.ts, tt.length); !got.Equal(tt.want) {
+				t.Errorf("OpenTime(%v, %v) = %v, want %v", tt.ts, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilderWeeklyBarsOpenOnMonday(t *testing.T) {
+	week := 7 * 24 * time.Hour
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	b, err := candles.NewBuilder(week)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Seed([]candles.Candle{{OpenTime: monday, Open: 100, High: 100, Low: 100, Close: 100, CloseTime: monday.Add(week - time.Millisecond)}}, monday.Add(time.Hour))
+
+	// Ticks before Thursday extend the Monday bar instead of being dropped
+	if closed := b.Add(90, 1, monday.Add(30*time.Hour)); len(closed) != 0 {
+		t.Fatalf("Add() closed %v on Tuesday", closed)
+	}
+	if closed := b.Add(110, 1, monday.Add(4*24*time.Hour)); len(closed) != 0 {
+		t.Fatalf("Add() closed %v on Friday", closed)
+	}
+	closed := b.Add(120, 1, monday.Add(week))
+	if len(closed) != 1 || !closed[0].OpenTime.Equal(monday) || closed[0].Low != 90 || closed[0].High != 110 {
+		t.Errorf("Add() on the next Monday closed %+v, want the Monday bar with low 90 and high 110", closed)
+	}
+}
+
+func TestBuilderAggregatesTicks(t *testing.T) {
+	b, err := candles.NewBuilder(time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	ticks := []struct {
+		price, volume float64
+		offset        time.Duration
+	}{
+		{100, 1, 5 * time.Second},
+		{105, 2, 20 * time.Second},
+		{95, 1, 40 * time.Second},
+		{101, 0.5, 59 * time.Second},
+	}
+	for _, tick := range ticks {
+		if closed := b.Add(tick.price, tick.volume, start.Add(tick.offset)); len(closed) != 0 {
+			t.Fatalf("no bar should close within the first minute, got %d", len(closed))
+		}
+	}
+
+	live, ok := b.Live()
+	if !ok {
+		t.Fatal("expected a live bar")
+	}
+	if live.Open != 100 || live.High != 105 || live.Low != 95 || live.Close != 101 || live.Volume != 4.5 {
+		t.Fatalf("live bar = %+v", live)
+	}
+	if !live.OpenTime.Equal(start) {
+		t.Fatalf("live bar open time = %v, want %v", live.OpenTime, start)
+	}
+
+	closed := b.Add(102, 1, start.Add(61*time.Second))
+	if len(closed) != 1 {
+		t.Fatalf("expected one closed bar, got %d", len(closed))
+	}
+	if closed[0].Close != 101 {
+		t.Fatalf("closed bar close = %v, want 101", closed[0].Close)
+	}
+
+	live, _ = b.Live()
+	if live.Open != 102 || !live.OpenTime.Equal(start.Add(time.Minute)) {
+		t.Fatalf("new live bar = %+v", live)
+	}
+}
+
+func TestBuilderSeedContinuesHistory(t *testing.T) {
+	b, _ := candles.NewBuilder(time.Hour)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(2*time.Hour + 30*time.Minute)
+
+	history := []candles.Candle{
+		{OpenTime: start, Close: 10, CloseTime: start.Add(time.Hour - time.Millisecond)},
+		{OpenTime: start.Add(time.Hour), Close: 11, CloseTime: start.Add(2*time.Hour - time.Millisecond)},
+		{OpenTime: start.Add(2 * time.Hour), Open: 11, High: 13, Low: 10, Close: 12, Volume: 5, CloseTime: start.Add(3*time.Hour - time.Millisecond)},
+	}
+
+	closed := b.Seed(history, now)
+	if len(closed) != 2 {
+		t.Fatalf("Seed() closed = %d bars, want 2", len(closed))
+	}
+
+	// A tick inside the open historical bar extends it
+	b.Add(14, 1, now.Add(time.Minute))
+	live, _ := b.Live()
+	if live.Open != 11 || live.High != 14 || live.Volume != 6 {
+		t.Fatalf("live bar after tick = %+v", live)
+	}
+
+	// Late ticks for closed bars are ignored
+	if closed := b.Add(1, 1, start.Add(30*time.Minute)); len(closed) != 0 {
+		t.Fatal("late tick should not close a bar")
+	}
+	live, _ = b.Live()
+	if live.Low != 10 {
+		t.Fatalf("late tick should not change live bar, got %+v", live)
+	}
+}
@@ -1,42 +1,213 @@
 package indicators
 
+import "time"
+
 const (
 	// HistorySize defines how many candles of indicator history to keep
 	HistorySize = 30
+
+	// Default MACD periods
+	DefaultMACDFast   = 12
+	DefaultMACDSlow   = 26
+	DefaultMACDSignal = 9
+
+	// Default Bollinger Bands settings
+	DefaultBollingerPeriod = 20
+	DefaultBollingerStdDev = 2.0
+	// Bounds of the Bollinger Bands standard deviation multiplier
+	MinBollingerStdDev = 0.1
+	MaxBollingerStdDev = 10.0
+
+	// Default OHLC indicator periods
+	DefaultATRPeriod       = 14
+	DefaultStochasticK     = 14
+	DefaultStochasticD     = 3
+	DefaultWilliamsRPeriod = 14
+
+	// DefaultVolumeSMAPeriod is the number of bars averaged by the volume SMA
+	DefaultVolumeSMAPeriod = 20
 )
 
 // AggregatedValues contains the latest values for all indicators.
 type AggregatedValues struct {
-	RSI float64
-	SMA float64
-	EMA float64
+	RSI           float64
+	SMA           float64
+	EMA           float64
+	MACD          float64
+	MACDSignal    float64
+	MACDHistogram float64
+	BBUpper       float64
+	BBMiddle      float64
+	BBLower       float64
+	BBPercentB    float64
+	ATR           float64
+	StochK        float64
+	StochD        float64
+	WilliamsR     float64
+	VWAP          float64
+	OBV           float64
+	VolumeSMA     float64
 }
 
 // IndicatorHistory contains historical values for indicators
 type IndicatorHistory struct {
-	RSI    []float64
-	SMA    []float64
-	EMA    []float64
-	Prices []float64
+	RSI           []float64
+	SMA           []float64
+	EMA           []float64
+	MACD          []float64
+	MACDSignal    []float64
+	MACDHistogram []float64
+	BBUpper       []float64
+	BBLower       []float64
+	BBPercentB    []float64
+	ATR           []float64
+	StochK        []float64
+	StochD        []float64
+	WilliamsR     []float64
+	Prices        []float64
+}
+
+// Option configures optional indicators of an Aggregator.
+type Option func(*aggregatorOptions)
+
+type aggregatorOptions struct {
+	macdFast    int
+	macdSlow    int
+	macdSignal  int
+	bbPeriod    int
+	bbStdDev    float64
+	atrPeriod   int
+	stochK      int
+	stochD      int
+	willRPeriod int
+	volSMA      int
+	vwapReset   bool
+	registry    *Registry
+	specs       []Spec
+}
+
+// WithMACD sets the MACD fast, slow and signal periods (default 12, 26, 9).
+func WithMACD(fast, slow, signal int) Option {
+	return func(o *aggregatorOptions) {
+		o.macdFast = fast
+		o.macdSlow = slow
+		o.macdSignal = signal
+	}
+}
+
+// WithBollinger sets the Bollinger Bands period and standard deviation multiplier (default 20, 2).
+func WithBollinger(period int, stdDev float64) Option {
+	return func(o *aggregatorOptions) {
+		o.bbPeriod = period
+		o.bbStdDev = stdDev
+	}
+}
+
+// WithATR sets the Average True Range period (default 14).
+func WithATR(period int) Option {
+	return func(o *aggregatorOptions) {
+		o.atrPeriod = period
+	}
+}
+
+// WithStochastic sets the Stochastic %K and %D periods (default 14, 3).
+func WithStochastic(kPeriod, dPeriod int) Option {
+	return func(o *aggregatorOptions) {
+		o.stochK = kPeriod
+		o.stochD = dPeriod
+	}
+}
+
+// WithWilliamsR sets the Williams %R period (default 14).
+func WithWilliamsR(period int) Option {
+	return func(o *aggregatorOptions) {
+		o.willRPeriod = period
+	}
+}
+
+// WithVolumeSMA sets the period of the bar volume moving average (default 20).
+func WithVolumeSMA(period int) Option {
+	return func(o *aggregatorOptions) {
+		o.volSMA = period
+	}
+}
+
+// WithVWAPDailyReset controls whether the VWAP session restarts at UTC midnight (default true).
+func WithVWAPDailyReset(reset bool) Option {
+	return func(o *aggregatorOptions) {
+		o.vwapReset = reset
+	}
+}
+
+// WithSpecs adds registry indicators, such as "ema:200", that run alongside
+// the fixed ones. Their values are reported by SpecValues.
+func WithSpecs(specs ...Spec) Option {
+	return func(o *aggregatorOptions) {
+		o.specs = append(o.specs, specs...)
+	}
+}
+
+// WithRegistry sets the registry used to resolve specs (default DefaultRegistry).
+func WithRegistry(r *Registry) Option {
+	return func(o *aggregatorOptions) {
+		o.registry = r
+	}
 }
 
 // Aggregator coordinates price updates across indicators and tracks recent prices.
 type Aggregator struct {
-	prices       *CircularBuffer
-	rsi          *RSI
-	sma          *SMA
-	ema          *EMA
-	last         AggregatedValues
-	rsiHistory   []float64
-	smaHistory   []float64
-	emaHistory   []float64
-	priceHistory []float64
-	lastPrice    float64
-	updateCount  int
+	prices        *CircularBuffer
+	rsi           *RSI
+	sma           *SMA
+	ema           *EMA
+	macd          *MACD
+	bands         *BollingerBands
+	atr           *ATR
+	stoch         *Stochastic
+	willR         *WilliamsR
+	vwap          *VWAP
+	obv           *OBV
+	volumeSMA     *SMA
+	specs         *Set
+	last          AggregatedValues
+	rsiHistory    []float64
+	smaHistory    []float64
+	emaHistory    []float64
+	macdHistory   []float64
+	signalHistory []float64
+	histHistory   []float64
+	upperHistory  []float64
+	lowerHistory  []float64
+	pctBHistory   []float64
+	atrHistory    []float64
+	stochKHistory []float64
+	stochDHistory []float64
+	willRHistory  []float64
+	priceHistory  []float64
+	lastPrice     float64
+	updateCount   int
 }
 
 // NewAggregator constructs an Aggregator with the provided indicator periods.
-func NewAggregator(rsiPeriod, smaPeriod, emaPeriod int) (*Aggregator, error) {
+func NewAggregator(rsiPeriod, smaPeriod, emaPeriod int, opts ...Option) (*Aggregator, error) {
+	options := aggregatorOptions{
+		macdFast:    DefaultMACDFast,
+		macdSlow:    DefaultMACDSlow,
+		macdSignal:  DefaultMACDSignal,
+		bbPeriod:    DefaultBollingerPeriod,
+		bbStdDev:    DefaultBollingerStdDev,
+		atrPeriod:   DefaultATRPeriod,
+		stochK:      DefaultStochasticK,
+		stochD:      DefaultStochasticD,
+		willRPeriod: DefaultWilliamsRPeriod,
+		volSMA:      DefaultVolumeSMAPeriod,
+		vwapReset:   true,
+		registry:    DefaultRegistry(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	maxPeriod := maxInt(rsiPeriod+1, smaPeriod, emaPeriod)
 	prices, err := NewCircularBuffer(maxPeriod)
 	if err != nil {
@@ -58,16 +229,61 @@ func NewAggregator(rsiPeriod, smaPeriod, emaPeriod int) (*Aggregator, error) {
 		return nil, err
 	}
 
+	macd, err := NewMACD(options.macdFast, options.macdSlow, options.macdSignal)
+	if err != nil {
+		return nil, err
+	}
+
+	bands, err := NewBollingerBands(options.bbPeriod, options.bbStdDev)
+	if err != nil {
+		return nil, err
+	}
+
+	atr, err := NewATR(options.atrPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	stoch, err := NewStochastic(options.stochK, options.stochD)
+	if err != nil {
+		return nil, err
+	}
+
+	willR, err := NewWilliamsR(options.willRPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeSMA, err := NewSMA(options.volSMA)
+	if err != nil {
+		return nil, err
+	}
+
+	specs, err := options.registry.NewSet(options.specs)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Aggregator{
-		prices: prices,
-		rsi:    rsi,
-		sma:    sma,
-		ema:    ema,
+		prices:    prices,
+		rsi:       rsi,
+		sma:       sma,
+		ema:       ema,
+		macd:      macd,
+		bands:     bands,
+		atr:       atr,
+		stoch:     stoch,
+		willR:     willR,
+		vwap:      NewVWAP(options.vwapReset),
+		obv:       NewOBV(),
+		volumeSMA: volumeSMA,
+		specs:     specs,
 	}, nil
 }
 
 // Update ingests a price, updates all indicators, and returns aggregated values.
 // Only updates RSI when price actually changes to avoid false RSI=100 readings.
+// Range-based indicators see the price as a bar with no range.
 func (a *Aggregator) Update(price float64) AggregatedValues {
 	a.updateCount++
 	
@@ -80,25 +296,125 @@ func (a *Aggregator) Update(price float64) AggregatedValues {
 		return a.last
 	}
 	
+	return a.advance(PriceBar(price))
+}
+
+// AddBar ingests a finished bar. Unlike Update it always advances the
+// indicators, since consecutive bars may legitimately close at the same price.
+func (a *Aggregator) AddBar(bar Bar) AggregatedValues {
+	a.updateCount++
+	a.lastPrice = bar.Close
+	return a.advance(bar)
+}
+
+// AddTrade folds an executed trade into the session VWAP. Trades are tracked
+// separately from bars since VWAP weights every fill rather than bar closes.
+func (a *Aggregator) AddTrade(price, quantity float64, ts time.Time) AggregatedValues {
+	a.last.VWAP = a.vwap.AddTrade(price, quantity, ts)
+	a.specs.AddTrade(price, quantity, ts)
+	return a.last
+}
+
+// Live returns the values the indicators would have if the in-progress bar
+// closed as it currently stands. The aggregator state is not modified.
+func (a *Aggregator) Live(bar Bar) AggregatedValues {
+	price := bar.Close
+	macd := a.macd.clone()
+	macd.Update(price)
+	bands := a.bands.clone()
+	bands.Update(price)
+	stoch := a.stoch.clone()
+	stoch.UpdateBar(bar)
+	return AggregatedValues{
+		RSI:           a.rsi.clone().Update(price),
+		SMA:           a.sma.clone().Update(price),
+		EMA:           a.ema.clone().Update(price),
+		MACD:          macd.Value(),
+		MACDSignal:    macd.Signal(),
+		MACDHistogram: macd.Histogram(),
+		BBUpper:       bands.Upper(),
+		BBMiddle:      bands.Middle(),
+		BBLower:       bands.Lower(),
+		BBPercentB:    bands.PercentB(),
+		ATR:           a.atr.clone().UpdateBar(bar),
+		StochK:        stoch.Value(),
+		StochD:        stoch.D(),
+		WilliamsR:     a.willR.clone().UpdateBar(bar),
+		VWAP:          a.vwap.Value(),
+		OBV:           a.obv.clone().UpdateBar(bar),
+		VolumeSMA:     a.volumeSMA.clone().Update(bar.Volume),
+	}
+}
+
+func (a *Aggregator) advance(bar Bar) AggregatedValues {
+	price := bar.Close
 	a.prices.Push(price)
 	rsiVal := a.rsi.Update(price)
 	smaVal := a.sma.Update(price)
 	emaVal := a.ema.Update(price)
+	a.macd.Update(price)
+	a.bands.Update(price)
+	a.atr.UpdateBar(bar)
+	a.stoch.UpdateBar(bar)
+	a.willR.UpdateBar(bar)
+	a.obv.UpdateBar(bar)
+	a.volumeSMA.Update(bar.Volume)
+	a.specs.AddBar(bar)
 	
 	a.last = AggregatedValues{
-		RSI: rsiVal,
-		SMA: smaVal,
-		EMA: emaVal,
+		RSI:           rsiVal,
+		SMA:           smaVal,
+		EMA:           emaVal,
+		MACD:          a.macd.Value(),
+		MACDSignal:    a.macd.Signal(),
+		MACDHistogram: a.macd.Histogram(),
+		BBUpper:       a.bands.Upper(),
+		BBMiddle:      a.bands.Middle(),
+		BBLower:       a.bands.Lower(),
+		BBPercentB:    a.bands.PercentB(),
+		ATR:           a.atr.Value(),
+		StochK:        a.stoch.Value(),
+		StochD:        a.stoch.D(),
+		WilliamsR:     a.willR.Value(),
+		VWAP:          a.vwap.Value(),
+		OBV:           a.obv.Value(),
+		VolumeSMA:     a.volumeSMA.Value(),
 	}
 	
 	a.priceHistory = appendWithLimit(a.priceHistory, price, HistorySize)
 	a.rsiHistory = appendWithLimit(a.rsiHistory, rsiVal, HistorySize)
 	a.smaHistory = appendWithLimit(a.smaHistory, smaVal, HistorySize)
 	a.emaHistory = appendWithLimit(a.emaHistory, emaVal, HistorySize)
+	a.macdHistory = appendWithLimit(a.macdHistory, a.last.MACD, HistorySize)
+	a.signalHistory = appendWithLimit(a.signalHistory, a.last.MACDSignal, HistorySize)
+	a.histHistory = appendWithLimit(a.histHistory, a.last.MACDHistogram, HistorySize)
+	a.upperHistory = appendWithLimit(a.upperHistory, a.last.BBUpper, HistorySize)
+	a.lowerHistory = appendWithLimit(a.lowerHistory, a.last.BBLower, HistorySize)
+	a.pctBHistory = appendWithLimit(a.pctBHistory, a.last.BBPercentB, HistorySize)
+	a.atrHistory = appendWithLimit(a.atrHistory, a.last.ATR, HistorySize)
+	a.stochKHistory = appendWithLimit(a.stochKHistory, a.last.StochK, HistorySize)
+	a.stochDHistory = appendWithLimit(a.stochDHistory, a.last.StochD, HistorySize)
+	a.willRHistory = appendWithLimit(a.willRHistory, a.last.WilliamsR, HistorySize)
 	
 	return a.last
 }
 
+// SpecValues returns the current values of the indicators added with WithSpecs.
+func (a *Aggregator) SpecValues() []Value {
+	return a.specs.Values()
+}
+
+// LiveSpecValues returns the values the WithSpecs indicators would have if
+// the in-progress bar closed as it currently stands.
+func (a *Aggregator) LiveSpecValues(bar Bar) []Value {
+	return a.specs.Live(bar)
+}
+
+// SpecWarmup returns how many bars the WithSpecs indicators need to warm up.
+func (a *Aggregator) SpecWarmup() int {
+	return a.specs.Warmup()
+}
+
 // Values returns the most recently computed aggregate values.
 func (a *Aggregator) Values() AggregatedValues {
 	return a.last
@@ -124,10 +440,20 @@ func (a *Aggregator) WarmupProgress() float64 {
 // History returns the historical values for all indicators.
 func (a *Aggregator) History() IndicatorHistory {
 	return IndicatorHistory{
-		RSI:    copySlice(a.rsiHistory),
-		SMA:    copySlice(a.smaHistory),
-		EMA:    copySlice(a.emaHistory),
-		Prices: copySlice(a.priceHistory),
+		RSI:           copySlice(a.rsiHistory),
+		SMA:           copySlice(a.smaHistory),
+		EMA:           copySlice(a.emaHistory),
+		MACD:          copySlice(a.macdHistory),
+		MACDSignal:    copySlice(a.signalHistory),
+		MACDHistogram: copySlice(a.histHistory),
+		BBUpper:       copySlice(a.upperHistory),
+		BBLower:       copySlice(a.lowerHistory),
+		BBPercentB:    copySlice(a.pctBHistory),
+		ATR:           copySlice(a.atrHistory),
+		StochK:        copySlice(a.stochKHistory),
+		StochD:        copySlice(a.stochDHistory),
+		WilliamsR:     copySlice(a.willRHistory),
+		Prices:        copySlice(a.priceHistory),
 	}
 }
 
@@ -157,3 +157,38 @@ func TestMaxInt(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregatorAddBarAdvancesOnEqualCloses(t *testing.T) {
+	agg, _ := NewAggregator(3, 3, 3)
+
+	agg.AddBar(PriceBar(10))
+	agg.AddBar(PriceBar(10))
+	agg.AddBar(PriceBar(10))
+
+	if got := len(agg.History().Prices); got != 3 {
+		t.Errorf("AddBar() should record every bar, history length = %d, want 3", got)
+	}
+	if agg.Values().SMA != 10 {
+		t.Errorf("SMA = %v, want 10", agg.Values().SMA)
+	}
+}
+
+func TestAggregatorLiveDoesNotMutate(t *testing.T) {
+	agg, _ := NewAggregator(3, 3, 3)
+	for _, p := range []float64{10, 11, 12, 13} {
+		agg.AddBar(PriceBar(p))
+	}
+
+	before := agg.Values()
+	live := agg.Live(PriceBar(16))
+
+	if live.SMA != (12+13+16)/3.0 {
+		t.Errorf("Live() SMA = %v, want %v", live.SMA, (12+13+16)/3.0)
+	}
+	if agg.Values() != before {
+		t.Errorf("Live() mutated aggregator: got %+v, want %+v", agg.Values(), before)
+	}
+	if again := agg.Live(PriceBar(16)); again != live {
+		t.Errorf("Live() is not repeatable: %+v vs %+v", again, live)
+	}
+}
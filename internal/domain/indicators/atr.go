@@ -0,0 +1,65 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// ATR implements the Average True Range using Wilder's smoothing.
+type ATR struct {
+	period    int
+	prevClose float64
+	hasPrev   bool
+	trSum     float64
+	count     int
+	value     float64
+}
+
+// NewATR creates an ATR with the given period.
+func NewATR(period int) (*ATR, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive")
+	}
+	return &ATR{period: period}, nil
+}
+
+// UpdateBar ingests a bar and returns the current ATR value.
+// Returns 0 until period bars are collected; initializes using the average of the first period true ranges.
+func (a *ATR) UpdateBar(bar Bar) float64 {
+	tr := bar.High - bar.Low
+	if a.hasPrev {
+		tr = math.Max(tr, math.Max(math.Abs(bar.High-a.prevClose), math.Abs(bar.Low-a.prevClose)))
+	}
+	a.prevClose = bar.Close
+	a.hasPrev = true
+
+	if a.count < a.period {
+		a.trSum += tr
+		a.count++
+		if a.count < a.period {
+			a.value = 0
+			return a.value
+		}
+		a.value = a.trSum / float64(a.period)
+		return a.value
+	}
+
+	a.value = (a.value*float64(a.period-1) + tr) / float64(a.period)
+	return a.value
+}
+
+// Value returns the last computed ATR.
+func (a *ATR) Value() float64 {
+	return a.value
+}
+
+// Period returns the configured period.
+func (a *ATR) Period() int {
+	return a.period
+}
+
+// clone returns an independent copy of the ATR state.
+func (a *ATR) clone() *ATR {
+	c := *a
+	return &c
+}
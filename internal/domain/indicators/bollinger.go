@@ -0,0 +1,83 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// BollingerBands implements Bollinger Bands: an SMA middle band with upper and
+// lower bands a multiple of the rolling standard deviation away, plus %B.
+type BollingerBands struct {
+	period     int
+	multiplier float64
+	buf        *CircularBuffer
+	upper      float64
+	middle     float64
+	lower      float64
+	percentB   float64
+}
+
+// NewBollingerBands creates Bollinger Bands with the given period and standard
+// deviation multiplier (commonly 20 and 2).
+func NewBollingerBands(period int, multiplier float64) (*BollingerBands, error) {
+	if multiplier <= 0 {
+		return nil, fmt.Errorf("standard deviation multiplier must be positive")
+	}
+	buf, err := NewCircularBuffer(period)
+	if err != nil {
+		return nil, err
+	}
+	return &BollingerBands{period: period, multiplier: multiplier, buf: buf}, nil
+}
+
+// Update ingests a price and returns the current middle band. Returns 0 until enough data is collected.
+// %B is 0 at the lower band and 1 at the upper band; it is 0.5 when the bands have zero width.
+func (b *BollingerBands) Update(price float64) float64 {
+	b.buf.Push(price)
+	if !b.buf.Full() {
+		return 0
+	}
+
+	b.middle = b.buf.Mean()
+	width := b.multiplier * math.Sqrt(b.buf.Variance())
+	b.upper = b.middle + width
+	b.lower = b.middle - width
+	if width == 0 {
+		b.percentB = 0.5
+	} else {
+		b.percentB = (price - b.lower) / (b.upper - b.lower)
+	}
+	return b.middle
+}
+
+// Upper returns the last computed upper band.
+func (b *BollingerBands) Upper() float64 {
+	return b.upper
+}
+
+// Middle returns the last computed middle band.
+func (b *BollingerBands) Middle() float64 {
+	return b.middle
+}
+
+// Lower returns the last computed lower band.
+func (b *BollingerBands) Lower() float64 {
+	return b.lower
+}
+
+// PercentB returns where the last price sits relative to the bands.
+func (b *BollingerBands) PercentB() float64 {
+	return b.percentB
+}
+
+// Period returns the configured period.
+func (b *BollingerBands) Period() int {
+	return b.period
+}
+
+// clone returns an independent copy of the Bollinger Bands state.
+func (b *BollingerBands) clone() *BollingerBands {
+	c := *b
+	c.buf = b.buf.clone()
+	return &c
+}
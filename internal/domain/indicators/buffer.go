@@ -9,6 +9,7 @@ type CircularBuffer struct {
 	count int
 	index int
 	sum   float64
+	sumSq float64
 }
 
 // NewCircularBuffer creates a buffer with the provided size.
@@ -24,12 +25,14 @@ func (cb *CircularBuffer) Push(value float64) {
 	if cb.count == cb.size {
 		old := cb.data[cb.index]
 		cb.sum -= old
+		cb.sumSq -= old * old
 	} else {
 		cb.count++
 	}
 
 	cb.data[cb.index] = value
 	cb.sum += value
+	cb.sumSq += value * value
 	cb.index = (cb.index + 1) % cb.size
 }
 
@@ -38,6 +41,59 @@ func (cb *CircularBuffer) Sum() float64 {
 	return cb.sum
 }
 
+// SumSquares returns the sum of the squared values currently in the buffer.
+func (cb *CircularBuffer) SumSquares() float64 {
+	return cb.sumSq
+}
+
+// Mean returns the average of the values currently in the buffer, or 0 when empty.
+func (cb *CircularBuffer) Mean() float64 {
+	if cb.count == 0 {
+		return 0
+	}
+	return cb.sum / float64(cb.count)
+}
+
+// Variance returns the population variance of the values currently in the buffer.
+func (cb *CircularBuffer) Variance() float64 {
+	if cb.count == 0 {
+		return 0
+	}
+	mean := cb.Mean()
+	variance := cb.sumSq/float64(cb.count) - mean*mean
+	if variance < 0 {
+		// guard against floating point cancellation on near-constant windows
+		return 0
+	}
+	return variance
+}
+
+// Max returns the largest value currently in the buffer, or 0 when empty.
+func (cb *CircularBuffer) Max() float64 {
+	if cb.count == 0 {
+		return 0
+	}
+	values := cb.Values()
+	highest := values[0]
+	for _, v := range values[1:] {
+		highest = max(highest, v)
+	}
+	return highest
+}
+
+// Min returns the smallest value currently in the buffer, or 0 when empty.
+func (cb *CircularBuffer) Min() float64 {
+	if cb.count == 0 {
+		return 0
+	}
+	values := cb.Values()
+	lowest := values[0]
+	for _, v := range values[1:] {
+		lowest = min(lowest, v)
+	}
+	return lowest
+}
+
 // Len returns the number of elements currently stored.
 func (cb *CircularBuffer) Len() int {
 	return cb.count
@@ -61,3 +117,11 @@ func (cb *CircularBuffer) Values() []float64 {
 	}
 	return values
 }
+
+// clone returns an independent copy of the buffer.
+func (cb *CircularBuffer) clone() *CircularBuffer {
+	c := *cb
+	c.data = make([]float64, len(cb.data))
+	copy(c.data, cb.data)
+	return &c
+}
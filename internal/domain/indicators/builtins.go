@@ -0,0 +1,219 @@
+package indicators
+
+import "time"
+
+// newBuiltinRegistry returns a registry with every indicator in this package.
+func newBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	for _, def := range builtinDefinitions() {
+		if err := r.Register(def); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// MaxPeriod is the longest period, in bars, a built-in indicator accepts.
+// Series preallocate their windows, so periods must stay bounded.
+const MaxPeriod = 1000
+
+func period(name string, def float64) ParamSpec {
+	return ParamSpec{Name: name, Kind: ParamInt, Default: def, Min: 1, Max: MaxPeriod}
+}
+
+// firstParam is the Warmup of indicators whose first parameter is their period.
+func firstParam(params []float64) int {
+	return int(params[0])
+}
+
+func builtinDefinitions() []Definition {
+	return []Definition{
+		{
+			Name:        "sma",
+			Description: "Simple moving average of closes",
+			Params:      []ParamSpec{period("period", 14)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				sma, err := NewSMA(int(p[0]))
+				return closeSeries[*SMA]{sma, (*SMA).clone}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "ema",
+			Description: "Exponential moving average of closes",
+			Params:      []ParamSpec{period("period", 14)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				ema, err := NewEMA(int(p[0]))
+				return closeSeries[*EMA]{ema, (*EMA).clone}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "rsi",
+			Description: "Relative Strength Index",
+			Params:      []ParamSpec{period("period", 14)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				rsi, err := NewRSI(int(p[0]))
+				return closeSeries[*RSI]{rsi, (*RSI).clone}, err
+			},
+			Warmup: func(p []float64) int { return int(p[0]) + 1 },
+		},
+		{
+			Name:        "stddev",
+			Description: "Rolling standard deviation of closes",
+			Params:      []ParamSpec{period("period", 20)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				sd, err := NewStdDev(int(p[0]))
+				return closeSeries[*StdDev]{sd, (*StdDev).clone}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "macd",
+			Description: "Moving Average Convergence Divergence",
+			Params:      []ParamSpec{period("fast", DefaultMACDFast), period("slow", DefaultMACDSlow), period("signal", DefaultMACDSignal)},
+			Outputs:     []string{"macd", "signal", "histogram"},
+			New: func(p []float64) (Series, error) {
+				macd, err := NewMACD(int(p[0]), int(p[1]), int(p[2]))
+				return macdSeries{macd}, err
+			},
+			Warmup: func(p []float64) int { return int(p[1] + p[2]) },
+		},
+		{
+			Name:        "bb",
+			Description: "Bollinger Bands",
+			Params: []ParamSpec{
+				period("period", DefaultBollingerPeriod),
+				{Name: "stddev", Kind: ParamFloat, Default: DefaultBollingerStdDev, Min: MinBollingerStdDev, Max: MaxBollingerStdDev},
+			},
+			Outputs: []string{"middle", "upper", "lower", "percent_b"},
+			New: func(p []float64) (Series, error) {
+				bands, err := NewBollingerBands(int(p[0]), p[1])
+				return bandsSeries{bands}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "atr",
+			Description: "Average True Range",
+			Params:      []ParamSpec{period("period", DefaultATRPeriod)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				atr, err := NewATR(int(p[0]))
+				return barSeries[*ATR]{atr, (*ATR).clone}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "stoch",
+			Description: "Stochastic Oscillator",
+			Params:      []ParamSpec{period("k", DefaultStochasticK), period("d", DefaultStochasticD)},
+			Outputs:     []string{"k", "d"},
+			New: func(p []float64) (Series, error) {
+				stoch, err := NewStochastic(int(p[0]), int(p[1]))
+				return stochSeries{stoch}, err
+			},
+			Warmup: func(p []float64) int { return int(p[0] + p[1]) },
+		},
+		{
+			Name:        "willr",
+			Description: "Williams %R",
+			Params:      []ParamSpec{period("period", DefaultWilliamsRPeriod)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				willR, err := NewWilliamsR(int(p[0]))
+				return barSeries[*WilliamsR]{willR, (*WilliamsR).clone}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "obv",
+			Description: "On-Balance Volume",
+			Outputs:     []string{"value"},
+			New: func([]float64) (Series, error) {
+				return barSeries[*OBV]{NewOBV(), (*OBV).clone}, nil
+			},
+		},
+		{
+			Name:        "volsma",
+			Description: "Simple moving average of bar volume",
+			Params:      []ParamSpec{period("period", DefaultVolumeSMAPeriod)},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				sma, err := NewSMA(int(p[0]))
+				return volumeSeries{sma}, err
+			},
+			Warmup: firstParam,
+		},
+		{
+			Name:        "vwap",
+			Description: "Session volume weighted average price",
+			Params:      []ParamSpec{{Name: "daily_reset", Kind: ParamInt, Default: 1, Min: 0, Max: 1}},
+			Outputs:     []string{"value"},
+			New: func(p []float64) (Series, error) {
+				return vwapSeries{NewVWAP(p[0] != 0)}, nil
+			},
+		},
+	}
+}
+
+// closeSeries adapts a close-price Indicator to a Series.
+type closeSeries[T Indicator] struct {
+	ind   T
+	clone func(T) T
+}
+
+func (s closeSeries[T]) AddBar(bar Bar)     { s.ind.Update(bar.Close) }
+func (s closeSeries[T]) Outputs() []float64 { return []float64{s.ind.Value()} }
+func (s closeSeries[T]) Clone() Series      { return closeSeries[T]{s.clone(s.ind), s.clone} }
+
+// barSeries adapts a BarIndicator to a Series.
+type barSeries[T BarIndicator] struct {
+	ind   T
+	clone func(T) T
+}
+
+func (s barSeries[T]) AddBar(bar Bar)     { s.ind.UpdateBar(bar) }
+func (s barSeries[T]) Outputs() []float64 { return []float64{s.ind.Value()} }
+func (s barSeries[T]) Clone() Series      { return barSeries[T]{s.clone(s.ind), s.clone} }
+
+type macdSeries struct{ *MACD }
+
+func (s macdSeries) AddBar(bar Bar) { s.Update(bar.Close) }
+func (s macdSeries) Outputs() []float64 {
+	return []float64{s.Value(), s.Signal(), s.Histogram()}
+}
+func (s macdSeries) Clone() Series { return macdSeries{s.clone()} }
+
+type bandsSeries struct{ *BollingerBands }
+
+func (s bandsSeries) AddBar(bar Bar) { s.Update(bar.Close) }
+func (s bandsSeries) Outputs() []float64 {
+	return []float64{s.Middle(), s.Upper(), s.Lower(), s.PercentB()}
+}
+func (s bandsSeries) Clone() Series { return bandsSeries{s.clone()} }
+
+type stochSeries struct{ *Stochastic }
+
+func (s stochSeries) AddBar(bar Bar)     { s.UpdateBar(bar) }
+func (s stochSeries) Outputs() []float64 { return []float64{s.Value(), s.D()} }
+func (s stochSeries) Clone() Series      { return stochSeries{s.clone()} }
+
+type volumeSeries struct{ *SMA }
+
+func (s volumeSeries) AddBar(bar Bar)     { s.Update(bar.Volume) }
+func (s volumeSeries) Outputs() []float64 { return []float64{s.Value()} }
+func (s volumeSeries) Clone() Series      { return volumeSeries{s.clone()} }
+
+type vwapSeries struct{ *VWAP }
+
+func (s vwapSeries) AddBar(Bar)         {}
+func (s vwapSeries) Outputs() []float64 { return []float64{s.Value()} }
+func (s vwapSeries) Clone() Series      { return vwapSeries{s.clone()} }
+func (s vwapSeries) AddTrade(price, quantity float64, ts time.Time) {
+	s.VWAP.AddTrade(price, quantity, ts)
+}
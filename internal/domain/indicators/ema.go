@@ -56,3 +56,10 @@ func (e *EMA) Value() float64 {
 func (e *EMA) Period() int {
 	return e.period
 }
+
+// clone returns an independent copy of the EMA state.
+func (e *EMA) clone() *EMA {
+	c := *e
+	c.buf = e.buf.clone()
+	return &c
+}
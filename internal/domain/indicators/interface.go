@@ -6,3 +6,25 @@ type Indicator interface {
 	Value() float64
 	Period() int
 }
+
+// Bar is a single OHLCV bar, with the same fields as binance.Kline and candles.Candle.
+type Bar struct {
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume float64
+}
+
+// PriceBar returns a bar whose open, high, low and close are all price.
+func PriceBar(price float64) Bar {
+	return Bar{Open: price, High: price, Low: price, Close: price}
+}
+
+// BarIndicator defines the API for indicators that need the full bar range
+// rather than only the close price.
+type BarIndicator interface {
+	UpdateBar(bar Bar) float64
+	Value() float64
+	Period() int
+}
@@ -0,0 +1,81 @@
+package indicators
+
+import "fmt"
+
+// MACD implements Moving Average Convergence Divergence: the difference between
+// a fast and a slow EMA, a signal EMA of that difference, and their histogram.
+type MACD struct {
+	fast      *EMA
+	slow      *EMA
+	signal    *EMA
+	value     float64
+	signalVal float64
+	histogram float64
+}
+
+// NewMACD creates a MACD with the given fast, slow and signal periods (commonly 12, 26, 9).
+func NewMACD(fastPeriod, slowPeriod, signalPeriod int) (*MACD, error) {
+	if fastPeriod >= slowPeriod {
+		return nil, fmt.Errorf("fast period must be smaller than slow period")
+	}
+	fast, err := NewEMA(fastPeriod)
+	if err != nil {
+		return nil, err
+	}
+	slow, err := NewEMA(slowPeriod)
+	if err != nil {
+		return nil, err
+	}
+	signal, err := NewEMA(signalPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return &MACD{fast: fast, slow: slow, signal: signal}, nil
+}
+
+// Update ingests a price and returns the current MACD line.
+// Returns 0 until the slow EMA is initialized; the signal line needs a further
+// signal period of MACD values before it becomes non-zero.
+func (m *MACD) Update(price float64) float64 {
+	fastVal := m.fast.Update(price)
+	slowVal := m.slow.Update(price)
+	if !m.slow.initialized {
+		return 0
+	}
+
+	m.value = fastVal - slowVal
+	m.signalVal = m.signal.Update(m.value)
+	if m.signal.initialized {
+		m.histogram = m.value - m.signalVal
+	}
+	return m.value
+}
+
+// Value returns the last computed MACD line.
+func (m *MACD) Value() float64 {
+	return m.value
+}
+
+// Signal returns the last computed signal line.
+func (m *MACD) Signal() float64 {
+	return m.signalVal
+}
+
+// Histogram returns the last computed MACD minus signal.
+func (m *MACD) Histogram() float64 {
+	return m.histogram
+}
+
+// Period returns the slow period, which determines the warmup length.
+func (m *MACD) Period() int {
+	return m.slow.Period()
+}
+
+// clone returns an independent copy of the MACD state.
+func (m *MACD) clone() *MACD {
+	c := *m
+	c.fast = m.fast.clone()
+	c.slow = m.slow.clone()
+	c.signal = m.signal.clone()
+	return &c
+}
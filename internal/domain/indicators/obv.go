@@ -0,0 +1,45 @@
+package indicators
+
+// OBV implements On-Balance Volume: a running total that adds a bar's volume
+// when it closes up and subtracts it when it closes down.
+type OBV struct {
+	prevClose float64
+	hasPrev   bool
+	value     float64
+}
+
+// NewOBV creates an OBV starting at zero.
+func NewOBV() *OBV {
+	return &OBV{}
+}
+
+// UpdateBar ingests a bar and returns the current OBV.
+func (o *OBV) UpdateBar(bar Bar) float64 {
+	if o.hasPrev {
+		switch {
+		case bar.Close > o.prevClose:
+			o.value += bar.Volume
+		case bar.Close < o.prevClose:
+			o.value -= bar.Volume
+		}
+	}
+	o.prevClose = bar.Close
+	o.hasPrev = true
+	return o.value
+}
+
+// Value returns the last computed OBV.
+func (o *OBV) Value() float64 {
+	return o.value
+}
+
+// Period returns 0, since OBV is cumulative rather than windowed.
+func (o *OBV) Period() int {
+	return 0
+}
+
+// clone returns an independent copy of the OBV state.
+func (o *OBV) clone() *OBV {
+	c := *o
+	return &c
+}
@@ -0,0 +1,156 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ParamKind is the type of an indicator parameter.
+type ParamKind int
+
+const (
+	// ParamInt parameters must be whole numbers, such as periods.
+	ParamInt ParamKind = iota
+	// ParamFloat parameters may be fractional, such as multipliers.
+	ParamFloat
+)
+
+// ParamSpec describes one positional parameter of a registered indicator.
+type ParamSpec struct {
+	Name    string
+	Kind    ParamKind
+	Default float64
+	Min     float64
+	// Max bounds the parameter from above; zero means unbounded.
+	Max float64
+}
+
+// Series is a running indicator instance created by the registry. It is fed
+// closed bars and reports one value per output declared by its Definition.
+type Series interface {
+	AddBar(bar Bar)
+	Outputs() []float64
+	Clone() Series
+}
+
+// TradeSeries is implemented by series that also consume individual trades.
+type TradeSeries interface {
+	Series
+	AddTrade(price, quantity float64, ts time.Time)
+}
+
+// Definition registers an indicator under a name.
+type Definition struct {
+	Name        string
+	Description string
+	Params      []ParamSpec
+	// Outputs names each value reported by the series; the first is the primary value.
+	Outputs []string
+	// New creates a series from fully resolved parameters.
+	New func(params []float64) (Series, error)
+	// Warmup returns how many bars the series needs before its values are meaningful.
+	Warmup func(params []float64) int
+}
+
+// Registry maps indicator names to their definitions.
+type Registry struct {
+	mu   sync.RWMutex
+	defs map[string]Definition
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{defs: make(map[string]Definition)}
+}
+
+var defaultRegistry = newBuiltinRegistry()
+
+// DefaultRegistry returns the registry holding the built-in indicators and any
+// indicators added with Register.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register adds an indicator to the default registry.
+func Register(def Definition) error {
+	return defaultRegistry.Register(def)
+}
+
+// Register adds an indicator definition. Names must be unique.
+func (r *Registry) Register(def Definition) error {
+	if def.Name == "" {
+		return fmt.Errorf("indicator definition must have a name")
+	}
+	if def.New == nil {
+		return fmt.Errorf("indicator %q: missing constructor", def.Name)
+	}
+	if len(def.Outputs) == 0 {
+		return fmt.Errorf("indicator %q: at least one output is required", def.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.defs[def.Name]; exists {
+		return fmt.Errorf("indicator %q already registered", def.Name)
+	}
+	r.defs[def.Name] = def
+	return nil
+}
+
+// Lookup returns the definition registered under name.
+func (r *Registry) Lookup(name string) (Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.defs[name]
+	return def, ok
+}
+
+// Definitions returns every registered definition sorted by name.
+func (r *Registry) Definitions() []Definition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]Definition, 0, len(r.defs))
+	for _, def := range r.defs {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Resolve validates spec against its definition and fills in defaults for
+// omitted trailing parameters, returning the canonical spec.
+func (r *Registry) Resolve(spec Spec) (Spec, Definition, error) {
+	def, ok := r.Lookup(spec.Name)
+	if !ok {
+		return Spec{}, Definition{}, fmt.Errorf("unknown indicator %q", spec.Name)
+	}
+	if len(spec.Params) > len(def.Params) {
+		return Spec{}, Definition{}, fmt.Errorf("indicator %q takes at most %d parameters, got %d", spec.Name, len(def.Params), len(spec.Params))
+	}
+
+	resolved := Spec{Name: def.Name, Params: make([]float64, len(def.Params))}
+	for i, param := range def.Params {
+		value := param.Default
+		if i < len(spec.Params) {
+			value = spec.Params[i]
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return Spec{}, Definition{}, fmt.Errorf("indicator %q: %s must be a finite number, got %v", spec.Name, param.Name, value)
+		}
+		if param.Kind == ParamInt && value != math.Trunc(value) {
+			return Spec{}, Definition{}, fmt.Errorf("indicator %q: %s must be a whole number, got %v", spec.Name, param.Name, value)
+		}
+		if value < param.Min {
+			return Spec{}, Definition{}, fmt.Errorf("indicator %q: %s must be at least %v, got %v", spec.Name, param.Name, param.Min, value)
+		}
+		if param.Max > 0 && value > param.Max {
+			return Spec{}, Definition{}, fmt.Errorf("indicator %q: %s must be at most %v, got %v", spec.Name, param.Name, param.Max, value)
+		}
+		resolved.Params[i] = value
+	}
+	return resolved, def, nil
+}
@@ -90,3 +90,10 @@ func (r *RSI) Value() float64 {
 func (r *RSI) Period() int {
 	return r.period
 }
+
+// clone returns an independent copy of the RSI state.
+func (r *RSI) clone() *RSI {
+	c := *r
+	c.buf = r.buf.clone()
+	return &c
+}
@@ -0,0 +1,122 @@
+package indicators
+
+import (
+	"fmt"
+	"time"
+)
+
+// Value is the output of one indicator instance in a Set.
+type Value struct {
+	Spec  Spec
+	Value float64
+	// Outputs holds every named output when the indicator has more than one.
+	Outputs map[string]float64
+}
+
+// Set runs a list of registry-created indicators side by side.
+type Set struct {
+	entries []setEntry
+}
+
+type setEntry struct {
+	spec   Spec
+	def    Definition
+	series Series
+}
+
+// NewSet resolves and instantiates specs. Specs that resolve to the same
+// canonical form are only instantiated once.
+func (r *Registry) NewSet(specs []Spec) (*Set, error) {
+	set := &Set{}
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		resolved, def, err := r.Resolve(spec)
+		if err != nil {
+			return nil, err
+		}
+		if seen[resolved.Key()] {
+			continue
+		}
+		seen[resolved.Key()] = true
+
+		series, err := def.New(resolved.Params)
+		if err != nil {
+			return nil, fmt.Errorf("indicator %s: %w", resolved.Key(), err)
+		}
+		set.entries = append(set.entries, setEntry{spec: resolved, def: def, series: series})
+	}
+	return set, nil
+}
+
+// Specs returns the canonical specs of the set in order.
+func (s *Set) Specs() []Spec {
+	specs := make([]Spec, len(s.entries))
+	for i, e := range s.entries {
+		specs[i] = e.spec
+	}
+	return specs
+}
+
+// Warmup returns the number of bars needed before every indicator in the set is meaningful.
+func (s *Set) Warmup() int {
+	n := 0
+	for _, e := range s.entries {
+		if e.def.Warmup != nil {
+			n = max(n, e.def.Warmup(e.spec.Params))
+		}
+	}
+	return n
+}
+
+// AddBar feeds a closed bar to every indicator.
+func (s *Set) AddBar(bar Bar) {
+	for _, e := range s.entries {
+		e.series.AddBar(bar)
+	}
+}
+
+// AddTrade feeds a trade to every indicator that consumes trades.
+func (s *Set) AddTrade(price, quantity float64, ts time.Time) {
+	for _, e := range s.entries {
+		if series, ok := e.series.(TradeSeries); ok {
+			series.AddTrade(price, quantity, ts)
+		}
+	}
+}
+
+// Values returns the current value of every indicator.
+func (s *Set) Values() []Value {
+	values := make([]Value, len(s.entries))
+	for i, e := range s.entries {
+		values[i] = newValue(e, e.series)
+	}
+	return values
+}
+
+// Live returns the values each indicator would have if bar closed now. The set is not modified.
+func (s *Set) Live(bar Bar) []Value {
+	values := make([]Value, len(s.entries))
+	for i, e := range s.entries {
+		series := e.series.Clone()
+		series.AddBar(bar)
+		values[i] = newValue(e, series)
+	}
+	return values
+}
+
+func newValue(e setEntry, series Series) Value {
+	outputs := series.Outputs()
+	v := Value{Spec: e.spec}
+	if len(outputs) > 0 {
+		v.Value = outputs[0]
+	}
+	if len(outputs) > 1 {
+		v.Outputs = make(map[string]float64, len(outputs))
+		for i, out := range outputs {
+			if i < len(e.def.Outputs) {
+				v.Outputs[e.def.Outputs[i]] = out
+			}
+		}
+	}
+	return v
+}
@@ -41,3 +41,10 @@ func (s *SMA) Value() float64 {
 func (s *SMA) Period() int {
 	return s.period
 }
+
+// clone returns an independent copy of the SMA state.
+func (s *SMA) clone() *SMA {
+	c := *s
+	c.buf = s.buf.clone()
+	return &c
+}
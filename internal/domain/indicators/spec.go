@@ -0,0 +1,75 @@
+package indicators
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Spec identifies an indicator instance by registered name and positional
+// parameters, written as "name:param:param" (e.g. "ema:50", "macd:12:26:9").
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// Key returns the canonical string form of the spec, used to key its values.
+func (s Spec) Key() string {
+	var b strings.Builder
+	b.WriteString(s.Name)
+	for _, p := range s.Params {
+		b.WriteByte(':')
+		b.WriteString(strconv.FormatFloat(p, 'g', -1, 64))
+	}
+	return b.String()
+}
+
+// String implements fmt.Stringer.
+func (s Spec) String() string {
+	return s.Key()
+}
+
+// ParseSpec parses a single "name:param:param" spec. Names are case-insensitive.
+func ParseSpec(text string) (Spec, error) {
+	parts := strings.Split(strings.TrimSpace(text), ":")
+	name := strings.ToLower(strings.TrimSpace(parts[0]))
+	if name == "" {
+		return Spec{}, fmt.Errorf("indicator spec %q: missing name", text)
+	}
+
+	spec := Spec{Name: name}
+	for _, part := range parts[1:] {
+		p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Spec{}, fmt.Errorf("indicator spec %q: invalid parameter %q", text, part)
+		}
+		spec.Params = append(spec.Params, p)
+	}
+	return spec, nil
+}
+
+// ParseSpecs parses a comma-separated list of specs such as "ema:50, ema:200, rsi:7".
+// Blank entries are ignored.
+func ParseSpecs(text string) ([]Spec, error) {
+	var specs []Spec
+	for _, item := range strings.Split(text, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		spec, err := ParseSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// FormatSpecs joins specs into the comma-separated form accepted by ParseSpecs.
+func FormatSpecs(specs []Spec) string {
+	keys := make([]string, len(specs))
+	for i, spec := range specs {
+		keys[i] = spec.Key()
+	}
+	return strings.Join(keys, ",")
+}
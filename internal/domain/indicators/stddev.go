@@ -0,0 +1,53 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// StdDev implements a rolling population standard deviation over a fixed period.
+type StdDev struct {
+	period int
+	buf    *CircularBuffer
+	value  float64
+}
+
+// NewStdDev creates a StdDev with the given period.
+func NewStdDev(period int) (*StdDev, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive")
+	}
+	buf, err := NewCircularBuffer(period)
+	if err != nil {
+		return nil, err
+	}
+	return &StdDev{period: period, buf: buf}, nil
+}
+
+// Update ingests a price and returns the current standard deviation. Returns 0 until enough data is collected.
+func (s *StdDev) Update(price float64) float64 {
+	s.buf.Push(price)
+	if !s.buf.Full() {
+		s.value = 0
+		return s.value
+	}
+	s.value = math.Sqrt(s.buf.Variance())
+	return s.value
+}
+
+// Value returns the last computed standard deviation.
+func (s *StdDev) Value() float64 {
+	return s.value
+}
+
+// Period returns the configured period.
+func (s *StdDev) Period() int {
+	return s.period
+}
+
+// clone returns an independent copy of the StdDev state.
+func (s *StdDev) clone() *StdDev {
+	c := *s
+	c.buf = s.buf.clone()
+	return &c
+}
@@ -0,0 +1,77 @@
+package indicators
+
+import "fmt"
+
+// Stochastic implements the Stochastic Oscillator: %K locates the close within
+// the high-low range of the last kPeriod bars and %D is an SMA of %K.
+type Stochastic struct {
+	kPeriod int
+	highs   *CircularBuffer
+	lows    *CircularBuffer
+	d       *SMA
+	k       float64
+}
+
+// NewStochastic creates a Stochastic Oscillator with the given %K and %D periods (commonly 14 and 3).
+func NewStochastic(kPeriod, dPeriod int) (*Stochastic, error) {
+	if kPeriod <= 0 {
+		return nil, fmt.Errorf("period must be positive")
+	}
+	highs, err := NewCircularBuffer(kPeriod)
+	if err != nil {
+		return nil, err
+	}
+	lows, err := NewCircularBuffer(kPeriod)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewSMA(dPeriod)
+	if err != nil {
+		return nil, err
+	}
+	return &Stochastic{kPeriod: kPeriod, highs: highs, lows: lows, d: d}, nil
+}
+
+// UpdateBar ingests a bar and returns the current %K (0-100).
+// Returns 0 until kPeriod bars are collected; %K is 50 when the range is flat.
+func (s *Stochastic) UpdateBar(bar Bar) float64 {
+	s.highs.Push(bar.High)
+	s.lows.Push(bar.Low)
+	if !s.highs.Full() {
+		s.k = 0
+		return s.k
+	}
+
+	highest, lowest := s.highs.Max(), s.lows.Min()
+	if highest == lowest {
+		s.k = 50
+	} else {
+		s.k = 100 * (bar.Close - lowest) / (highest - lowest)
+	}
+	s.d.Update(s.k)
+	return s.k
+}
+
+// Value returns the last computed %K.
+func (s *Stochastic) Value() float64 {
+	return s.k
+}
+
+// D returns the last computed %D, or 0 until enough %K values are collected.
+func (s *Stochastic) D() float64 {
+	return s.d.Value()
+}
+
+// Period returns the %K period.
+func (s *Stochastic) Period() int {
+	return s.kPeriod
+}
+
+// clone returns an independent copy of the Stochastic state.
+func (s *Stochastic) clone() *Stochastic {
+	c := *s
+	c.highs = s.highs.clone()
+	c.lows = s.lows.clone()
+	c.d = s.d.clone()
+	return &c
+}
@@ -1,6 +1,7 @@
 package indicators_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/rp4ri/quantacode/internal/domain/indicators"
@@ -39,3 +40,29 @@ func TestCircularBufferPushAndWrap(t *testing.T) {
 		}
 	}
 }
+
+func TestCircularBufferVariance(t *testing.T) {
+	buf, err := indicators.NewCircularBuffer(4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if buf.Variance() != 0 {
+		t.Fatalf("variance of empty buffer = %v, want 0", buf.Variance())
+	}
+
+	for _, v := range []float64{2, 4, 4, 4} {
+		buf.Push(v)
+	}
+	if buf.Mean() != 3.5 {
+		t.Fatalf("mean = %v, want 3.5", buf.Mean())
+	}
+	if buf.SumSquares() != 52 {
+		t.Fatalf("sum of squares = %v, want 52", buf.SumSquares())
+	}
+
+	// wrap evicts 2: window is 4, 4, 4, 6 => mean 4.5, variance 0.75
+	buf.Push(6)
+	if math.Abs(buf.Variance()-0.75) > 1e-9 {
+		t.Fatalf("variance after wrap = %v, want 0.75", buf.Variance())
+	}
+}
@@ -3,6 +3,7 @@ package indicators_test
 import (
 	"math"
 	"testing"
+	"time"
 
 	"github.com/rp4ri/quantacode/internal/domain/indicators"
 )
@@ -101,3 +102,268 @@ func TestAggregator(t *testing.T) {
 		t.Fatalf("SMA got %v, want 3", last.SMA)
 	}
 }
+
+func TestMACD(t *testing.T) {
+	if _, err := indicators.NewMACD(26, 12, 9); err == nil {
+		t.Fatal("expected error when fast period is not smaller than slow period")
+	}
+
+	macd, err := indicators.NewMACD(2, 3, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// fast EMA: 15, 25, 35, 25; slow EMA: 20, 30, 25 => MACD: 5, 5, 0
+	// signal seeded by SMA(5, 5)=5, then (0-5)*2/3+5 = 1.667
+	prices := []float64{10, 20, 30, 40, 20}
+	var got float64
+	for _, price := range prices {
+		got = macd.Update(price)
+	}
+
+	if math.Abs(got) > 1e-9 {
+		t.Fatalf("MACD got %v, want 0", got)
+	}
+	if math.Abs(macd.Signal()-5.0/3) > 1e-9 {
+		t.Fatalf("signal got %v, want %v", macd.Signal(), 5.0/3)
+	}
+	if math.Abs(macd.Histogram()+5.0/3) > 1e-9 {
+		t.Fatalf("histogram got %v, want %v", macd.Histogram(), -5.0/3)
+	}
+}
+
+func TestAggregatorWithMACD(t *testing.T) {
+	if _, err := indicators.NewAggregator(3, 3, 3, indicators.WithMACD(5, 5, 3)); err == nil {
+		t.Fatal("expected error for invalid MACD periods")
+	}
+
+	agg, err := indicators.NewAggregator(3, 3, 3, indicators.WithMACD(2, 3, 2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, price := range []float64{10, 20, 30, 40, 20} {
+		agg.Update(price)
+	}
+
+	vals := agg.Values()
+	if math.Abs(vals.MACDHistogram+5.0/3) > 1e-9 {
+		t.Fatalf("MACD histogram got %v, want %v", vals.MACDHistogram, -5.0/3)
+	}
+	if got := len(agg.History().MACD); got != 5 {
+		t.Fatalf("MACD history length got %d, want 5", got)
+	}
+}
+
+func TestStdDev(t *testing.T) {
+	sd, err := indicators.NewStdDev(8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got float64
+	for _, price := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		got = sd.Update(price)
+	}
+	if math.Abs(got-2) > 1e-9 {
+		t.Fatalf("stddev got %v, want 2", got)
+	}
+}
+
+func TestBollingerBands(t *testing.T) {
+	if _, err := indicators.NewBollingerBands(20, 0); err == nil {
+		t.Fatal("expected error for non-positive multiplier")
+	}
+
+	bb, err := indicators.NewBollingerBands(8, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	prices := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	for i, price := range prices {
+		middle := bb.Update(price)
+		if i < len(prices)-1 && middle != 0 {
+			t.Fatalf("middle band before warmup = %v, want 0", middle)
+		}
+	}
+
+	// mean 5, stddev 2 => bands 1 and 9, last price 9 sits on the upper band
+	if math.Abs(bb.Middle()-5) > 1e-9 || math.Abs(bb.Upper()-9) > 1e-9 || math.Abs(bb.Lower()-1) > 1e-9 {
+		t.Fatalf("bands got %v/%v/%v, want 9/5/1", bb.Upper(), bb.Middle(), bb.Lower())
+	}
+	if math.Abs(bb.PercentB()-1) > 1e-9 {
+		t.Fatalf("%%B got %v, want 1", bb.PercentB())
+	}
+
+	flat, _ := indicators.NewBollingerBands(3, 2)
+	for _, price := range []float64{10, 10, 10} {
+		flat.Update(price)
+	}
+	if flat.PercentB() != 0.5 {
+		t.Fatalf("%%B with zero-width bands got %v, want 0.5", flat.PercentB())
+	}
+}
+
+func TestATR(t *testing.T) {
+	atr, err := indicators.NewATR(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// true ranges: 2 (high-low), 3 (high-prev close), 4 (prev close-low), 1
+	bars := []indicators.Bar{
+		{High: 11, Low: 9, Close: 10},
+		{High: 13, Low: 11, Close: 12},
+		{High: 12, Low: 8, Close: 9},
+		{High: 9.5, Low: 8.5, Close: 9},
+	}
+	var got float64
+	for i, bar := range bars {
+		got = atr.UpdateBar(bar)
+		if i == 1 && got != 0 {
+			t.Fatalf("ATR before warmup = %v, want 0", got)
+		}
+		if i == 2 && math.Abs(got-3) > 1e-9 {
+			t.Fatalf("initial ATR = %v, want 3", got)
+		}
+	}
+
+	// Wilder smoothing: (3*2 + 1) / 3
+	if math.Abs(got-7.0/3) > 1e-9 {
+		t.Fatalf("ATR got %v, want %v", got, 7.0/3)
+	}
+}
+
+func TestStochastic(t *testing.T) {
+	stoch, err := indicators.NewStochastic(3, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bars := []indicators.Bar{
+		{High: 10, Low: 8, Close: 9},
+		{High: 12, Low: 9, Close: 11},
+		{High: 11, Low: 7, Close: 10},  // range 7-12 => %K 60
+		{High: 13, Low: 10, Close: 13}, // range 7-13 => %K 100
+	}
+	var k float64
+	for _, bar := range bars {
+		k = stoch.UpdateBar(bar)
+	}
+
+	if math.Abs(k-100) > 1e-9 {
+		t.Fatalf("%%K got %v, want 100", k)
+	}
+	if math.Abs(stoch.D()-80) > 1e-9 {
+		t.Fatalf("%%D got %v, want 80", stoch.D())
+	}
+}
+
+func TestWilliamsR(t *testing.T) {
+	willR, err := indicators.NewWilliamsR(3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bars := []indicators.Bar{
+		{High: 10, Low: 8, Close: 9},
+		{High: 12, Low: 9, Close: 11},
+		{High: 11, Low: 7, Close: 8}, // range 7-12 => -100 * (12-8)/5
+	}
+	var got float64
+	for _, bar := range bars {
+		got = willR.UpdateBar(bar)
+	}
+	if math.Abs(got+80) > 1e-9 {
+		t.Fatalf("%%R got %v, want -80", got)
+	}
+
+	flat, _ := indicators.NewWilliamsR(2)
+	flat.UpdateBar(indicators.PriceBar(5))
+	if got := flat.UpdateBar(indicators.PriceBar(5)); got != -50 {
+		t.Fatalf("%%R with flat range got %v, want -50", got)
+	}
+}
+
+func TestAggregatorAddBarFeedsRangeIndicators(t *testing.T) {
+	agg, err := indicators.NewAggregator(3, 3, 3, indicators.WithATR(2), indicators.WithStochastic(2, 1), indicators.WithWilliamsR(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	agg.AddBar(indicators.Bar{High: 11, Low: 9, Close: 10})
+	vals := agg.AddBar(indicators.Bar{High: 12, Low: 10, Close: 11})
+
+	if math.Abs(vals.ATR-2) > 1e-9 {
+		t.Fatalf("ATR got %v, want 2", vals.ATR)
+	}
+	if math.Abs(vals.StochK-200.0/3) > 1e-9 || math.Abs(vals.StochD-vals.StochK) > 1e-9 {
+		t.Fatalf("stochastic got %%K %v %%D %v, want %v", vals.StochK, vals.StochD, 200.0/3)
+	}
+	if math.Abs(vals.WilliamsR+100.0/3) > 1e-9 {
+		t.Fatalf("%%R got %v, want %v", vals.WilliamsR, -100.0/3)
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	day := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+	vwap := indicators.NewVWAP(true)
+	vwap.AddTrade(100, 1, day)
+	if got := vwap.AddTrade(110, 3, day.Add(time.Hour)); math.Abs(got-107.5) > 1e-9 {
+		t.Fatalf("VWAP got %v, want 107.5", got)
+	}
+	if got := vwap.AddTrade(120, 0, day.Add(time.Hour)); math.Abs(got-107.5) > 1e-9 {
+		t.Fatalf("zero-quantity trade changed VWAP to %v", got)
+	}
+
+	// crossing UTC midnight starts a new session
+	if got := vwap.AddTrade(200, 2, day.Add(3*time.Hour)); got != 200 {
+		t.Fatalf("VWAP after midnight got %v, want 200", got)
+	}
+
+	continuous := indicators.NewVWAP(false)
+	continuous.AddTrade(100, 1, day)
+	if got := continuous.AddTrade(200, 1, day.Add(3*time.Hour)); got != 150 {
+		t.Fatalf("VWAP without reset got %v, want 150", got)
+	}
+}
+
+func TestOBV(t *testing.T) {
+	obv := indicators.NewOBV()
+	bars := []indicators.Bar{
+		{Close: 10, Volume: 5},
+		{Close: 11, Volume: 3}, // up: +3
+		{Close: 9, Volume: 4},  // down: -4
+		{Close: 9, Volume: 8},  // unchanged
+		{Close: 12, Volume: 2}, // up: +2
+	}
+	var got float64
+	for _, bar := range bars {
+		got = obv.UpdateBar(bar)
+	}
+	if got != 1 {
+		t.Fatalf("OBV got %v, want 1", got)
+	}
+}
+
+func TestAggregatorVolumeIndicators(t *testing.T) {
+	agg, err := indicators.NewAggregator(3, 3, 3, indicators.WithVolumeSMA(2))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	agg.AddBar(indicators.Bar{Open: 10, High: 10, Low: 10, Close: 10, Volume: 4})
+	vals := agg.AddBar(indicators.Bar{Open: 10, High: 11, Low: 10, Close: 11, Volume: 6})
+	if vals.OBV != 6 || vals.VolumeSMA != 5 {
+		t.Fatalf("got OBV %v volume SMA %v, want 6 and 5", vals.OBV, vals.VolumeSMA)
+	}
+
+	now := time.Now()
+	agg.AddTrade(11, 1, now)
+	vals = agg.AddTrade(12, 1, now)
+	if vals.VWAP != 11.5 || agg.Values().VWAP != 11.5 {
+		t.Fatalf("VWAP got %v, want 11.5", vals.VWAP)
+	}
+	if live := agg.Live(indicators.PriceBar(12)); live.VWAP != 11.5 {
+		t.Fatalf("live VWAP got %v, want 11.5", live.VWAP)
+	}
+}
@@ -0,0 +1,146 @@
+package indicators_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+)
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := indicators.ParseSpecs("ema:50, EMA:200 ,rsi:7,, bb:20:2.5, obv")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []indicators.Spec{
+		{Name: "ema", Params: []float64{50}},
+		{Name: "ema", Params: []float64{200}},
+		{Name: "rsi", Params: []float64{7}},
+		{Name: "bb", Params: []float64{20, 2.5}},
+		{Name: "obv"},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Fatalf("got %+v, want %+v", specs, want)
+	}
+	if got := indicators.FormatSpecs(specs); got != "ema:50,ema:200,rsi:7,bb:20:2.5,obv" {
+		t.Fatalf("FormatSpecs() = %q", got)
+	}
+
+	for _, bad := range []string{":14", "ema:x", "ema:"} {
+		if _, err := indicators.ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRegistryResolve(t *testing.T) {
+	reg := indicators.DefaultRegistry()
+
+	spec, _, err := reg.Resolve(indicators.Spec{Name: "macd", Params: []float64{8}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Key() != "macd:8:26:9" {
+		t.Fatalf("resolved key = %q, want macd:8:26:9", spec.Key())
+	}
+
+	tests := []struct {
+		name string
+		spec indicators.Spec
+	}{
+		{"unknown indicator", indicators.Spec{Name: "nope"}},
+		{"too many params", indicators.Spec{Name: "ema", Params: []float64{1, 2}}},
+		{"fractional period", indicators.Spec{Name: "ema", Params: []float64{2.5}}},
+		{"below minimum", indicators.Spec{Name: "rsi", Params: []float64{0}}},
+		{"above maximum", indicators.Spec{Name: "sma", Params: []float64{1e18}}},
+		{"not a number", indicators.Spec{Name: "bb", Params: []float64{20, math.NaN()}}},
+		{"infinite", indicators.Spec{Name: "bb", Params: []float64{20, math.Inf(1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := reg.Resolve(tt.spec); err == nil {
+				t.Errorf("Resolve(%v) should fail", tt.spec)
+			}
+		})
+	}
+
+	// Constructor errors surface when building a set
+	if _, err := reg.NewSet([]indicators.Spec{{Name: "macd", Params: []float64{30, 26}}}); err == nil {
+		t.Error("NewSet() should reject a MACD fast period above the slow period")
+	}
+	if _, err := reg.NewSet([]indicators.Spec{{Name: "sma", Params: []float64{1e18}}}); err == nil {
+		t.Error("NewSet() should reject a period too long to allocate")
+	}
+}
+
+// constSeries is a custom indicator reporting a fixed value.
+type constSeries struct{ v float64 }
+
+func (s constSeries) AddBar(indicators.Bar)    {}
+func (s constSeries) Outputs() []float64       { return []float64{s.v} }
+func (s constSeries) Clone() indicators.Series { return s }
+
+func TestRegistryCustomIndicator(t *testing.T) {
+	reg := indicators.NewRegistry()
+	def := indicators.Definition{
+		Name:    "const",
+		Params:  []indicators.ParamSpec{{Name: "value", Kind: indicators.ParamFloat, Default: 1}},
+		Outputs: []string{"value"},
+		New: func(p []float64) (indicators.Series, error) {
+			return constSeries{p[0]}, nil
+		},
+	}
+	if err := reg.Register(def); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := reg.Register(def); err == nil {
+		t.Fatal("Register() should reject duplicate names")
+	}
+
+	set, err := reg.NewSet([]indicators.Spec{{Name: "const", Params: []float64{4.5}}})
+	if err != nil {
+		t.Fatalf("NewSet() error = %v", err)
+	}
+	if got := set.Values(); len(got) != 1 || got[0].Value != 4.5 || got[0].Spec.Key() != "const:4.5" {
+		t.Fatalf("Values() = %+v", got)
+	}
+}
+
+func TestAggregatorWithSpecs(t *testing.T) {
+	specs, _ := indicators.ParseSpecs("ema:3, sma:2, ema:3, macd:2:3:2")
+	agg, err := indicators.NewAggregator(3, 3, 3, indicators.WithSpecs(specs...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, price := range []float64{10, 20, 30, 40, 20} {
+		agg.AddBar(indicators.PriceBar(price))
+	}
+
+	values := agg.SpecValues()
+	if len(values) != 3 {
+		t.Fatalf("got %d values, want 3 (duplicate specs are merged)", len(values))
+	}
+	if values[0].Spec.Key() != "ema:3" || values[0].Value != agg.Values().EMA {
+		t.Errorf("ema:3 = %+v, want %v", values[0], agg.Values().EMA)
+	}
+	if values[1].Value != 30 {
+		t.Errorf("sma:2 = %v, want 30", values[1].Value)
+	}
+	macd := values[2]
+	if macd.Outputs == nil || math.Abs(macd.Outputs["histogram"]+5.0/3) > 1e-9 {
+		t.Errorf("macd outputs = %v, want histogram %v", macd.Outputs, -5.0/3)
+	}
+	if agg.SpecWarmup() != 5 {
+		t.Errorf("SpecWarmup() = %d, want 5", agg.SpecWarmup())
+	}
+
+	before := agg.SpecValues()
+	live := agg.LiveSpecValues(indicators.PriceBar(40))
+	if live[1].Value != 30 {
+		t.Errorf("live sma:2 = %v, want 30", live[1].Value)
+	}
+	if !reflect.DeepEqual(agg.SpecValues(), before) {
+		t.Error("LiveSpecValues() mutated the aggregator")
+	}
+}
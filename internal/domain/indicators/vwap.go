@@ -0,0 +1,57 @@
+package indicators
+
+import "time"
+
+// VWAP implements a session Volume Weighted Average Price over individual trades.
+// When daily reset is enabled the session restarts at every UTC midnight.
+type VWAP struct {
+	dailyReset  bool
+	session     time.Time
+	priceVolume float64
+	volume      float64
+	value       float64
+}
+
+// NewVWAP creates a VWAP. With dailyReset the accumulation restarts at UTC
+// midnight; otherwise it covers every trade since creation.
+func NewVWAP(dailyReset bool) *VWAP {
+	return &VWAP{dailyReset: dailyReset}
+}
+
+// AddTrade ingests a trade and returns the current VWAP.
+// Returns 0 until a trade with positive quantity has been seen in the session.
+func (v *VWAP) AddTrade(price, quantity float64, ts time.Time) float64 {
+	if v.dailyReset {
+		day := ts.UTC().Truncate(24 * time.Hour)
+		if !day.Equal(v.session) {
+			v.session = day
+			v.priceVolume = 0
+			v.volume = 0
+			v.value = 0
+		}
+	}
+	if quantity <= 0 {
+		return v.value
+	}
+
+	v.priceVolume += price * quantity
+	v.volume += quantity
+	v.value = v.priceVolume / v.volume
+	return v.value
+}
+
+// Value returns the last computed VWAP.
+func (v *VWAP) Value() float64 {
+	return v.value
+}
+
+// Volume returns the total quantity traded in the current session.
+func (v *VWAP) Volume() float64 {
+	return v.volume
+}
+
+// clone returns an independent copy of the VWAP state.
+func (v *VWAP) clone() *VWAP {
+	c := *v
+	return &c
+}
@@ -0,0 +1,65 @@
+package indicators
+
+import "fmt"
+
+// WilliamsR implements Williams %R: the close relative to the high-low range of
+// the last period bars, from 0 (close at the highest high) to -100 (at the lowest low).
+type WilliamsR struct {
+	period int
+	highs  *CircularBuffer
+	lows   *CircularBuffer
+	value  float64
+}
+
+// NewWilliamsR creates a Williams %R with the given period.
+func NewWilliamsR(period int) (*WilliamsR, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive")
+	}
+	highs, err := NewCircularBuffer(period)
+	if err != nil {
+		return nil, err
+	}
+	lows, err := NewCircularBuffer(period)
+	if err != nil {
+		return nil, err
+	}
+	return &WilliamsR{period: period, highs: highs, lows: lows}, nil
+}
+
+// UpdateBar ingests a bar and returns the current %R (-100 to 0).
+// Returns 0 until period bars are collected; %R is -50 when the range is flat.
+func (w *WilliamsR) UpdateBar(bar Bar) float64 {
+	w.highs.Push(bar.High)
+	w.lows.Push(bar.Low)
+	if !w.highs.Full() {
+		w.value = 0
+		return w.value
+	}
+
+	highest, lowest := w.highs.Max(), w.lows.Min()
+	if highest == lowest {
+		w.value = -50
+	} else {
+		w.value = -100 * (highest - bar.Close) / (highest - lowest)
+	}
+	return w.value
+}
+
+// Value returns the last computed %R.
+func (w *WilliamsR) Value() float64 {
+	return w.value
+}
+
+// Period returns the configured period.
+func (w *WilliamsR) Period() int {
+	return w.period
+}
+
+// clone returns an independent copy of the Williams %R state.
+func (w *WilliamsR) clone() *WilliamsR {
+	c := *w
+	c.highs = w.highs.clone()
+	c.lows = w.lows.clone()
+	return &c
+}
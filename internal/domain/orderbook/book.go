@@ -0,0 +1,102 @@
+package orderbook
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrStale is returned for a diff that the book already contains.
+	ErrStale = errors.New("orderbook: diff is older than the book")
+	// ErrGap is returned when a diff does not continue the book's sequence; the
+	// book must be rebuilt from a new snapshot.
+	ErrGap = errors.New("orderbook: diff sequence gap")
+)
+
+// Level is the total quantity resting at one price.
+type Level struct {
+	Price    float64
+	Quantity float64
+}
+
+// Diff is a batch of level changes covering the update ids FirstID through
+// FinalID. A zero quantity removes the level.
+type Diff struct {
+	FirstID int64
+	FinalID int64
+	Bids    []Level
+	Asks    []Level
+}
+
+// Book is a local order book kept in sync with an exchange by applying
+// sequenced diffs on top of a snapshot.
+type Book struct {
+	lastID int64
+	synced bool // a diff has been applied on top of the snapshot
+	bids   map[float64]float64
+	asks   map[float64]float64
+}
+
+// New creates a book from a snapshot taken at update id lastID.
+func New(lastID int64, bids, asks []Level) *Book {
+	b := &Book{
+		lastID: lastID,
+		bids:   make(map[float64]float64, len(bids)),
+		asks:   make(map[float64]float64, len(asks)),
+	}
+	setLevels(b.bids, bids)
+	setLevels(b.asks, asks)
+	return b
+}
+
+// LastID returns the update id the book is current to.
+func (b *Book) LastID() int64 {
+	return b.lastID
+}
+
+// Apply applies a diff. Diffs the book already contains return ErrStale and
+// are ignored. The first diff after the snapshot must span the update id
+// following it; every later one must start right after the previous one.
+// Otherwise Apply returns ErrGap and leaves the book unchanged.
+func (b *Book) Apply(d Diff) error {
+	if d.FinalID <= b.lastID {
+		return ErrStale
+	}
+	next := b.lastID + 1
+	if b.synced && d.FirstID != next || !b.synced && d.FirstID > next {
+		return fmt.Errorf("%w: expected update %d, got %d-%d", ErrGap, next, d.FirstID, d.FinalID)
+	}
+	setLevels(b.bids, d.Bids)
+	setLevels(b.asks, d.Asks)
+	b.lastID = d.FinalID
+	b.synced = true
+	return nil
+}
+
+// Top returns up to n of the best bids, highest first, and asks, lowest first.
+func (b *Book) Top(n int) (bids, asks []Level) {
+	return top(b.bids, n, func(a, b float64) bool { return a > b }), top(b.asks, n, func(a, b float64) bool { return a < b })
+}
+
+func setLevels(side map[float64]float64, levels []Level) {
+	for _, l := range levels {
+		if l.Quantity == 0 {
+			delete(side, l.Price)
+			continue
+		}
+		side[l.Price] = l.Quantity
+	}
+}
+
+func top(side map[float64]float64, n int, better func(a, b float64) bool) []Level {
+	levels := make([]Level, 0, len(side))
+	for price, qty := range side {
+		levels = append(levels, Level{Price: price, Quantity: qty})
+	}
+	sort.Slice(levels, func(i, j int) bool { return better(levels[i].Price, levels[j].Price) })
+	if n >= 0 && len(levels) > n {
+		levels = levels[:n]
+	}
+	return levels
+}
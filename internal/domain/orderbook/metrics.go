@@ -0,0 +1,41 @@
+package orderbook
+
+// Metrics summarises the top of a book.
+type Metrics struct {
+	BestBid float64
+	BestAsk float64
+	Mid     float64
+	// Spread is BestAsk-BestBid; SpreadBps is the spread in basis points of the mid price.
+	Spread    float64
+	SpreadBps float64
+	// Imbalance is (bid quantity - ask quantity) / (bid quantity + ask quantity)
+	// over the given levels, from -1 (only asks) to 1 (only bids).
+	Imbalance float64
+}
+
+// Measure computes the metrics of bids, best first, and asks, best first.
+// Price metrics are zero unless both sides have a level.
+func Measure(bids, asks []Level) Metrics {
+	var m Metrics
+	if len(bids) > 0 && len(asks) > 0 {
+		m.BestBid, m.BestAsk = bids[0].Price, asks[0].Price
+		m.Mid = (m.BestBid + m.BestAsk) / 2
+		m.Spread = m.BestAsk - m.BestBid
+		if m.Mid > 0 {
+			m.SpreadBps = m.Spread / m.Mid * 10000
+		}
+	}
+	bidQty, askQty := quantity(bids), quantity(asks)
+	if total := bidQty + askQty; total > 0 {
+		m.Imbalance = (bidQty - askQty) / total
+	}
+	return m
+}
+
+func quantity(levels []Level) float64 {
+	var sum float64
+	for _, l := range levels {
+		sum += l.Quantity
+	}
+	return sum
+}
@@ -0,0 +1,72 @@
+package orderbook_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/rp4ri/quantacode/internal/domain/orderbook"
+)
+
+func TestBookAppliesSequencedDiffs(t *testing.T) {
+	book := orderbook.New(100,
+		[]orderbook.Level{{Price: 99, Quantity: 1}, {Price: 98, Quantity: 2}},
+		[]orderbook.Level{{Price: 101, Quantity: 1}, {Price: 102, Quantity: 3}})
+
+	if err := book.Apply(orderbook.Diff{FirstID: 90, FinalID: 100}); !errors.Is(err, orderbook.ErrStale) {
+		t.Errorf("Apply(old diff) error = %v, want ErrStale", err)
+	}
+	if err := book.Apply(orderbook.Diff{FirstID: 102, FinalID: 105}); !errors.Is(err, orderbook.ErrGap) {
+		t.Errorf("Apply(diff after a gap) error = %v, want ErrGap", err)
+	}
+
+	// The first diff may overlap the snapshot
+	err := book.Apply(orderbook.Diff{FirstID: 95, FinalID: 103,
+		Bids: []orderbook.Level{{Price: 99, Quantity: 0}, {Price: 100, Quantity: 4}},
+		Asks: []orderbook.Level{{Price: 101, Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if book.LastID() != 103 {
+		t.Errorf("LastID() = %d, want 103", book.LastID())
+	}
+	// Later diffs must follow on exactly
+	if err := book.Apply(orderbook.Diff{FirstID: 103, FinalID: 106}); !errors.Is(err, orderbook.ErrGap) {
+		t.Errorf("Apply(overlapping diff) error = %v, want ErrGap", err)
+	}
+	if err := book.Apply(orderbook.Diff{FirstID: 104, FinalID: 104, Asks: []orderbook.Level{{Price: 100.5, Quantity: 1}}}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	bids, asks := book.Top(2)
+	wantBids := []orderbook.Level{{Price: 100, Quantity: 4}, {Price: 98, Quantity: 2}}
+	wantAsks := []orderbook.Level{{Price: 100.5, Quantity: 1}, {Price: 101, Quantity: 2}}
+	for i := range wantBids {
+		if bids[i] != wantBids[i] || asks[i] != wantAsks[i] {
+			t.Fatalf("Top(2) = %v / %v, want %v / %v", bids, asks, wantBids, wantAsks)
+		}
+	}
+	if bids, _ := book.Top(10); len(bids) != 2 {
+		t.Errorf("Top(10) returned %d bids, want all 2", len(bids))
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	m := orderbook.Measure(
+		[]orderbook.Level{{Price: 99.5, Quantity: 3}, {Price: 99, Quantity: 3}},
+		[]orderbook.Level{{Price: 100.5, Quantity: 2}})
+
+	if m.BestBid != 99.5 || m.BestAsk != 100.5 || m.Mid != 100 || m.Spread != 1 {
+		t.Errorf("Measure() = %+v", m)
+	}
+	if math.Abs(m.SpreadBps-100) > 1e-9 {
+		t.Errorf("SpreadBps = %v, want 100", m.SpreadBps)
+	}
+	if math.Abs(m.Imbalance-0.5) > 1e-9 {
+		t.Errorf("Imbalance = %v, want 0.5", m.Imbalance)
+	}
+
+	if m := orderbook.Measure(nil, []orderbook.Level{{Price: 1, Quantity: 1}}); m.Spread != 0 || m.Imbalance != -1 {
+		t.Errorf("Measure(asks only) = %+v, want no spread and imbalance -1", m)
+	}
+}
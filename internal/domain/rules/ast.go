@@ -0,0 +1,221 @@
+package rules
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+)
+
+// valueType is the static type of an expression.
+type valueType int
+
+const (
+	numberType valueType = iota
+	boolType
+)
+
+func (t valueType) String() string {
+	if t == boolType {
+		return "condition"
+	}
+	return "number"
+}
+
+// node is a parsed expression.
+type node interface {
+	typ() valueType
+	pos() int
+	String() string
+}
+
+// evaluator carries the environment and crossover state through one evaluation.
+type evaluator struct {
+	env    Env
+	prev   []crossState
+	next   []crossState
+	commit bool
+}
+
+type numberNode struct {
+	at    int
+	value float64
+}
+
+type fieldNode struct {
+	at   int
+	name string
+}
+
+type indicatorNode struct {
+	at     int
+	spec   indicators.Spec
+	output string // empty selects the primary output
+}
+
+type unaryNode struct {
+	at int
+	x  node
+}
+
+type arithNode struct {
+	op   string
+	l, r node
+}
+
+type compareNode struct {
+	op   string
+	l, r node
+}
+
+type crossNode struct {
+	above bool
+	slot  int // index into the rule's crossover state
+	l, r  node
+}
+
+type logicNode struct {
+	and  bool
+	l, r node
+}
+
+type notNode struct {
+	at int
+	x  node
+}
+
+func (n *numberNode) typ() valueType    { return numberType }
+func (n *fieldNode) typ() valueType     { return numberType }
+func (n *indicatorNode) typ() valueType { return numberType }
+func (n *unaryNode) typ() valueType     { return numberType }
+func (n *arithNode) typ() valueType     { return numberType }
+func (n *compareNode) typ() valueType   { return boolType }
+func (n *crossNode) typ() valueType     { return boolType }
+func (n *logicNode) typ() valueType     { return boolType }
+func (n *notNode) typ() valueType       { return boolType }
+
+func (n *numberNode) pos() int    { return n.at }
+func (n *fieldNode) pos() int     { return n.at }
+func (n *indicatorNode) pos() int { return n.at }
+func (n *unaryNode) pos() int     { return n.at }
+func (n *arithNode) pos() int     { return n.l.pos() }
+func (n *compareNode) pos() int   { return n.l.pos() }
+func (n *crossNode) pos() int     { return n.l.pos() }
+func (n *logicNode) pos() int     { return n.l.pos() }
+func (n *notNode) pos() int       { return n.at }
+
+func (n *numberNode) String() string { return strconv.FormatFloat(n.value, 'g', -1, 64) }
+func (n *fieldNode) String() string  { return n.name }
+func (n *indicatorNode) String() string {
+	params := make([]string, len(n.spec.Params))
+	for i, p := range n.spec.Params {
+		params[i] = strconv.FormatFloat(p, 'g', -1, 64)
+	}
+	s := n.spec.Name + "(" + strings.Join(params, ", ") + ")"
+	if n.output != "" {
+		s += "." + n.output
+	}
+	return s
+}
+func (n *unaryNode) String() string { return "-" + n.x.String() }
+func (n *arithNode) String() string {
+	return "(" + n.l.String() + " " + n.op + " " + n.r.String() + ")"
+}
+func (n *compareNode) String() string { return n.l.String() + " " + n.op + " " + n.r.String() }
+func (n *crossNode) String() string {
+	op := "crosses_below"
+	if n.above {
+		op = "crosses_above"
+	}
+	return n.l.String() + " " + op + " " + n.r.String()
+}
+func (n *logicNode) String() string {
+	op := "or"
+	if n.and {
+		op = "and"
+	}
+	return "(" + n.l.String() + " " + op + " " + n.r.String() + ")"
+}
+func (n *notNode) String() string { return "not " + n.x.String() }
+
+// number evaluates a numeric node. Missing values evaluate to NaN, which makes
+// every comparison that depends on them false.
+func (e *evaluator) number(n node) float64 {
+	switch n := n.(type) {
+	case *numberNode:
+		return n.value
+	case *fieldNode:
+		if v, ok := e.env.Field(n.name); ok {
+			return v
+		}
+	case *indicatorNode:
+		if v, ok := e.env.Indicator(n.spec, n.output); ok {
+			return v
+		}
+	case *unaryNode:
+		return -e.number(n.x)
+	case *arithNode:
+		l, r := e.number(n.l), e.number(n.r)
+		switch n.op {
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		case "/":
+			if r == 0 {
+				return math.NaN()
+			}
+			return l / r
+		}
+	}
+	return math.NaN()
+}
+
+// truth evaluates a boolean node. Both sides of and/or are always evaluated so
+// that every crossover sees every sample.
+func (e *evaluator) truth(n node) bool {
+	switch n := n.(type) {
+	case *compareNode:
+		l, r := e.number(n.l), e.number(n.r)
+		switch n.op {
+		case "<":
+			return l < r
+		case "<=":
+			return l <= r
+		case ">":
+			return l > r
+		case ">=":
+			return l >= r
+		case "==":
+			return l == r
+		case "!=":
+			return l != r
+		}
+	case *crossNode:
+		cur := crossState{left: e.number(n.l), right: e.number(n.r)}
+		cur.valid = !math.IsNaN(cur.left) && !math.IsNaN(cur.right)
+		prev := e.prev[n.slot]
+		if e.commit {
+			e.next[n.slot] = cur
+		}
+		if !prev.valid || !cur.valid {
+			return false
+		}
+		if n.above {
+			return prev.left <= prev.right && cur.left > cur.right
+		}
+		return prev.left >= prev.right && cur.left < cur.right
+	case *logicNode:
+		l, r := e.truth(n.l), e.truth(n.r)
+		if n.and {
+			return l && r
+		}
+		return l || r
+	case *notNode:
+		return !e.truth(n.x)
+	}
+	return false
+}
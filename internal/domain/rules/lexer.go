@@ -0,0 +1,154 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies a lexical token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokLParen
+	tokRParen
+	tokComma
+	tokDot
+	tokOp // comparison and arithmetic operators
+	tokAnd
+	tokOr
+	tokNot
+	tokCrossesAbove
+	tokCrossesBelow
+)
+
+var keywords = map[string]tokenKind{
+	"and":           tokAnd,
+	"or":            tokOr,
+	"not":           tokNot,
+	"crosses_above": tokCrossesAbove,
+	"crosses_below": tokCrossesBelow,
+}
+
+// token is one lexeme with its byte offset in the source.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// ParseError reports a syntax or validation error at a position in the source.
+type ParseError struct {
+	Source string
+	// Pos is the 1-based column of the offending character.
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos, e.Msg)
+}
+
+// Caret returns the source with a marker line under the offending column.
+func (e *ParseError) Caret() string {
+	return e.Source + "\n" + strings.Repeat(" ", max(e.Pos-1, 0)) + "^"
+}
+
+func errorAt(src string, offset int, format string, args ...any) *ParseError {
+	return &ParseError{Source: src, Pos: offset + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// lex splits src into tokens. Operators are <, <=, >, >=, ==, !=, +, -, * and /;
+// && and || are accepted as aliases of and/or.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			word := strings.ToLower(src[start:i])
+			kind, ok := keywords[word]
+			if !ok {
+				kind = tokIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start})
+		default:
+			start := i
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch {
+			case two == "<=" || two == ">=" || two == "==" || two == "!=":
+				tokens = append(tokens, token{kind: tokOp, text: two, pos: start})
+				i += 2
+			case two == "&&":
+				tokens = append(tokens, token{kind: tokAnd, text: two, pos: start})
+				i += 2
+			case two == "||":
+				tokens = append(tokens, token{kind: tokOr, text: two, pos: start})
+				i += 2
+			case strings.IndexByte("<>+-*/", c) >= 0:
+				tokens = append(tokens, token{kind: tokOp, text: string(c), pos: start})
+				i++
+			case c == '(':
+				tokens = append(tokens, token{kind: tokLParen, text: "(", pos: start})
+				i++
+			case c == ')':
+				tokens = append(tokens, token{kind: tokRParen, text: ")", pos: start})
+				i++
+			case c == ',':
+				tokens = append(tokens, token{kind: tokComma, text: ",", pos: start})
+				i++
+			case c == '.':
+				tokens = append(tokens, token{kind: tokDot, text: ".", pos: start})
+				i++
+			case c == '!':
+				tokens = append(tokens, token{kind: tokNot, text: "!", pos: start})
+				i++
+			default:
+				r := []rune(src[i:])[0]
+				if unicode.IsPrint(r) {
+					return nil, errorAt(src, i, "unexpected character %q", r)
+				}
+				return nil, errorAt(src, i, "unexpected character %U", r)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func isDigit(c byte) bool      { return c >= '0' && c <= '9' }
+func isIdentStart(c byte) bool { return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') }
+func isIdentPart(c byte) bool  { return isIdentStart(c) || isDigit(c) }
@@ -0,0 +1,316 @@
+package rules
+
+import (
+	"strconv"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+)
+
+// parser is a recursive-descent parser over the token stream. Precedence from
+// lowest to highest: or, and, not, comparisons and crossovers, + -, * /, unary minus.
+type parser struct {
+	src      string
+	tokens   []token
+	i        int
+	registry *indicators.Registry
+	crosses  int
+	specs    []indicators.Spec
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(at int, format string, args ...any) error {
+	return errorAt(p.src, at, format, args...)
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t.pos, "expected %s, found %s", what, t.describe())
+	}
+	return t, nil
+}
+
+func (p *parser) requireType(n node, want valueType, context string) error {
+	if n.typ() != want {
+		return p.errorf(n.pos(), "%s needs a %s, found %s %q", context, want, n.typ(), n.String())
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireType(left, boolType, "'or'"); err != nil {
+			return nil, err
+		}
+		if err := p.requireType(right, boolType, "'or'"); err != nil {
+			return nil, err
+		}
+		left = &logicNode{l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireType(left, boolType, "'and'"); err != nil {
+			return nil, err
+		}
+		if err := p.requireType(right, boolType, "'and'"); err != nil {
+			return nil, err
+		}
+		left = &logicNode{and: true, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind != tokNot {
+		return p.parseComparison()
+	}
+	op := p.next()
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.requireType(x, boolType, "'not'"); err != nil {
+		return nil, err
+	}
+	return &notNode{at: op.pos, x: x}, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	isCompare := t.kind == tokOp && (t.text == "<" || t.text == "<=" || t.text == ">" || t.text == ">=" || t.text == "==" || t.text == "!=")
+	isCross := t.kind == tokCrossesAbove || t.kind == tokCrossesBelow
+	if !isCompare && !isCross {
+		return left, nil
+	}
+	p.next()
+
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.requireType(left, numberType, strconv.Quote(t.text)); err != nil {
+		return nil, err
+	}
+	if err := p.requireType(right, numberType, strconv.Quote(t.text)); err != nil {
+		return nil, err
+	}
+
+	var n node
+	if isCross {
+		n = &crossNode{above: t.kind == tokCrossesAbove, slot: p.crosses, l: left, r: right}
+		p.crosses++
+	} else {
+		n = &compareNode{op: t.text, l: left, r: right}
+	}
+
+	if next := p.peek(); (next.kind == tokOp && next.text != "+" && next.text != "-" && next.text != "*" && next.text != "/") ||
+		next.kind == tokCrossesAbove || next.kind == tokCrossesBelow {
+		return nil, p.errorf(next.pos, "comparisons cannot be chained; combine them with 'and'")
+	}
+	return n, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokOp && (t.text == "+" || t.text == "-"); t = p.peek() {
+		p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.arith(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokOp && (t.text == "*" || t.text == "/"); t = p.peek() {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = p.arith(t, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) arith(op token, left, right node) (node, error) {
+	if err := p.requireType(left, numberType, strconv.Quote(op.text)); err != nil {
+		return nil, err
+	}
+	if err := p.requireType(right, numberType, strconv.Quote(op.text)); err != nil {
+		return nil, err
+	}
+	return &arithNode{op: op.text, l: left, r: right}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); t.kind == tokOp && t.text == "-" {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.requireType(x, numberType, "'-'"); err != nil {
+			return nil, err
+		}
+		if num, ok := x.(*numberNode); ok {
+			return &numberNode{at: t.pos, value: -num.value}, nil
+		}
+		return &unaryNode{at: t.pos, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t.pos, "invalid number %q", t.text)
+		}
+		return &numberNode{at: t.pos, value: v}, nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	case tokIdent:
+		return p.parseIdent(t)
+	default:
+		return nil, p.errorf(t.pos, "expected a value, found %s", t.describe())
+	}
+}
+
+// parseIdent parses a price field, a default indicator such as rsi, or a
+// registry indicator call such as ema(50) or macd(12, 26, 9).signal.
+func (p *parser) parseIdent(name token) (node, error) {
+	if p.peek().kind != tokLParen && p.peek().kind != tokDot && IsField(name.text) {
+		return &fieldNode{at: name.pos, name: canonicalField(name.text)}, nil
+	}
+
+	def, ok := p.registry.Lookup(name.text)
+	if !ok {
+		return nil, p.errorf(name.pos, "unknown field or indicator %q", name.text)
+	}
+
+	spec := indicators.Spec{Name: def.Name}
+	if p.peek().kind == tokLParen {
+		p.next()
+		for p.peek().kind != tokRParen {
+			if len(spec.Params) > 0 {
+				if _, err := p.expect(tokComma, "',' or ')'"); err != nil {
+					return nil, err
+				}
+			}
+			param, err := p.parseParam()
+			if err != nil {
+				return nil, err
+			}
+			spec.Params = append(spec.Params, param)
+		}
+		p.next()
+	}
+
+	resolved, _, err := p.registry.Resolve(spec)
+	if err != nil {
+		return nil, p.errorf(name.pos, "%v", err)
+	}
+	n := &indicatorNode{at: name.pos, spec: resolved}
+
+	if p.peek().kind == tokDot {
+		p.next()
+		out, err := p.expect(tokIdent, "an output name")
+		if err != nil {
+			return nil, err
+		}
+		if !hasOutput(def, out.text) {
+			return nil, p.errorf(out.pos, "indicator %q has no output %q (outputs: %v)", def.Name, out.text, def.Outputs)
+		}
+		if out.text != def.Outputs[0] {
+			n.output = out.text
+		}
+	}
+
+	p.specs = append(p.specs, resolved)
+	return n, nil
+}
+
+// parseParam reads a numeric literal, optionally negative.
+func (p *parser) parseParam() (float64, error) {
+	sign := 1.0
+	if t := p.peek(); t.kind == tokOp && t.text == "-" {
+		p.next()
+		sign = -1
+	}
+	t, err := p.expect(tokNumber, "a number")
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return 0, p.errorf(t.pos, "invalid number %q", t.text)
+	}
+	return sign * v, nil
+}
+
+func hasOutput(def indicators.Definition, name string) bool {
+	for _, out := range def.Outputs {
+		if out == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+// Package rules implements a small expression language for trading signals,
+// such as "rsi(14) < 30 and close > ema(50)" or "macd crosses_above macd_signal".
+//
+// Expressions combine price fields, the aggregator's default indicators and
+// registry indicator calls with arithmetic (+ - * /), comparisons
+// (< <= > >= == !=), crossovers (crosses_above, crosses_below) and the logical
+// operators and, or and not.
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+)
+
+// Env supplies the values a rule is evaluated against.
+type Env interface {
+	// Field returns a price field or default indicator value, see Fields.
+	Field(name string) (float64, bool)
+	// Indicator returns an output of a registry indicator. An empty output selects its primary value.
+	Indicator(spec indicators.Spec, output string) (float64, bool)
+}
+
+// crossState is the last committed sample of a crossover's two sides.
+type crossState struct {
+	left, right float64
+	valid       bool
+}
+
+// Result is the outcome of evaluating a rule.
+type Result struct {
+	Value bool
+	// Triggered is true when the rule became true on this evaluation.
+	Triggered bool
+}
+
+// Rule is a parsed expression. Crossovers compare against the previous
+// committed evaluation, so a Rule holds state and must not be shared between
+// independent series.
+type Rule struct {
+	Name   string
+	source string
+	root   node
+	specs  []indicators.Spec
+	prev   []crossState
+	last   bool
+}
+
+// Parse parses an expression using the default indicator registry. The rule's
+// name defaults to the expression itself.
+func Parse(src string) (*Rule, error) {
+	return ParseWith(indicators.DefaultRegistry(), src)
+}
+
+// ParseWith parses an expression, resolving indicator calls against registry.
+// Errors are *ParseError values carrying the column of the problem.
+func ParseWith(registry *indicators.Registry, src string) (*Rule, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, errorAt(src, 0, "empty expression")
+	}
+
+	p := &parser{src: src, tokens: tokens, registry: registry}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t.pos, "unexpected %s", t.describe())
+	}
+	if root.typ() != boolType {
+		return nil, p.errorf(root.pos(), "expression is a number, not a condition; compare it with < > == or crosses_above")
+	}
+
+	source := strings.TrimSpace(src)
+	return &Rule{
+		Name:   source,
+		source: source,
+		root:   root,
+		specs:  dedupeSpecs(p.specs),
+		prev:   make([]crossState, p.crosses),
+	}, nil
+}
+
+// ParseNamed parses "name: expression". Without a name prefix it behaves like Parse.
+// Error positions refer to the whole text.
+func ParseNamed(text string) (*Rule, error) {
+	name, expr, ok := strings.Cut(text, ":")
+	if !ok || !ValidName(strings.TrimSpace(name)) {
+		return Parse(text)
+	}
+
+	offset := len(name) + 1
+	rule, err := Parse(strings.Repeat(" ", offset) + expr)
+	if err != nil {
+		if perr, ok := err.(*ParseError); ok {
+			perr.Source = text
+		}
+		return nil, err
+	}
+	rule.Name = strings.TrimSpace(name)
+	return rule, nil
+}
+
+// ValidName reports whether s can name a rule: letters, digits, '_' and '-',
+// starting with a letter or '_'.
+func ValidName(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentPart(s[i]) && s[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// Source returns the expression text.
+func (r *Rule) Source() string {
+	return r.source
+}
+
+// String returns the parsed expression with explicit grouping, useful for debugging precedence.
+func (r *Rule) String() string {
+	return r.root.String()
+}
+
+// Specs returns the registry indicators the rule references, which must be
+// computed alongside the aggregator for the rule to evaluate.
+func (r *Rule) Specs() []indicators.Spec {
+	return append([]indicators.Spec(nil), r.specs...)
+}
+
+// Evaluate evaluates the rule and records the sample for future crossovers.
+// Call it once per closed bar.
+func (r *Rule) Evaluate(env Env) Result {
+	e := &evaluator{env: env, prev: r.prev, next: make([]crossState, len(r.prev)), commit: true}
+	value := e.truth(r.root)
+	result := Result{Value: value, Triggered: value && !r.last}
+	r.prev = e.next
+	r.last = value
+	return result
+}
+
+// Peek evaluates the rule against a provisional sample, such as a bar that is
+// still open, without recording it.
+func (r *Rule) Peek(env Env) Result {
+	e := &evaluator{env: env, prev: r.prev}
+	value := e.truth(r.root)
+	return Result{Value: value, Triggered: value && !r.last}
+}
+
+// Reset forgets crossover history and the last result.
+func (r *Rule) Reset() {
+	r.prev = make([]crossState, len(r.prev))
+	r.last = false
+}
+
+// Clone returns an independent copy of the rule with the same state.
+func (r *Rule) Clone() *Rule {
+	c := *r
+	c.prev = append([]crossState(nil), r.prev...)
+	return &c
+}
+
+func dedupeSpecs(specs []indicators.Spec) []indicators.Spec {
+	seen := make(map[string]bool)
+	var out []indicators.Spec
+	for _, s := range specs {
+		if !seen[s.Key()] {
+			seen[s.Key()] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SpecsOf returns the registry indicators referenced by any of the rules.
+func SpecsOf(rules []*Rule) []indicators.Spec {
+	var specs []indicators.Spec
+	for _, r := range rules {
+		specs = append(specs, r.specs...)
+	}
+	return dedupeSpecs(specs)
+}
+
+// ParseList parses one named rule per entry and rejects duplicate names.
+func ParseList(texts []string) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(texts))
+	names := make(map[string]bool)
+	for _, text := range texts {
+		rule, err := ParseNamed(text)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", text, err)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+)
+
+// fields maps every field name and alias to its canonical name.
+var fields = map[string]string{
+	"open":           "open",
+	"high":           "high",
+	"low":            "low",
+	"close":          "close",
+	"price":          "close",
+	"volume":         "volume",
+	"rsi":            "rsi",
+	"sma":            "sma",
+	"ema":            "ema",
+	"macd":           "macd",
+	"macd_signal":    "macd_signal",
+	"macd_hist":      "macd_hist",
+	"macd_histogram": "macd_hist",
+	"bb_upper":       "bb_upper",
+	"bb_middle":      "bb_middle",
+	"bb_lower":       "bb_lower",
+	"bb_percent_b":   "bb_percent_b",
+	"percent_b":      "bb_percent_b",
+	"atr":            "atr",
+	"stoch_k":        "stoch_k",
+	"stoch_d":        "stoch_d",
+	"willr":          "willr",
+	"williams_r":     "willr",
+	"vwap":           "vwap",
+	"obv":            "obv",
+	"volume_sma":     "volume_sma",
+}
+
+// IsField reports whether name is a price field or default indicator.
+func IsField(name string) bool {
+	_, ok := fields[strings.ToLower(name)]
+	return ok
+}
+
+func canonicalField(name string) string {
+	return fields[strings.ToLower(name)]
+}
+
+// Fields returns the canonical field names, sorted.
+func Fields() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, canonical := range fields {
+		if !seen[canonical] {
+			seen[canonical] = true
+			names = append(names, canonical)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot is an Env over a bar, the aggregator's default values and registry indicator values.
+type Snapshot struct {
+	Bar        indicators.Bar
+	Values     indicators.AggregatedValues
+	Indicators []indicators.Value
+}
+
+// Field implements Env.
+func (s Snapshot) Field(name string) (float64, bool) {
+	v := s.Values
+	switch canonicalField(name) {
+	case "open":
+		return s.Bar.Open, true
+	case "high":
+		return s.Bar.High, true
+	case "low":
+		return s.Bar.Low, true
+	case "close":
+		return s.Bar.Close, true
+	case "volume":
+		return s.Bar.Volume, true
+	case "rsi":
+		return v.RSI, true
+	case "sma":
+		return v.SMA, true
+	case "ema":
+		return v.EMA, true
+	case "macd":
+		return v.MACD, true
+	case "macd_signal":
+		return v.MACDSignal, true
+	case "macd_hist":
+		return v.MACDHistogram, true
+	case "bb_upper":
+		return v.BBUpper, true
+	case "bb_middle":
+		return v.BBMiddle, true
+	case "bb_lower":
+		return v.BBLower, true
+	case "bb_percent_b":
+		return v.BBPercentB, true
+	case "atr":
+		return v.ATR, true
+	case "stoch_k":
+		return v.StochK, true
+	case "stoch_d":
+		return v.StochD, true
+	case "willr":
+		return v.WilliamsR, true
+	case "vwap":
+		return v.VWAP, true
+	case "obv":
+		return v.OBV, true
+	case "volume_sma":
+		return v.VolumeSMA, true
+	}
+	return 0, false
+}
+
+// Indicator implements Env.
+func (s Snapshot) Indicator(spec indicators.Spec, output string) (float64, bool) {
+	key := spec.Key()
+	for _, v := range s.Indicators {
+		if v.Spec.Key() != key {
+			continue
+		}
+		if output == "" {
+			return v.Value, true
+		}
+		out, ok := v.Outputs[output]
+		return out, ok
+	}
+	return 0, false
+}
@@ -0,0 +1,214 @@
+package rules_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+)
+
+func mustParse(t *testing.T, src string) *rules.Rule {
+	t.Helper()
+	rule, err := rules.Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", src, err)
+	}
+	return rule
+}
+
+func snapshot(close float64, vals indicators.AggregatedValues, extra ...indicators.Value) rules.Snapshot {
+	return rules.Snapshot{
+		Bar:        indicators.Bar{Open: close, High: close, Low: close, Close: close},
+		Values:     vals,
+		Indicators: extra,
+	}
+}
+
+func TestEvaluateComparisons(t *testing.T) {
+	env := snapshot(105, indicators.AggregatedValues{RSI: 25, EMA: 100, MACD: 1.5, MACDSignal: 1},
+		indicators.Value{Spec: indicators.Spec{Name: "ema", Params: []float64{50}}, Value: 110},
+		indicators.Value{Spec: indicators.Spec{Name: "rsi", Params: []float64{14}}, Value: 28},
+		indicators.Value{
+			Spec:    indicators.Spec{Name: "bb", Params: []float64{20, 2}},
+			Value:   100,
+			Outputs: map[string]float64{"middle": 100, "upper": 104, "lower": 96, "percent_b": 1.1},
+		},
+	)
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"rsi < 30", true},
+		{"RSI(14) < 30 AND close > ema", true},
+		{"rsi(14) < 30 and close > ema(50)", false},
+		{"close < ema(50) or rsi > 70", true},
+		{"not rsi > 70", true},
+		{"!(rsi < 30)", false},
+		{"close - ema >= 5", true},
+		{"close > ema * 1.06", false},
+		{"close / ema == 1.05", true},
+		{"-rsi < -20", true},
+		{"macd > macd_signal && price > 100", true},
+		{"close > bb(20, 2).upper", true},
+		{"bb().percent_b > 1", true},
+		{"bb(20,2) == 100", true},
+		{"close > 1e2", true},
+		{"(rsi < 30 or rsi > 70) and volume == 0", true},
+		{"close > ema(200)", false}, // not computed: missing values never match
+		{"close / 0 > 1", false},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.src).Evaluate(env).Value; got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	tests := map[string]string{
+		"a_or_b_and_c": "rsi < 30 or rsi > 70 and close > ema",
+		"arith":        "close > ema + 2 * atr",
+	}
+	want := map[string]string{
+		"a_or_b_and_c": "(rsi < 30 or (rsi > 70 and close > ema))",
+		"arith":        "close > (ema + (2 * atr))",
+	}
+	for name, src := range tests {
+		if got := mustParse(t, src).String(); got != want[name] {
+			t.Errorf("%s: String() = %q, want %q", name, got, want[name])
+		}
+	}
+}
+
+func TestCrossovers(t *testing.T) {
+	above := mustParse(t, "close crosses_above ema")
+	below := mustParse(t, "rsi crosses_below 70")
+
+	steps := []struct {
+		close, ema, rsi float64
+		above, below    bool
+	}{
+		{close: 99, ema: 100, rsi: 75},                            // first sample only primes the state
+		{close: 100, ema: 100, rsi: 72},                           // touching is not crossing
+		{close: 101, ema: 100, rsi: 69, above: true, below: true}, // both cross
+		{close: 102, ema: 100, rsi: 65},                           // stays above
+		{close: 98, ema: 100, rsi: 71},
+		{close: 103, ema: 101, rsi: 60, above: true, below: true},
+	}
+	for i, s := range steps {
+		env := snapshot(s.close, indicators.AggregatedValues{EMA: s.ema, RSI: s.rsi})
+		if got := above.Evaluate(env).Value; got != s.above {
+			t.Errorf("step %d: crosses_above = %v, want %v", i, got, s.above)
+		}
+		if got := below.Evaluate(env).Value; got != s.below {
+			t.Errorf("step %d: crosses_below = %v, want %v", i, got, s.below)
+		}
+	}
+}
+
+func TestPeekDoesNotAdvanceCrossover(t *testing.T) {
+	rule := mustParse(t, "close crosses_above 100")
+	rule.Evaluate(snapshot(99, indicators.AggregatedValues{}))
+
+	// A live bar crossing is visible but not recorded
+	if !rule.Peek(snapshot(101, indicators.AggregatedValues{})).Value {
+		t.Error("Peek() should see the live crossover")
+	}
+	if !rule.Peek(snapshot(102, indicators.AggregatedValues{})).Value {
+		t.Error("Peek() should still compare against the last closed sample")
+	}
+	if !rule.Evaluate(snapshot(101, indicators.AggregatedValues{})).Value {
+		t.Error("Evaluate() should report the crossover on close")
+	}
+	if rule.Evaluate(snapshot(102, indicators.AggregatedValues{})).Value {
+		t.Error("crossover should not repeat while staying above")
+	}
+}
+
+func TestCrossoverSeesEverySampleUnderShortCircuit(t *testing.T) {
+	// The crossover must observe the first bar even though the left side is false
+	rule := mustParse(t, "volume > 0 and close crosses_above 100")
+	rule.Evaluate(rules.Snapshot{Bar: indicators.Bar{Close: 99}})
+	if !rule.Evaluate(rules.Snapshot{Bar: indicators.Bar{Close: 101, Volume: 1}}).Value {
+		t.Error("expected crossover after a bar where the other condition was false")
+	}
+}
+
+func TestTriggered(t *testing.T) {
+	rule := mustParse(t, "rsi < 30")
+	var triggered []bool
+	for _, rsi := range []float64{40, 25, 20, 35, 28} {
+		triggered = append(triggered, rule.Evaluate(snapshot(0, indicators.AggregatedValues{RSI: rsi})).Triggered)
+	}
+	if want := []bool{false, true, false, false, true}; !reflect.DeepEqual(triggered, want) {
+		t.Errorf("Triggered = %v, want %v", triggered, want)
+	}
+}
+
+func TestSpecs(t *testing.T) {
+	rule := mustParse(t, "ema(50) crosses_above ema(200) and rsi(14) < 70 and ema(50) > sma and macd().signal > 0")
+	var keys []string
+	for _, s := range rule.Specs() {
+		keys = append(keys, s.Key())
+	}
+	want := []string{"ema:50", "ema:200", "rsi:14", "macd:12:26:9"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Specs() = %v, want %v", keys, want)
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	tests := []struct {
+		src string
+		pos int
+	}{
+		{"", 1},
+		{"rsi < ", 7},
+		{"rsi < 30 and", 13},
+		{"foo > 1", 1},
+		{"rsi < 30 $ 1", 10},
+		{"close > ema(50", 15},
+		{"ema(50, 60) > 1", 1},
+		{"bb(20).width > 1", 8},
+		{"rsi + 1", 1},
+		{"rsi < 30 and 5", 14},
+		{"(rsi < 30) + 1 > 2", 2},
+		{"1 < rsi < 70", 9},
+		{"rsi < 30)", 9},
+		{"ema(-5) > 1", 1},
+	}
+	for _, tt := range tests {
+		_, err := rules.Parse(tt.src)
+		var perr *rules.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(%q) error = %v, want *ParseError", tt.src, err)
+			continue
+		}
+		if perr.Pos != tt.pos {
+			t.Errorf("Parse(%q) error at column %d (%v), want %d", tt.src, perr.Pos, perr, tt.pos)
+		}
+	}
+}
+
+func TestParseNamed(t *testing.T) {
+	rule, err := rules.ParseNamed("oversold: rsi(14) < 30")
+	if err != nil {
+		t.Fatalf("ParseNamed() error = %v", err)
+	}
+	if rule.Name != "oversold" || rule.Source() != "rsi(14) < 30" {
+		t.Errorf("Name, Source = %q, %q", rule.Name, rule.Source())
+	}
+
+	_, err = rules.ParseNamed("oversold: rsi < ")
+	var perr *rules.ParseError
+	if !errors.As(err, &perr) || perr.Pos != 17 || perr.Source != "oversold: rsi < " {
+		t.Errorf("ParseNamed() error = %#v, want column 17 of the full text", err)
+	}
+
+	if _, err := rules.ParseList([]string{"a: rsi < 30", "a: rsi > 70"}); err == nil {
+		t.Error("ParseList() should reject duplicate names")
+	}
+}
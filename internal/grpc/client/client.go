@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
@@ -21,15 +23,177 @@ type PriceUpdate struct {
 	Timestamp time.Time
 }
 
-// IndicatorUpdate represents indicator values.
+// Candle is an OHLCV bar.
+type Candle struct {
+	OpenTime  time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	CloseTime time.Time
+}
+
+// LiveIndicators are indicator values that include the in-progress bar.
+type LiveIndicators struct {
+	RSI           float64
+	SMA           float64
+	EMA           float64
+	MACD          float64
+	MACDSignal    float64
+	MACDHistogram float64
+	BBUpper       float64
+	BBMiddle      float64
+	BBLower       float64
+	BBPercentB    float64
+	ATR           float64
+	StochK        float64
+	StochD        float64
+	WilliamsR     float64
+	VWAP          float64
+	OBV           float64
+	VolumeSMA     float64
+	Values        map[string]IndicatorValue
+	Rules         []RuleResult
+	Bar           Candle
+}
+
+// IndicatorUpdate represents indicator values. RSI, SMA, EMA and the histories
+// are computed on closed bars; Live, when set, includes the in-progress bar.
 type IndicatorUpdate struct {
-	RSI        float64
-	SMA        float64
-	EMA        float64
-	Timestamp  time.Time
-	RSIHistory []float64
-	SMAHistory []float64
-	EMAHistory []float64
+	Symbol               string
+	Interval             string
+	RSI                  float64
+	SMA                  float64
+	EMA                  float64
+	MACD                 float64
+	MACDSignal           float64
+	MACDHistogram        float64
+	Timestamp            time.Time
+	RSIHistory           []float64
+	SMAHistory           []float64
+	EMAHistory           []float64
+	MACDHistory          []float64
+	MACDSignalHistory    []float64
+	MACDHistogramHistory []float64
+	BBUpper              float64
+	BBMiddle             float64
+	BBLower              float64
+	BBPercentB           float64
+	BBUpperHistory       []float64
+	BBLowerHistory       []float64
+	BBPercentBHistory    []float64
+	ATR                  float64
+	StochK               float64
+	StochD               float64
+	WilliamsR            float64
+	VWAP                 float64
+	OBV                  float64
+	VolumeSMA            float64
+	Values               map[string]IndicatorValue // requested registry indicators keyed by spec, e.g. "ema:50"
+	Rules                []RuleResult              // requested signal rules on the last closed bar
+	Live                 *LiveIndicators
+	BarClosed            bool
+	// Coalesced counts the ticks the server folded into later updates because of
+	// the requested update rate; Dropped counts ticks lost because the stream fell
+	// behind the exchange feed. Both are totals since the symbol was subscribed.
+	Coalesced uint64
+	Dropped   uint64
+}
+
+// IndicatorValue is the output of a requested registry indicator.
+type IndicatorValue struct {
+	Name    string
+	Params  []float64
+	Value   float64
+	Outputs map[string]float64 // every named output for multi-output indicators
+}
+
+// RuleResult is the state of a requested signal rule.
+type RuleResult struct {
+	Name      string
+	Value     bool
+	Triggered bool // the rule became true on this update
+}
+
+// AlertRule raises an Alert when its condition becomes true. Set either
+// Expression or MovePercent and MoveWindow.
+type AlertRule struct {
+	ID     string
+	Symbol string // empty watches every subscribed symbol
+	// Expression is a signal rule such as "rsi crosses_above 70".
+	Expression string
+	// MovePercent fires on a price move of at least this percent within MoveWindow.
+	MovePercent float64
+	MoveWindow  time.Duration // sent in whole minutes; see alerts.ParseMove
+	Cooldown    time.Duration // zero uses the server default
+	Message     string
+}
+
+func (r AlertRule) proto() *pb.AlertRule {
+	msg := &pb.AlertRule{
+		Id:              r.ID,
+		Symbol:          r.Symbol,
+		CooldownSeconds: int32(r.Cooldown / time.Second),
+		Message:         r.Message,
+	}
+	if r.Expression != "" {
+		msg.Condition = &pb.AlertRule_Expression{Expression: r.Expression}
+	} else if r.MovePercent > 0 {
+		msg.Condition = &pb.AlertRule_Move{Move: &pb.PriceMove{Percent: r.MovePercent, Minutes: int32(r.MoveWindow / time.Minute)}}
+	}
+	return msg
+}
+
+// Alert is a fired alert rule.
+type Alert struct {
+	RuleID    string
+	Symbol    string
+	Message   string
+	Condition string
+	Price     float64
+	Timestamp time.Time
+}
+
+// OrderBookUpdate is the top of a symbol's order book.
+type OrderBookUpdate struct {
+	Symbol    string
+	Bids      []BookLevel // highest first
+	Asks      []BookLevel // lowest first
+	BestBid   float64
+	BestAsk   float64
+	Mid       float64
+	Spread    float64
+	SpreadBps float64
+	Imbalance float64 // (bid - ask) / (bid + ask) quantity over the levels, from -1 to 1
+	Timestamp time.Time
+}
+
+// BookLevel is the quantity resting at one price.
+type BookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// StatusUpdate is the state of the server's upstream connection for a symbol.
+type StatusUpdate struct {
+	Symbol    string
+	Exchange  string
+	State     string // connecting, live, degraded, reconnecting or failed
+	Endpoint  string
+	Simulated bool          // fed by a simulation or a recording, not the exchange
+	LastData  time.Duration // age of the upstream's last data when sent, -1 if none
+	Error     string
+	Timestamp time.Time // when the state was entered
+}
+
+// SymbolChannels receives the updates for one symbol of a multi-symbol stream.
+type SymbolChannels struct {
+	Prices     chan<- PriceUpdate
+	Indicators chan<- IndicatorUpdate
+	Alerts     chan<- Alert
+	OrderBooks chan<- OrderBookUpdate // requires StreamConfig.DepthLevels
+	Statuses   chan<- StatusUpdate
 }
 
 // Client manages gRPC connection to the server.
@@ -57,21 +221,79 @@ func (c *Client) Close() error {
 }
 
 // StreamPrices starts streaming prices and indicators.
-func (c *Client) StreamPrices(ctx context.Context, symbol string, priceCh chan<- PriceUpdate, indicatorCh chan<- IndicatorUpdate) error {
-	req := &pb.StreamRequest{
-		Symbol: symbol,
-		Indicators: &pb.IndicatorConfig{
-			RsiPeriod: 14,
-			SmaPeriod: 14,
-			EmaPeriod: 14,
-		},
+func (c *Client) StreamPrices(ctx context.Context, symbol string, cfg StreamConfig, priceCh chan<- PriceUpdate, indicatorCh chan<- IndicatorUpdate) error {
+	req, err := newStreamRequest([]string{symbol}, cfg)
+	if err != nil {
+		return err
 	}
+	stream, err := c.client.StreamPrices(ctx, req)
+	if err != nil {
+		return fmt.Errorf("start stream: %w", err)
+	}
+
+	return receive(stream, func(string) (SymbolChannels, bool) {
+		return SymbolChannels{Prices: priceCh, Indicators: indicatorCh}, true
+	}, nil, nil)
+}
 
+// StreamMulti streams several symbols over a single gRPC stream and routes each
+// update to the channels registered for its symbol.
+func (c *Client) StreamMulti(ctx context.Context, cfg StreamConfig, channels map[string]SymbolChannels) error {
+	if len(channels) == 0 {
+		return fmt.Errorf("no symbols requested")
+	}
+
+	routes := make(map[string]SymbolChannels, len(channels))
+	symbols := make([]string, 0, len(channels))
+	for symbol, ch := range channels {
+		routes[strings.ToUpper(symbol)] = ch
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	req, err := newStreamRequest(symbols, cfg)
+	if err != nil {
+		return err
+	}
 	stream, err := c.client.StreamPrices(ctx, req)
 	if err != nil {
 		return fmt.Errorf("start stream: %w", err)
 	}
 
+	return receive(stream, func(symbol string) (SymbolChannels, bool) {
+		ch, ok := routes[strings.ToUpper(symbol)]
+		return ch, ok
+	}, nil, nil)
+}
+
+func newStreamRequest(symbols []string, cfg StreamConfig) (*pb.StreamRequest, error) {
+	indicatorCfg, err := cfg.indicatorsProto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StreamRequest{
+		Symbol:      symbols[0],
+		Symbols:     symbols[1:],
+		Interval:    cfg.Interval,
+		Indicators:  indicatorCfg,
+		Rate:        cfg.rateProto(),
+		DepthLevels: int32(cfg.DepthLevels),
+		Exchange:    cfg.Exchange,
+	}, nil
+}
+
+// updateReceiver is the receiving half of both the StreamPrices and Control streams.
+type updateReceiver interface {
+	Recv() (*pb.MarketUpdate, error)
+}
+
+// receive reads the stream until it ends, delivering each update to the channels
+// returned by route. Indicator history windows are rebuilt from snapshots and
+// deltas; when an update was missed onGap is called with its symbol, and the
+// windows stay frozen until the server sends the next snapshot. Command
+// acknowledgements are passed to onAck. Both callbacks may be nil.
+func receive(stream updateReceiver, route func(symbol string) (SymbolChannels, bool), onAck func(*pb.CommandAck), onGap func(symbol string)) error {
+	windows := make(map[string]*indicatorWindow)
 	for {
 		msg, err := stream.Recv()
 		if err == io.EOF {
@@ -83,24 +305,195 @@ func (c *Client) StreamPrices(ctx context.Context, symbol string, priceCh chan<-
 
 		switch update := msg.Update.(type) {
 		case *pb.MarketUpdate_Price:
-			priceCh <- PriceUpdate{
+			ch, ok := route(update.Price.Symbol)
+			if !ok || ch.Prices == nil {
+				continue
+			}
+			ch.Prices <- PriceUpdate{
 				Symbol:    update.Price.Symbol,
 				Price:     update.Price.Price,
 				Volume:    update.Price.Volume,
 				Timestamp: time.UnixMilli(update.Price.Timestamp),
 			}
 		case *pb.MarketUpdate_Indicators:
-			indicatorCh <- IndicatorUpdate{
-				RSI:        update.Indicators.Rsi,
-				SMA:        update.Indicators.Sma,
-				EMA:        update.Indicators.Ema,
-				Timestamp:  time.UnixMilli(update.Indicators.Timestamp),
-				RSIHistory: update.Indicators.RsiHistory,
-				SMAHistory: update.Indicators.SmaHistory,
-				EMAHistory: update.Indicators.EmaHistory,
+			window, ok := windows[update.Indicators.Symbol]
+			if !ok {
+				window = &indicatorWindow{}
+				windows[update.Indicators.Symbol] = window
+			}
+			if window.apply(update.Indicators) && onGap != nil {
+				onGap(update.Indicators.Symbol)
+			}
+
+			ch, ok := route(update.Indicators.Symbol)
+			if !ok || ch.Indicators == nil {
+				continue
+			}
+			out := IndicatorUpdate{
+				Symbol:        update.Indicators.Symbol,
+				Interval:      update.Indicators.Interval,
+				RSI:           update.Indicators.Rsi,
+				SMA:           update.Indicators.Sma,
+				EMA:           update.Indicators.Ema,
+				MACD:          update.Indicators.Macd,
+				MACDSignal:    update.Indicators.MacdSignal,
+				MACDHistogram: update.Indicators.MacdHistogram,
+				Timestamp:     time.UnixMilli(update.Indicators.Timestamp),
+				BBUpper:       update.Indicators.BbUpper,
+				BBMiddle:      update.Indicators.BbMiddle,
+				BBLower:       update.Indicators.BbLower,
+				BBPercentB:    update.Indicators.BbPercentB,
+				ATR:           update.Indicators.Atr,
+				StochK:        update.Indicators.StochK,
+				StochD:        update.Indicators.StochD,
+				WilliamsR:     update.Indicators.WilliamsR,
+				VWAP:          update.Indicators.Vwap,
+				OBV:           update.Indicators.Obv,
+				VolumeSMA:     update.Indicators.VolumeSma,
+				Values:        valuesFromProto(update.Indicators.Values),
+				Rules:         rulesFromProto(update.Indicators.Rules),
+				Live:          liveFromProto(update.Indicators.Live),
+				BarClosed:     update.Indicators.BarClosed,
+				Coalesced:     update.Indicators.Coalesced,
+				Dropped:       update.Indicators.Dropped,
+			}
+			window.fill(&out)
+			ch.Indicators <- out
+		case *pb.MarketUpdate_Alert:
+			ch, ok := route(update.Alert.Symbol)
+			if !ok || ch.Alerts == nil {
+				continue
+			}
+			ch.Alerts <- Alert{
+				RuleID:    update.Alert.RuleId,
+				Symbol:    update.Alert.Symbol,
+				Message:   update.Alert.Message,
+				Condition: update.Alert.Condition,
+				Price:     update.Alert.Price,
+				Timestamp: time.UnixMilli(update.Alert.Timestamp),
+			}
+		case *pb.MarketUpdate_OrderBook:
+			ch, ok := route(update.OrderBook.Symbol)
+			if !ok || ch.OrderBooks == nil {
+				continue
+			}
+			book := update.OrderBook
+			ch.OrderBooks <- OrderBookUpdate{
+				Symbol:    book.Symbol,
+				Bids:      levelsFromProto(book.Bids),
+				Asks:      levelsFromProto(book.Asks),
+				BestBid:   book.BestBid,
+				BestAsk:   book.BestAsk,
+				Mid:       book.Mid,
+				Spread:    book.Spread,
+				SpreadBps: book.SpreadBps,
+				Imbalance: book.Imbalance,
+				Timestamp: time.UnixMilli(book.Timestamp),
+			}
+		case *pb.MarketUpdate_Status:
+			ch, ok := route(update.Status.Symbol)
+			if !ok || ch.Statuses == nil {
+				continue
+			}
+			status := update.Status
+			lastData := time.Duration(-1)
+			if status.LastMessageAgeMs >= 0 {
+				lastData = time.Duration(status.LastMessageAgeMs) * time.Millisecond
+			}
+			ch.Statuses <- StatusUpdate{
+				Symbol:    status.Symbol,
+				Exchange:  status.Exchange,
+				State:     status.State,
+				Endpoint:  status.Endpoint,
+				Simulated: status.Simulated,
+				LastData:  lastData,
+				Error:     status.Error,
+				Timestamp: time.UnixMilli(status.Timestamp),
+			}
+		case *pb.MarketUpdate_Ack:
+			if onAck != nil {
+				onAck(update.Ack)
 			}
 		default:
 			log.Printf("unknown update type: %T", update)
 		}
 	}
 }
+
+func levelsFromProto(levels []*pb.PriceLevel) []BookLevel {
+	out := make([]BookLevel, len(levels))
+	for i, l := range levels {
+		out[i] = BookLevel{Price: l.GetPrice(), Quantity: l.GetQuantity()}
+	}
+	return out
+}
+
+func liveFromProto(live *pb.LiveIndicators) *LiveIndicators {
+	if live == nil {
+		return nil
+	}
+	return &LiveIndicators{
+		RSI:           live.Rsi,
+		SMA:           live.Sma,
+		EMA:           live.Ema,
+		MACD:          live.Macd,
+		MACDSignal:    live.MacdSignal,
+		MACDHistogram: live.MacdHistogram,
+		BBUpper:       live.BbUpper,
+		BBMiddle:      live.BbMiddle,
+		BBLower:       live.BbLower,
+		BBPercentB:    live.BbPercentB,
+		ATR:           live.Atr,
+		StochK:        live.StochK,
+		StochD:        live.StochD,
+		WilliamsR:     live.WilliamsR,
+		VWAP:          live.Vwap,
+		OBV:           live.Obv,
+		VolumeSMA:     live.VolumeSma,
+		Values:        valuesFromProto(live.Values),
+		Rules:         rulesFromProto(live.Rules),
+		Bar:           candleFromProto(live.Bar),
+	}
+}
+
+func valuesFromProto(values map[string]*pb.IndicatorValue) map[string]IndicatorValue {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]IndicatorValue, len(values))
+	for key, v := range values {
+		out[key] = IndicatorValue{
+			Name:    v.GetName(),
+			Params:  v.GetParams(),
+			Value:   v.GetValue(),
+			Outputs: v.GetOutputs(),
+		}
+	}
+	return out
+}
+
+func rulesFromProto(results []*pb.RuleResult) []RuleResult {
+	if len(results) == 0 {
+		return nil
+	}
+	out := make([]RuleResult, len(results))
+	for i, r := range results {
+		out[i] = RuleResult{Name: r.GetName(), Value: r.GetValue(), Triggered: r.GetTriggered()}
+	}
+	return out
+}
+
+func candleFromProto(c *pb.Candle) Candle {
+	if c == nil {
+		return Candle{}
+	}
+	return Candle{
+		OpenTime:  time.UnixMilli(c.OpenTime),
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+		CloseTime: time.UnixMilli(c.CloseTime),
+	}
+}
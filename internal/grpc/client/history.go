@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// HistoryRequest selects closed candles of a symbol by open time. A zero From
+// returns the last Limit candles before To, a zero To means now and a zero
+// Limit uses the server default of 500 (at most 1000).
+type HistoryRequest struct {
+	Symbol   string
+	Interval string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// IndicatorSeries is one indicator computed over a range of candles. Values
+// are NaN where the indicator had not seen enough candles yet.
+type IndicatorSeries struct {
+	Key     string // canonical spec, e.g. "ema:50"
+	Name    string
+	Params  []float64
+	Values  []float64
+	Outputs map[string][]float64 // every named output for multi-output indicators
+}
+
+// IndicatorHistory holds indicator series aligned with candle open times.
+type IndicatorHistory struct {
+	Symbol     string
+	Interval   string
+	Timestamps []time.Time
+	Series     []IndicatorSeries
+}
+
+// GetCandles fetches closed candles from the server, oldest first.
+func (c *Client) GetCandles(ctx context.Context, req HistoryRequest) ([]Candle, error) {
+	from, to := req.millis()
+	resp, err := c.client.GetCandles(ctx, &pb.CandlesRequest{
+		Symbol:   req.Symbol,
+		Interval: req.Interval,
+		From:     from,
+		To:       to,
+		Limit:    int32(req.Limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get candles: %w", err)
+	}
+
+	out := make([]Candle, len(resp.GetCandles()))
+	for i, c := range resp.GetCandles() {
+		out[i] = candleFromProto(c)
+	}
+	return out, nil
+}
+
+// GetIndicatorSeries computes registry indicators such as "ema:50" or "macd:12:26:9"
+// over the requested candles on the server.
+func (c *Client) GetIndicatorSeries(ctx context.Context, req HistoryRequest, specs ...string) (*IndicatorHistory, error) {
+	msgs := make([]*pb.IndicatorSpec, len(specs))
+	for i, text := range specs {
+		spec, err := indicators.ParseSpec(text)
+		if err != nil {
+			return nil, err
+		}
+		msgs[i] = &pb.IndicatorSpec{Name: spec.Name, Params: spec.Params}
+	}
+
+	from, to := req.millis()
+	resp, err := c.client.GetIndicatorSeries(ctx, &pb.IndicatorSeriesRequest{
+		Symbol:   req.Symbol,
+		Interval: req.Interval,
+		From:     from,
+		To:       to,
+		Limit:    int32(req.Limit),
+		Specs:    msgs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get indicator series: %w", err)
+	}
+
+	history := &IndicatorHistory{Symbol: resp.GetSymbol(), Interval: resp.GetInterval()}
+	for _, ts := range resp.GetTimestamps() {
+		history.Timestamps = append(history.Timestamps, time.UnixMilli(ts))
+	}
+	for _, s := range resp.GetSeries() {
+		series := IndicatorSeries{Key: s.GetKey(), Name: s.GetName(), Params: s.GetParams(), Values: s.GetValues()}
+		if len(s.GetOutputs()) > 0 {
+			series.Outputs = make(map[string][]float64, len(s.GetOutputs()))
+			for name, out := range s.GetOutputs() {
+				series.Outputs[name] = out.GetValues()
+			}
+		}
+		history.Series = append(history.Series, series)
+	}
+	return history, nil
+}
+
+func (r HistoryRequest) millis() (from, to int64) {
+	if !r.From.IsZero() {
+		from = r.From.UnixMilli()
+	}
+	if !r.To.IsZero() {
+		to = r.To.UnixMilli()
+	}
+	return from, to
+}
+
+// GetSymbols lists the symbols an exchange trades; an empty exchange selects
+// the server's default.
+func (c *Client) GetSymbols(ctx context.Context, exchange string) ([]string, error) {
+	resp, err := c.client.GetSymbols(ctx, &pb.SymbolsRequest{Exchange: exchange})
+	if err != nil {
+		return nil, fmt.Errorf("get symbols: %w", err)
+	}
+	return resp.GetSymbols(), nil
+}
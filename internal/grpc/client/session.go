@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// resyncTimeout bounds how long a gap-triggered resync waits for its ack.
+const resyncTimeout = 10 * time.Second
+
+// StreamConfig holds the timeframe and indicator periods requested from the server.
+// Zero values leave the server default (or, when reconfiguring, the current value).
+type StreamConfig struct {
+	Interval   string
+	RSIPeriod  int
+	SMAPeriod  int
+	EMAPeriod  int
+	MACDFast   int
+	MACDSlow   int
+	MACDSignal int
+	BBPeriod   int
+	BBStdDev   float64
+	VolumeSMA  int
+	// VWAPDailyReset restarts the VWAP session at UTC midnight; nil keeps the server default.
+	VWAPDailyReset *bool
+	// Indicators requests additional registry indicators such as "ema:50" or "macd:8:21:5".
+	// When reconfiguring, a non-empty list replaces the current one.
+	Indicators []string
+	// Rules requests signal rules such as "oversold: rsi(14) < 30", evaluated by the server
+	// on every update. When reconfiguring, a non-empty list replaces the current one.
+	Rules []string
+	// MaxUpdatesPerSecond limits the price and indicator updates sent per symbol;
+	// the ticks in between are coalesced into the next update. Zero is unlimited.
+	// BarCloseOnly sends updates only when a bar closes. Alerts are never delayed.
+	// Both are fixed when a symbol is subscribed; reconfiguring ignores them.
+	MaxUpdatesPerSecond float64
+	BarCloseOnly        bool
+	// DepthLevels streams the top levels of the order book per side, up to 20.
+	// Zero disables order book updates. Fixed at subscription like the rate.
+	DepthLevels int
+	// Exchange selects the exchange to stream from, as in "kraken"; empty uses
+	// the server's default. Fixed at subscription like the rate.
+	Exchange string
+}
+
+// DefaultStreamConfig returns the configuration used when none is specified.
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		Interval:   "1h",
+		RSIPeriod:  14,
+		SMAPeriod:  14,
+		EMAPeriod:  14,
+		MACDFast:   12,
+		MACDSlow:   26,
+		MACDSignal: 9,
+		BBPeriod:   20,
+		BBStdDev:   2,
+		VolumeSMA:  20,
+	}
+}
+
+// rateProto returns the requested update rate, or nil for every tick.
+func (c StreamConfig) rateProto() *pb.UpdateRate {
+	if c.MaxUpdatesPerSecond <= 0 && !c.BarCloseOnly {
+		return nil
+	}
+	return &pb.UpdateRate{MaxPerSecond: max(c.MaxUpdatesPerSecond, 0), BarCloseOnly: c.BarCloseOnly}
+}
+
+func (c StreamConfig) indicatorsProto() (*pb.IndicatorConfig, error) {
+	var specs []*pb.IndicatorSpec
+	for _, text := range c.Indicators {
+		spec, err := indicators.ParseSpec(text)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, &pb.IndicatorSpec{Name: spec.Name, Params: spec.Params})
+	}
+
+	var signalRules []*pb.SignalRule
+	for _, text := range c.Rules {
+		rule, err := rules.ParseNamed(text)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", text, err)
+		}
+		signalRules = append(signalRules, &pb.SignalRule{Name: rule.Name, Expression: rule.Source()})
+	}
+
+	return &pb.IndicatorConfig{
+		RsiPeriod:       int32(c.RSIPeriod),
+		SmaPeriod:       int32(c.SMAPeriod),
+		EmaPeriod:       int32(c.EMAPeriod),
+		MacdFast:        int32(c.MACDFast),
+		MacdSlow:        int32(c.MACDSlow),
+		MacdSignal:      int32(c.MACDSignal),
+		BbPeriod:        int32(c.BBPeriod),
+		BbStddev:        c.BBStdDev,
+		VolumeSmaPeriod: int32(c.VolumeSMA),
+		VwapDailyReset:  c.VWAPDailyReset,
+		Specs:           specs,
+		Rules:           signalRules,
+	}, nil
+}
+
+// Session is a bidirectional control stream. Symbols can be subscribed,
+// unsubscribed and reconfigured without reopening the stream; updates for all
+// symbols are delivered to the channels given to OpenSession.
+type Session struct {
+	stream pb.MarketDataService_ControlClient
+	sendMu sync.Mutex
+	nextID atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[string]chan error
+
+	done chan struct{}
+	err  error
+}
+
+// OpenSession opens a control stream. Updates for every symbol are written to
+// channels until ctx is cancelled or the stream fails; nil channels drop their updates.
+func (c *Client) OpenSession(ctx context.Context, channels SymbolChannels) (*Session, error) {
+	stream, err := c.client.Control(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open control stream: %w", err)
+	}
+
+	s := &Session{
+		stream:  stream,
+		pending: make(map[string]chan error),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		err := receive(stream, func(string) (SymbolChannels, bool) {
+			return channels, true
+		}, s.handleAck, s.resyncAfterGap)
+		s.finish(err)
+	}()
+
+	return s, nil
+}
+
+// Subscribe starts streaming the given symbols and waits for the server to acknowledge.
+func (s *Session) Subscribe(ctx context.Context, cfg StreamConfig, symbols ...string) error {
+	indicatorCfg, err := cfg.indicatorsProto()
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_Subscribe{
+			Subscribe: &pb.SubscribeCommand{Symbols: symbols, Indicators: indicatorCfg, Interval: cfg.Interval, Rate: cfg.rateProto(), DepthLevels: int32(cfg.DepthLevels), Exchange: cfg.Exchange},
+		},
+	})
+}
+
+// Unsubscribe stops streaming the given symbols.
+func (s *Session) Unsubscribe(ctx context.Context, symbols ...string) error {
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_Unsubscribe{
+			Unsubscribe: &pb.UnsubscribeCommand{Symbols: symbols},
+		},
+	})
+}
+
+// Reconfigure changes the timeframe and indicator periods of symbol, or of every
+// subscribed symbol when symbol is empty. Zero fields in cfg are left unchanged.
+func (s *Session) Reconfigure(ctx context.Context, symbol string, cfg StreamConfig) error {
+	indicatorCfg, err := cfg.indicatorsProto()
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_Reconfigure{
+			Reconfigure: &pb.ReconfigureCommand{Symbol: symbol, Indicators: indicatorCfg, Interval: cfg.Interval},
+		},
+	})
+}
+
+// AddAlerts registers alert rules on the session. A rule with the id of an
+// existing one replaces it.
+func (s *Session) AddAlerts(ctx context.Context, rules ...AlertRule) error {
+	msgs := make([]*pb.AlertRule, len(rules))
+	for i, r := range rules {
+		msgs[i] = r.proto()
+	}
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_AddAlerts{AddAlerts: &pb.AddAlertsCommand{Alerts: msgs}},
+	})
+}
+
+// RemoveAlerts removes alert rules by id.
+func (s *Session) RemoveAlerts(ctx context.Context, ids ...string) error {
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_RemoveAlerts{RemoveAlerts: &pb.RemoveAlertsCommand{Ids: ids}},
+	})
+}
+
+// Resync asks the server for an indicator snapshot of the given symbols, or of
+// every subscribed symbol when none are given.
+func (s *Session) Resync(ctx context.Context, symbols ...string) error {
+	return s.do(ctx, &pb.ControlCommand{
+		Command: &pb.ControlCommand_Resync{Resync: &pb.ResyncCommand{Symbols: symbols}},
+	})
+}
+
+// resyncAfterGap requests a snapshot for a symbol whose indicator updates have
+// a gap. It runs off the receive loop, which must keep reading to see the ack.
+func (s *Session) resyncAfterGap(symbol string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), resyncTimeout)
+		defer cancel()
+		if err := s.Resync(ctx, symbol); err != nil {
+			log.Printf("resync %s: %v", symbol, err)
+		}
+	}()
+}
+
+// Done is closed when the session ends.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the session, if any.
+func (s *Session) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Close half-closes the stream, ending the session on the server.
+func (s *Session) Close() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	return s.stream.CloseSend()
+}
+
+// do sends a command and blocks until its acknowledgement arrives.
+func (s *Session) do(ctx context.Context, cmd *pb.ControlCommand) error {
+	cmd.Id = strconv.FormatUint(s.nextID.Add(1), 10)
+	ackCh := make(chan error, 1)
+
+	s.mu.Lock()
+	s.pending[cmd.Id] = ackCh
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, cmd.Id)
+		s.mu.Unlock()
+	}()
+
+	s.sendMu.Lock()
+	err := s.stream.Send(cmd)
+	s.sendMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("send command: %w", err)
+	}
+
+	select {
+	case err := <-ackCh:
+		return err
+	case <-s.done:
+		return fmt.Errorf("session closed: %w", s.err)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Session) handleAck(ack *pb.CommandAck) {
+	s.mu.Lock()
+	ackCh, ok := s.pending[ack.GetId()]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if ack.GetOk() {
+		ackCh <- nil
+		return
+	}
+	ackCh <- fmt.Errorf("server rejected command: %s", ack.GetError())
+}
+
+func (s *Session) finish(err error) {
+	s.err = err
+	close(s.done)
+}
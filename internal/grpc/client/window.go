@@ -0,0 +1,96 @@
+package client
+
+import (
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// indicatorWindow rebuilds the rolling history windows of one symbol from a
+// snapshot and the points appended by the deltas that follow it.
+type indicatorWindow struct {
+	seq    uint64
+	synced bool
+	size   int
+
+	rsi, sma, ema                   []float64
+	macd, macdSignal, macdHistogram []float64
+	bbUpper, bbLower, bbPercentB    []float64
+}
+
+// apply folds an update into the window. It reports a gap when the update does
+// not follow the previous one; the window then stays as it was until the next
+// snapshot, and further gaps are not reported again.
+func (w *indicatorWindow) apply(u *pb.IndicatorUpdate) (gap bool) {
+	switch {
+	case u.GetSnapshot():
+		w.seq = u.GetSequence()
+		w.synced = true
+		w.size = int(u.GetHistorySize())
+		w.rsi = clone(u.GetRsiHistory())
+		w.sma = clone(u.GetSmaHistory())
+		w.ema = clone(u.GetEmaHistory())
+		w.macd = clone(u.GetMacdHistory())
+		w.macdSignal = clone(u.GetMacdSignalHistory())
+		w.macdHistogram = clone(u.GetMacdHistogramHistory())
+		w.bbUpper = clone(u.GetBbUpperHistory())
+		w.bbLower = clone(u.GetBbLowerHistory())
+		w.bbPercentB = clone(u.GetBbPercentBHistory())
+		return false
+
+	case u.GetSequence() == 0:
+		// Servers without sequence numbers send the full windows every time
+		*w = indicatorWindow{}
+		w.rsi, w.sma, w.ema = u.GetRsiHistory(), u.GetSmaHistory(), u.GetEmaHistory()
+		w.macd, w.macdSignal, w.macdHistogram = u.GetMacdHistory(), u.GetMacdSignalHistory(), u.GetMacdHistogramHistory()
+		w.bbUpper, w.bbLower, w.bbPercentB = u.GetBbUpperHistory(), u.GetBbLowerHistory(), u.GetBbPercentBHistory()
+		return false
+
+	case !w.synced || u.GetSequence() != w.seq+1:
+		gap = w.synced
+		w.synced = false
+		w.seq = u.GetSequence()
+		return gap
+	}
+
+	w.seq = u.GetSequence()
+	for _, p := range u.GetAppended() {
+		w.rsi = push(w.rsi, p.GetRsi(), w.size)
+		w.sma = push(w.sma, p.GetSma(), w.size)
+		w.ema = push(w.ema, p.GetEma(), w.size)
+		w.macd = push(w.macd, p.GetMacd(), w.size)
+		w.macdSignal = push(w.macdSignal, p.GetMacdSignal(), w.size)
+		w.macdHistogram = push(w.macdHistogram, p.GetMacdHistogram(), w.size)
+		w.bbUpper = push(w.bbUpper, p.GetBbUpper(), w.size)
+		w.bbLower = push(w.bbLower, p.GetBbLower(), w.size)
+		w.bbPercentB = push(w.bbPercentB, p.GetBbPercentB(), w.size)
+	}
+	return false
+}
+
+// fill copies the windows into an update handed to the caller.
+func (w *indicatorWindow) fill(u *IndicatorUpdate) {
+	u.RSIHistory = clone(w.rsi)
+	u.SMAHistory = clone(w.sma)
+	u.EMAHistory = clone(w.ema)
+	u.MACDHistory = clone(w.macd)
+	u.MACDSignalHistory = clone(w.macdSignal)
+	u.MACDHistogramHistory = clone(w.macdHistogram)
+	u.BBUpperHistory = clone(w.bbUpper)
+	u.BBLowerHistory = clone(w.bbLower)
+	u.BBPercentBHistory = clone(w.bbPercentB)
+}
+
+// push appends v, dropping the oldest values beyond size when size is positive.
+func push(window []float64, v float64, size int) []float64 {
+	window = append(window, v)
+	if size > 0 && len(window) > size {
+		window = window[len(window)-size:]
+	}
+	return window
+}
+
+func clone(values []float64) []float64 {
+	if values == nil {
+		return nil
+	}
+	return append([]float64(nil), values...)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"slices"
+	"testing"
+
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+func TestIndicatorWindowSnapshotAndDeltas(t *testing.T) {
+	var w indicatorWindow
+	w.apply(&pb.IndicatorUpdate{Sequence: 1, Snapshot: true, HistorySize: 3, RsiHistory: []float64{1, 2, 3}, SmaHistory: []float64{10, 20, 30}})
+
+	if gap := w.apply(&pb.IndicatorUpdate{Sequence: 2}); gap {
+		t.Fatal("tick without a bar close reported a gap")
+	}
+	w.apply(&pb.IndicatorUpdate{Sequence: 3, Appended: []*pb.HistoryPoint{{Rsi: 4, Sma: 40}}})
+
+	var out IndicatorUpdate
+	w.fill(&out)
+	if !slices.Equal(out.RSIHistory, []float64{2, 3, 4}) || !slices.Equal(out.SMAHistory, []float64{20, 30, 40}) {
+		t.Errorf("windows = %v %v, want [2 3 4] [20 30 40]", out.RSIHistory, out.SMAHistory)
+	}
+	// Updates handed out must not share memory with the window
+	out.RSIHistory[0] = 99
+	w.fill(&out)
+	if out.RSIHistory[0] != 2 {
+		t.Error("fill returned the window's own slice")
+	}
+}
+
+func TestIndicatorWindowGap(t *testing.T) {
+	var w indicatorWindow
+	w.apply(&pb.IndicatorUpdate{Sequence: 1, Snapshot: true, HistorySize: 30, RsiHistory: []float64{1}})
+
+	if gap := w.apply(&pb.IndicatorUpdate{Sequence: 3, Appended: []*pb.HistoryPoint{{Rsi: 2}}}); !gap {
+		t.Fatal("skipped sequence was not reported")
+	}
+	if gap := w.apply(&pb.IndicatorUpdate{Sequence: 4, Appended: []*pb.HistoryPoint{{Rsi: 3}}}); gap {
+		t.Error("gap reported twice before a resync")
+	}
+	var out IndicatorUpdate
+	w.fill(&out)
+	if !slices.Equal(out.RSIHistory, []float64{1}) {
+		t.Errorf("window = %v, want it frozen at [1] until the next snapshot", out.RSIHistory)
+	}
+
+	w.apply(&pb.IndicatorUpdate{Sequence: 5, Snapshot: true, RsiHistory: []float64{1, 2, 3}})
+	if gap := w.apply(&pb.IndicatorUpdate{Sequence: 6, Appended: []*pb.HistoryPoint{{Rsi: 4}}}); gap {
+		t.Error("gap reported after resync")
+	}
+	w.fill(&out)
+	if !slices.Equal(out.RSIHistory, []float64{1, 2, 3, 4}) {
+		t.Errorf("window = %v, want [1 2 3 4]", out.RSIHistory)
+	}
+}
+
+func TestIndicatorWindowLegacyServer(t *testing.T) {
+	var w indicatorWindow
+	w.apply(&pb.IndicatorUpdate{RsiHistory: []float64{5, 6}})
+	var out IndicatorUpdate
+	w.fill(&out)
+	if !slices.Equal(out.RSIHistory, []float64{5, 6}) {
+		t.Errorf("window = %v, want the full history of an unsequenced update", out.RSIHistory)
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// alertRulesFromProto validates and converts requested alert rules.
+func alertRulesFromProto(requested []*pb.AlertRule) ([]alerts.Rule, error) {
+	out := make([]alerts.Rule, 0, len(requested))
+	for _, r := range requested {
+		rule := alerts.Rule{
+			ID:       strings.TrimSpace(r.GetId()),
+			Symbol:   strings.TrimSpace(r.GetSymbol()),
+			Cooldown: time.Duration(r.GetCooldownSeconds()) * time.Second,
+			Message:  r.GetMessage(),
+		}
+		switch c := r.Condition.(type) {
+		case *pb.AlertRule_Expression:
+			cond, err := rules.Parse(c.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("alert %q: %w", rule.ID, err)
+			}
+			rule.Condition = cond
+		case *pb.AlertRule_Move:
+			rule.Move = &alerts.Move{
+				Percent: c.Move.GetPercent(),
+				Window:  time.Duration(c.Move.GetMinutes()) * time.Minute,
+			}
+		}
+		out = append(out, rule)
+	}
+	return out, nil
+}
+
+func alertMessage(a alerts.Alert) *pb.MarketUpdate {
+	return &pb.MarketUpdate{
+		Update: &pb.MarketUpdate_Alert{
+			Alert: &pb.Alert{
+				RuleId:    a.RuleID,
+				Symbol:    a.Symbol,
+				Message:   a.Message,
+				Condition: a.Condition,
+				Price:     a.Price,
+				Timestamp: a.Time.UnixMilli(),
+			},
+		},
+	}
+}
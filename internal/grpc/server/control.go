@@ -0,0 +1,339 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// controlSession tracks the per-symbol pipelines of one Control stream.
+type controlSession struct {
+	h      *Handler
+	ctx    context.Context
+	send   func(*pb.MarketUpdate) error
+	alerts *alerts.Book
+
+	mu      sync.Mutex
+	streams map[string]*activeStream
+	wg      sync.WaitGroup
+}
+
+// activeStream is a running symbolStream that can be cancelled independently.
+type activeStream struct {
+	stream *symbolStream
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Control implements the bidirectional control stream. Clients subscribe,
+// unsubscribe and reconfigure symbols mid-stream; every command is acknowledged.
+func (h *Handler) Control(stream pb.MarketDataService_ControlServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	book, _ := alerts.NewBook()
+	s := &controlSession{
+		h:       h,
+		ctx:     ctx,
+		send:    lockedSend(stream),
+		alerts:  book,
+		streams: make(map[string]*activeStream),
+	}
+	defer s.wg.Wait()
+	defer s.closeAll()
+
+	for {
+		cmd, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch c := cmd.Command.(type) {
+		case *pb.ControlCommand_Subscribe:
+			s.subscribe(cmd.GetId(), c.Subscribe)
+		case *pb.ControlCommand_Unsubscribe:
+			s.ack(cmd.GetId(), s.unsubscribe(c.Unsubscribe.GetSymbols()))
+		case *pb.ControlCommand_Reconfigure:
+			// Reconfiguring re-fetches history, so run it off the receive loop
+			s.goAck(func() { s.reconfigureAndAck(cmd.GetId(), c.Reconfigure) })
+		case *pb.ControlCommand_AddAlerts:
+			s.goAck(func() { s.addAlertsAndAck(cmd.GetId(), c.AddAlerts.GetAlerts()) })
+		case *pb.ControlCommand_Resync:
+			s.ack(cmd.GetId(), s.resync(c.Resync.GetSymbols()))
+		case *pb.ControlCommand_RemoveAlerts:
+			if err := s.alerts.Remove(c.RemoveAlerts.GetIds()...); err != nil {
+				s.ack(cmd.GetId(), fmt.Errorf("remove alerts: %w", err))
+				continue
+			}
+			s.ack(cmd.GetId(), nil)
+		default:
+			s.ack(cmd.GetId(), fmt.Errorf("unknown command: %T", c))
+		}
+	}
+}
+
+// subscribe starts a pipeline per new symbol and acknowledges once all of them are live.
+// Symbols that are already streaming keep their configuration and fail the ack;
+// clients change them with a reconfigure command instead.
+func (s *controlSession) subscribe(id string, cmd *pb.SubscribeCommand) {
+	symbols := normalizeSymbols(cmd.GetSymbols())
+	if len(symbols) == 0 {
+		s.ack(id, fmt.Errorf("subscribe: no symbols given"))
+		return
+	}
+	cfg, err := defaultStreamConfig().merge(cmd.GetInterval(), cmd.GetIndicators())
+	if err != nil {
+		s.ack(id, fmt.Errorf("subscribe: %w", err))
+		return
+	}
+	rate, err := updateRateFromProto(cmd.GetRate())
+	if err != nil {
+		s.ack(id, fmt.Errorf("subscribe: %w", err))
+		return
+	}
+	depthLevels, err := resolveDepthLevels(cmd.GetDepthLevels())
+	if err != nil {
+		s.ack(id, fmt.Errorf("subscribe: %w", err))
+		return
+	}
+	hub, err := s.h.exchangeHub(cmd.GetExchange(), depthLevels)
+	if err != nil {
+		s.ack(id, fmt.Errorf("subscribe: %w", err))
+		return
+	}
+
+	results := make(chan error, len(symbols))
+	s.mu.Lock()
+	for _, symbol := range symbols {
+		if _, ok := s.streams[symbol]; ok {
+			results <- fmt.Errorf("already subscribed to %s", symbol)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(s.ctx)
+		active := &activeStream{stream: s.h.newSymbolStream(symbol, cfg, s.alerts, s.send), ctx: ctx, cancel: cancel}
+		active.stream.throttle.rate = rate
+		active.stream.depthLevels = depthLevels
+		s.h.onExchange(active.stream, hub)
+		s.streams[symbol] = active
+
+		s.wg.Add(1)
+		go func(symbol string) {
+			defer s.wg.Done()
+			var once sync.Once
+			err := active.stream.run(ctx, func(err error) {
+				once.Do(func() { results <- err })
+			})
+			// Report failures that happened before the stream became ready
+			once.Do(func() { results <- err })
+			s.remove(symbol, active)
+		}(symbol)
+	}
+	s.mu.Unlock()
+
+	s.goAck(func() {
+		var errs []string
+		for range symbols {
+			if err := <-results; err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			s.ack(id, fmt.Errorf("subscribe: %s", strings.Join(errs, "; ")))
+			return
+		}
+		s.ack(id, nil)
+	})
+}
+
+// goAck runs f, which acknowledges a command, in the background. Control
+// waits for it before returning, so acks are never sent on a finished stream.
+func (s *controlSession) goAck(f func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		f()
+	}()
+}
+
+// unsubscribe stops the pipelines for the given symbols.
+func (s *controlSession) unsubscribe(symbols []string) error {
+	symbols = normalizeSymbols(symbols)
+	if len(symbols) == 0 {
+		return fmt.Errorf("unsubscribe: no symbols given")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var missing []string
+	for _, symbol := range symbols {
+		active, ok := s.streams[symbol]
+		if !ok {
+			missing = append(missing, symbol)
+			continue
+		}
+		active.cancel()
+		delete(s.streams, symbol)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unsubscribe: not subscribed to %s", strings.Join(missing, ","))
+	}
+	return nil
+}
+
+// resync asks the pipelines of the given symbols, or of all symbols when none
+// are given, for an indicator snapshot.
+func (s *controlSession) resync(symbols []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbols = normalizeSymbols(symbols)
+	if len(symbols) == 0 {
+		for _, active := range s.streams {
+			active.stream.requestSnapshot()
+		}
+		return nil
+	}
+	var missing []string
+	for _, symbol := range symbols {
+		active, ok := s.streams[symbol]
+		if !ok {
+			missing = append(missing, symbol)
+			continue
+		}
+		active.stream.requestSnapshot()
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("resync: not subscribed to %s", strings.Join(missing, ","))
+	}
+	return nil
+}
+
+// reconfigureAndAck swaps the timeframe and aggregator of one or all active symbols.
+// Fields left unset in the command keep their current value.
+func (s *controlSession) reconfigureAndAck(id string, cmd *pb.ReconfigureCommand) {
+	s.mu.Lock()
+	var targets []*activeStream
+	if symbol := strings.ToLower(strings.TrimSpace(cmd.GetSymbol())); symbol != "" {
+		active, ok := s.streams[symbol]
+		if !ok {
+			s.mu.Unlock()
+			s.ack(id, fmt.Errorf("reconfigure: not subscribed to %s", symbol))
+			return
+		}
+		targets = append(targets, active)
+	} else {
+		for _, active := range s.streams {
+			targets = append(targets, active)
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.reconfigure(targets, cmd.GetInterval(), cmd.GetIndicators()); err != nil {
+		s.ack(id, fmt.Errorf("reconfigure: %w", err))
+		return
+	}
+	s.ack(id, nil)
+}
+
+// reconfigure applies a configuration change to each target stream in turn.
+func (s *controlSession) reconfigure(targets []*activeStream, interval string, indicatorCfg *pb.IndicatorConfig) error {
+	for _, active := range targets {
+		req := reconfigureRequest{interval: interval, indicators: indicatorCfg, done: make(chan error, 1)}
+		select {
+		case active.stream.reconfigure <- req:
+		case <-active.ctx.Done():
+			return fmt.Errorf("%s: stream closed", active.stream.symbol)
+		}
+		if err := <-req.done; err != nil {
+			return fmt.Errorf("%s: %w", active.stream.symbol, err)
+		}
+	}
+	return nil
+}
+
+// addAlertsAndAck registers alert rules for the session. Streams watched by a
+// rule that uses registry indicators are re-warmed so those indicators are computed.
+func (s *controlSession) addAlertsAndAck(id string, requested []*pb.AlertRule) {
+	rs, err := alertRulesFromProto(requested)
+	if err == nil {
+		err = s.alerts.Add(rs...)
+	}
+	if err != nil {
+		s.ack(id, fmt.Errorf("add alerts: %w", err))
+		return
+	}
+
+	s.mu.Lock()
+	var targets []*activeStream
+	for symbol, active := range s.streams {
+		for _, r := range rs {
+			if r.Condition != nil && len(r.Condition.Specs()) > 0 && (r.Symbol == "" || strings.EqualFold(r.Symbol, symbol)) {
+				targets = append(targets, active)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.reconfigure(targets, "", nil); err != nil {
+		s.ack(id, fmt.Errorf("add alerts: %w", err))
+		return
+	}
+	s.ack(id, nil)
+}
+
+// remove forgets a finished pipeline unless it has already been replaced.
+func (s *controlSession) remove(symbol string, active *activeStream) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.streams[symbol] == active {
+		delete(s.streams, symbol)
+	}
+	active.cancel()
+}
+
+func (s *controlSession) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for symbol, active := range s.streams {
+		active.cancel()
+		delete(s.streams, symbol)
+	}
+}
+
+func (s *controlSession) ack(id string, err error) {
+	ack := &pb.CommandAck{Id: id, Ok: err == nil}
+	if err != nil {
+		ack.Error = err.Error()
+		log.Printf("control command %s failed: %v", id, err)
+	}
+	if sendErr := s.send(&pb.MarketUpdate{Update: &pb.MarketUpdate_Ack{Ack: ack}}); sendErr != nil {
+		log.Printf("send ack error: %v", sendErr)
+	}
+}
+
+// normalizeSymbols lower-cases and de-duplicates symbols, dropping blanks.
+func normalizeSymbols(symbols []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range symbols {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// startTestServer serves a Handler backed by simulated feeds over an in-memory connection.
+func startTestServer(t *testing.T) pb.MarketDataServiceClient {
+	t.Helper()
+
+	hub, _ := newTestHub(0)
+	paper := NewExchangeHub("paper", newPaperSource, 0)
+	handler := NewHandler("btcusdt", hub)
+	handler.AddExchange(paper)
+	handler.fetchKlines = func(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+		klines := make([]binance.Kline, limit)
+		start := time.Now().Add(-time.Duration(limit) * time.Hour)
+		for i := range klines {
+			price := 100 + float64(i%7)
+			klines[i] = binance.Kline{
+				OpenTime:  start.Add(time.Duration(i) * time.Hour),
+				Open:      price,
+				High:      price + 1,
+				Low:       price - 1,
+				Close:     price,
+				Volume:    10,
+				CloseTime: start.Add(time.Duration(i+1)*time.Hour - time.Millisecond),
+			}
+		}
+		return klines, nil
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	pb.RegisterMarketDataServiceServer(srv, handler)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Stop()
+		hub.Close()
+		paper.Close()
+	})
+	return pb.NewMarketDataServiceClient(conn)
+}
+
+// waitForAck reads updates until the ack with the given id arrives, recording seen symbols.
+func waitForAck(t *testing.T, stream pb.MarketDataService_ControlClient, id string, seen map[string]bool) *pb.CommandAck {
+	t.Helper()
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		switch u := msg.Update.(type) {
+		case *pb.MarketUpdate_Ack:
+			if u.Ack.GetId() == id {
+				return u.Ack
+			}
+		case *pb.MarketUpdate_Indicators:
+			if seen != nil {
+				seen[u.Indicators.GetSymbol()] = true
+			}
+		}
+	}
+}
+
+func TestControlSubscribeReconfigureUnsubscribe(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.Control(ctx)
+	if err != nil {
+		t.Fatalf("Control() error = %v", err)
+	}
+
+	send := func(cmd *pb.ControlCommand) {
+		t.Helper()
+		if err := stream.Send(cmd); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	send(&pb.ControlCommand{Id: "1", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"BTCUSDT", "ethusdt"}},
+	}})
+	if ack := waitForAck(t, stream, "1", nil); !ack.GetOk() {
+		t.Fatalf("subscribe ack = %+v, want ok", ack)
+	}
+
+	send(&pb.ControlCommand{Id: "1b", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"btcusdt"}, Interval: "5m"},
+	}})
+	if ack := waitForAck(t, stream, "1b", nil); ack.GetOk() || !strings.Contains(ack.GetError(), "already subscribed to btcusdt") {
+		t.Errorf("duplicate subscribe ack = %+v, want already subscribed error", ack)
+	}
+
+	send(&pb.ControlCommand{Id: "2", Command: &pb.ControlCommand_Reconfigure{
+		Reconfigure: &pb.ReconfigureCommand{Symbol: "ethusdt", Indicators: &pb.IndicatorConfig{RsiPeriod: 7}},
+	}})
+	if ack := waitForAck(t, stream, "2", nil); !ack.GetOk() {
+		t.Fatalf("reconfigure ack = %+v, want ok", ack)
+	}
+
+	send(&pb.ControlCommand{Id: "3", Command: &pb.ControlCommand_Unsubscribe{
+		Unsubscribe: &pb.UnsubscribeCommand{Symbols: []string{"ethusdt"}},
+	}})
+	if ack := waitForAck(t, stream, "3", nil); !ack.GetOk() {
+		t.Fatalf("unsubscribe ack = %+v, want ok", ack)
+	}
+
+	send(&pb.ControlCommand{Id: "4", Command: &pb.ControlCommand_Reconfigure{
+		Reconfigure: &pb.ReconfigureCommand{Symbol: "ethusdt"},
+	}})
+	if ack := waitForAck(t, stream, "4", nil); ack.GetOk() {
+		t.Error("reconfigure of unsubscribed symbol should be rejected")
+	}
+
+	// BTC keeps streaming after ETH was dropped
+	seen := make(map[string]bool)
+	send(&pb.ControlCommand{Id: "5", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"solusdt"}},
+	}})
+	waitForAck(t, stream, "5", seen)
+	for !seen["BTCUSDT"] {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if u, ok := msg.Update.(*pb.MarketUpdate_Indicators); ok {
+			seen[u.Indicators.GetSymbol()] = true
+		}
+	}
+}
+
+func TestControlAlerts(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.Control(ctx)
+	if err != nil {
+		t.Fatalf("Control() error = %v", err)
+	}
+	send := func(cmd *pb.ControlCommand) {
+		t.Helper()
+		if err := stream.Send(cmd); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	send(&pb.ControlCommand{Id: "1", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"btcusdt"}},
+	}})
+	if ack := waitForAck(t, stream, "1", nil); !ack.GetOk() {
+		t.Fatalf("subscribe ack = %+v, want ok", ack)
+	}
+
+	send(&pb.ControlCommand{Id: "2", Command: &pb.ControlCommand_AddAlerts{
+		AddAlerts: &pb.AddAlertsCommand{Alerts: []*pb.AlertRule{{Id: "bad", Condition: &pb.AlertRule_Expression{Expression: "rsi <"}}}},
+	}})
+	if ack := waitForAck(t, stream, "2", nil); ack.GetOk() {
+		t.Error("invalid alert expression should be rejected")
+	}
+
+	send(&pb.ControlCommand{Id: "3", Command: &pb.ControlCommand_AddAlerts{
+		AddAlerts: &pb.AddAlertsCommand{Alerts: []*pb.AlertRule{{
+			Id:        "live",
+			Symbol:    "BTCUSDT",
+			Condition: &pb.AlertRule_Expression{Expression: "close > 0 and ema(20) > 0"},
+			Message:   "price is positive",
+		}}},
+	}})
+	if ack := waitForAck(t, stream, "3", nil); !ack.GetOk() {
+		t.Fatalf("add alerts ack = %+v, want ok", ack)
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if u, ok := msg.Update.(*pb.MarketUpdate_Alert); ok {
+			a := u.Alert
+			if a.GetRuleId() != "live" || a.GetSymbol() != "BTCUSDT" || a.GetMessage() != "price is positive" || a.GetPrice() <= 0 {
+				t.Errorf("alert = %+v", a)
+			}
+			break
+		}
+	}
+
+	send(&pb.ControlCommand{Id: "4", Command: &pb.ControlCommand_RemoveAlerts{
+		RemoveAlerts: &pb.RemoveAlertsCommand{Ids: []string{"live"}},
+	}})
+	if ack := waitForAck(t, stream, "4", nil); !ack.GetOk() {
+		t.Errorf("remove alerts ack = %+v, want ok", ack)
+	}
+	send(&pb.ControlCommand{Id: "5", Command: &pb.ControlCommand_RemoveAlerts{
+		RemoveAlerts: &pb.RemoveAlertsCommand{Ids: []string{"live"}},
+	}})
+	if ack := waitForAck(t, stream, "5", nil); ack.GetOk() {
+		t.Error("removing an unknown alert should be rejected")
+	}
+}
+
+func TestControlSnapshotDeltaAndResync(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.Control(ctx)
+	if err != nil {
+		t.Fatalf("Control() error = %v", err)
+	}
+	nextIndicators := func() *pb.IndicatorUpdate {
+		t.Helper()
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv() error = %v", err)
+			}
+			if u, ok := msg.Update.(*pb.MarketUpdate_Indicators); ok {
+				return u.Indicators
+			}
+		}
+	}
+
+	stream.Send(&pb.ControlCommand{Id: "1", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"btcusdt"}},
+	}})
+
+	first := nextIndicators()
+	if !first.GetSnapshot() || len(first.GetRsiHistory()) == 0 || first.GetHistorySize() == 0 {
+		t.Fatalf("first update = snapshot %v with %d history points, want a full snapshot", first.GetSnapshot(), len(first.GetRsiHistory()))
+	}
+	delta := nextIndicators()
+	if delta.GetSnapshot() || len(delta.GetRsiHistory()) != 0 || delta.GetSequence() != first.GetSequence()+1 {
+		t.Errorf("second update = snapshot %v, %d history points, sequence %d; want a delta following %d",
+			delta.GetSnapshot(), len(delta.GetRsiHistory()), delta.GetSequence(), first.GetSequence())
+	}
+
+	stream.Send(&pb.ControlCommand{Id: "2", Command: &pb.ControlCommand_Resync{Resync: &pb.ResyncCommand{}}})
+	for {
+		if u := nextIndicators(); u.GetSnapshot() {
+			if len(u.GetRsiHistory()) == 0 {
+				t.Error("resync snapshot has no history")
+			}
+			break
+		}
+	}
+
+	stream.Send(&pb.ControlCommand{Id: "3", Command: &pb.ControlCommand_Resync{Resync: &pb.ResyncCommand{Symbols: []string{"ethusdt"}}}})
+	if ack := waitForAck(t, stream, "3", nil); ack.GetOk() {
+		t.Error("resync of an unsubscribed symbol should be rejected")
+	}
+}
+
+func TestControlOrderBook(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.Control(ctx)
+	if err != nil {
+		t.Fatalf("Control() error = %v", err)
+	}
+	stream.Send(&pb.ControlCommand{Id: "1", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"btcusdt"}, DepthLevels: -1},
+	}})
+	if ack := waitForAck(t, stream, "1", nil); ack.GetOk() {
+		t.Error("negative depth levels should be rejected")
+	}
+
+	stream.Send(&pb.ControlCommand{Id: "2", Command: &pb.ControlCommand_Subscribe{
+		Subscribe: &pb.SubscribeCommand{Symbols: []string{"btcusdt"}, DepthLevels: 5},
+	}})
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		u, ok := msg.Update.(*pb.MarketUpdate_OrderBook)
+		if !ok {
+			continue
+		}
+		book := u.OrderBook
+		if book.GetSymbol() != "BTCUSDT" || len(book.GetBids()) != 5 || len(book.GetAsks()) != 5 {
+			t.Fatalf("order book = %s with %d bids and %d asks, want BTCUSDT with 5 each", book.GetSymbol(), len(book.GetBids()), len(book.GetAsks()))
+		}
+		if book.GetSpread() <= 0 || book.GetBestBid() != book.GetBids()[0].GetPrice() || book.GetImbalance() < -1 || book.GetImbalance() > 1 {
+			t.Errorf("order book metrics = spread %v, best bid %v, imbalance %v", book.GetSpread(), book.GetBestBid(), book.GetImbalance())
+		}
+		break
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// AddExchange makes hub's exchange selectable by name in stream requests,
+// replacing any hub registered under the same name.
+func (h *Handler) AddExchange(hub *Hub) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.exchanges[hub.Exchange()] = hub
+}
+
+// exchangeHub returns the hub of the named exchange, the default one for an
+// empty name, checking that it can stream depthLevels order book levels.
+func (h *Handler) exchangeHub(name string, depthLevels int) (*Hub, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = h.hub.Exchange()
+	}
+
+	h.mu.RLock()
+	hub, ok := h.exchanges[name]
+	names := make([]string, 0, len(h.exchanges))
+	for n := range h.exchanges {
+		names = append(names, n)
+	}
+	h.mu.RUnlock()
+
+	if !ok {
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown exchange %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	if depthLevels > 0 && !hub.SupportsDepth() {
+		return nil, fmt.Errorf("%s does not stream order books", name)
+	}
+	return hub, nil
+}
+
+// onExchange makes s stream from hub. Streams on the default exchange keep the
+// handler's history fetcher; others warm up from their exchange's bars.
+func (h *Handler) onExchange(s *symbolStream, hub *Hub) {
+	if hub != h.hub {
+		s.hub, s.fetchKlines = hub, hub.FetchBars
+	}
+}
+
+// GetSymbols lists the symbols an exchange trades.
+func (h *Handler) GetSymbols(ctx context.Context, req *pb.SymbolsRequest) (*pb.SymbolsResponse, error) {
+	hub, err := h.exchangeHub(req.GetExchange(), 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	symbols, err := hub.Symbols(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &pb.SymbolsResponse{Exchange: hub.Exchange(), Symbols: symbols}, nil
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// paperSource is an exchange without order books that trades every symbol at
+// 42 and reports a flat history at 40.
+type paperSource struct {
+	mu          sync.RWMutex
+	subscribers exchange.Subscribers[exchange.Tick]
+	done        chan struct{}
+	once        sync.Once
+}
+
+func newPaperSource(string) exchange.MarketDataSource {
+	return &paperSource{done: make(chan struct{})}
+}
+
+func (p *paperSource) Connect(ctx context.Context) error {
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			case now := <-ticker.C:
+				p.mu.RLock()
+				p.subscribers.Broadcast(exchange.Tick{Price: 42, Volume: 1, Timestamp: now, IsTrade: true})
+				p.mu.RUnlock()
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *paperSource) Subscribe(opts ...exchange.SubscribeOption) <-chan exchange.Tick {
+	sub := exchange.NewSubscriber[exchange.Tick]("paper", exchange.DropNewest, opts)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subscribers = append(p.subscribers, sub)
+	return sub.C()
+}
+
+func (p *paperSource) Unsubscribe(ch <-chan exchange.Tick) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i := p.subscribers.Find(ch); i >= 0 {
+		p.subscribers.Remove(i)
+	}
+}
+
+func (p *paperSource) FetchBars(ctx context.Context, interval string, start, end time.Time, limit int) ([]exchange.Bar, error) {
+	bars := make([]exchange.Bar, limit)
+	first := time.Now().Truncate(time.Hour).Add(-time.Duration(limit) * time.Hour)
+	for i := range bars {
+		open := first.Add(time.Duration(i) * time.Hour)
+		bars[i] = exchange.Bar{OpenTime: open, Open: 40, High: 40, Low: 40, Close: 40, Volume: 1, CloseTime: open.Add(time.Hour - time.Millisecond)}
+	}
+	return bars, nil
+}
+
+func (p *paperSource) Symbols(ctx context.Context) ([]string, error) {
+	return []string{"btcusd", "ethusd"}, nil
+}
+
+func (p *paperSource) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+		p.mu.Lock()
+		p.subscribers.RemoveAll()
+		p.mu.Unlock()
+	})
+	return nil
+}
+
+func TestStreamPricesFromExchange(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamPrices(ctx, &pb.StreamRequest{Symbol: "btcusd", Exchange: "Paper"})
+	if err != nil {
+		t.Fatalf("StreamPrices() error = %v", err)
+	}
+	var prices []float64
+	for len(prices) < 2 {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		if p := msg.GetPrice(); p != nil {
+			prices = append(prices, p.GetPrice())
+		}
+	}
+	// The warm-up close comes from the exchange's history, then its live trades
+	if prices[0] != 40 || prices[1] != 42 {
+		t.Errorf("prices = %v, want the paper exchange's 40 then 42", prices)
+	}
+
+	for name, req := range map[string]*pb.StreamRequest{
+		"unknown exchange": {Symbol: "btcusd", Exchange: "nope"},
+		"order book":       {Symbol: "btcusd", Exchange: "paper", DepthLevels: 5},
+	} {
+		stream, err := client.StreamPrices(ctx, req)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: error = %v, want InvalidArgument", name, err)
+		}
+		if name == "unknown exchange" && !strings.Contains(err.Error(), "binance, paper") {
+			t.Errorf("%s: error %q should list the exchanges", name, err)
+		}
+	}
+
+	resp, err := client.GetSymbols(ctx, &pb.SymbolsRequest{Exchange: "paper"})
+	if err != nil || resp.GetExchange() != "paper" || strings.Join(resp.GetSymbols(), ",") != "btcusd,ethusd" {
+		t.Errorf("GetSymbols() = %v, %v", resp, err)
+	}
+}
@@ -1,13 +1,21 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/candles"
 	"github.com/rp4ri/quantacode/internal/domain/indicators"
 	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
 	pb "github.com/rp4ri/quantacode/proto"
 )
 
@@ -15,161 +23,364 @@ import (
 type Handler struct {
 	pb.UnimplementedMarketDataServiceServer
 	defaultSymbol string
-	mu            sync.RWMutex
+	hub           *Hub
+	fetchKlines   func(ctx context.Context, symbol, interval string, limit int) ([]exchange.Bar, error)
+	// fetchKlineRange serves history requests the cache and store cannot answer
+	fetchKlineRange func(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]exchange.Bar, error)
+	cache           *candleCache
+	mu              sync.RWMutex
+	exchanges       map[string]*Hub // selectable hubs by exchange name, hub included; guarded by mu
 }
 
-// NewHandler creates a new gRPC handler.
-func NewHandler(defaultSymbol string) *Handler {
-	return &Handler{
-		defaultSymbol: strings.ToLower(defaultSymbol),
+// NewHandler creates a new gRPC handler that sources prices from the shared
+// hub, the default exchange; see AddExchange for others.
+func NewHandler(defaultSymbol string, hub *Hub) *Handler {
+	h := &Handler{
+		defaultSymbol:   strings.ToLower(defaultSymbol),
+		hub:             hub,
+		fetchKlines:     binance.FetchKlines,
+		fetchKlineRange: binance.FetchKlinesRange,
+		cache:           newCandleCache(),
+		exchanges:       make(map[string]*Hub),
+	}
+	if hub != nil {
+		h.exchanges[hub.Exchange()] = hub
 	}
+	return h
 }
 
-// StreamPrices implements bidirectional streaming of prices and indicators.
-func (h *Handler) StreamPrices(req *pb.StreamRequest, stream pb.MarketDataService_StreamPricesServer) error {
-	ctx := stream.Context()
+// defaultInterval is the candle interval used when a request does not specify one.
+const defaultInterval = "1h"
 
-	// Use requested symbol or default
-	symbol := strings.ToLower(req.GetSymbol())
-	if symbol == "" {
-		symbol = h.defaultSymbol
-	}
+// streamConfig holds the timeframe and indicator configuration for a stream.
+type streamConfig struct {
+	interval   string
+	rsi        int
+	sma        int
+	ema        int
+	macdFast   int
+	macdSlow   int
+	macdSignal int
+	bbPeriod   int
+	bbStdDev   float64
+	volumeSMA  int
+	vwapReset  bool
+	specs      string // canonical comma-separated registry specs, see indicators.FormatSpecs
+	rules      string // canonical newline-separated signal rules, see resolveRules
+}
 
-	// Default indicator periods
-	rsiPeriod := int(req.GetIndicators().GetRsiPeriod())
-	smaPeriod := int(req.GetIndicators().GetSmaPeriod())
-	emaPeriod := int(req.GetIndicators().GetEmaPeriod())
+// defaultStreamConfig returns the configuration used when a request leaves fields unset.
+func defaultStreamConfig() streamConfig {
+	return streamConfig{
+		interval:   defaultInterval,
+		rsi:        14,
+		sma:        14,
+		ema:        14,
+		macdFast:   indicators.DefaultMACDFast,
+		macdSlow:   indicators.DefaultMACDSlow,
+		macdSignal: indicators.DefaultMACDSignal,
+		bbPeriod:   indicators.DefaultBollingerPeriod,
+		bbStdDev:   indicators.DefaultBollingerStdDev,
+		volumeSMA:  indicators.DefaultVolumeSMAPeriod,
+		vwapReset:  true,
+	}
+}
 
-	if rsiPeriod <= 0 {
-		rsiPeriod = 14
+// merge returns a copy of c with every non-zero requested field applied.
+func (c streamConfig) merge(interval string, indicatorCfg *pb.IndicatorConfig) (streamConfig, error) {
+	if interval = strings.TrimSpace(interval); interval != "" {
+		if !binance.ValidInterval(interval) {
+			return c, fmt.Errorf("unsupported interval %q", interval)
+		}
+		c.interval = interval
+	}
+	if p := int(indicatorCfg.GetRsiPeriod()); p > 0 {
+		c.rsi = p
+	}
+	if p := int(indicatorCfg.GetSmaPeriod()); p > 0 {
+		c.sma = p
+	}
+	if p := int(indicatorCfg.GetEmaPeriod()); p > 0 {
+		c.ema = p
+	}
+	if p := int(indicatorCfg.GetMacdFast()); p > 0 {
+		c.macdFast = p
+	}
+	if p := int(indicatorCfg.GetMacdSlow()); p > 0 {
+		c.macdSlow = p
+	}
+	if p := int(indicatorCfg.GetMacdSignal()); p > 0 {
+		c.macdSignal = p
+	}
+	if p := int(indicatorCfg.GetBbPeriod()); p > 0 {
+		c.bbPeriod = p
+	}
+	if k := indicatorCfg.GetBbStddev(); k > 0 {
+		c.bbStdDev = k
+	}
+	if p := int(indicatorCfg.GetVolumeSmaPeriod()); p > 0 {
+		c.volumeSMA = p
+	}
+	if indicatorCfg != nil && indicatorCfg.VwapDailyReset != nil {
+		c.vwapReset = indicatorCfg.GetVwapDailyReset()
+	}
+	if requested := indicatorCfg.GetSpecs(); len(requested) > 0 {
+		specs, err := resolveSpecs(requested)
+		if err != nil {
+			return c, err
+		}
+		c.specs = indicators.FormatSpecs(specs)
 	}
-	if smaPeriod <= 0 {
-		smaPeriod = 14
+	if requested := indicatorCfg.GetRules(); len(requested) > 0 {
+		resolved, err := resolveRules(requested)
+		if err != nil {
+			return c, err
+		}
+		c.rules = resolved
+	}
+	for _, p := range []int{c.rsi, c.sma, c.ema, c.macdFast, c.macdSlow, c.macdSignal, c.bbPeriod, c.volumeSMA} {
+		if p > indicators.MaxPeriod {
+			return c, fmt.Errorf("indicator period %d exceeds the maximum of %d", p, indicators.MaxPeriod)
+		}
 	}
-	if emaPeriod <= 0 {
-		emaPeriod = 14
+	if c.bbStdDev < indicators.MinBollingerStdDev || c.bbStdDev > indicators.MaxBollingerStdDev {
+		return c, fmt.Errorf("Bollinger standard deviation %v must be between %v and %v", c.bbStdDev, indicators.MinBollingerStdDev, indicators.MaxBollingerStdDev)
 	}
+	if c.macdFast >= c.macdSlow {
+		return c, fmt.Errorf("MACD fast period %d must be smaller than slow period %d", c.macdFast, c.macdSlow)
+	}
+	return c, nil
+}
 
-	agg, err := indicators.NewAggregator(rsiPeriod, smaPeriod, emaPeriod)
+// resolveSpecs validates the requested registry indicators and returns their canonical specs.
+func resolveSpecs(requested []*pb.IndicatorSpec) ([]indicators.Spec, error) {
+	specs := make([]indicators.Spec, len(requested))
+	for i, s := range requested {
+		specs[i] = indicators.Spec{Name: strings.ToLower(strings.TrimSpace(s.GetName())), Params: s.GetParams()}
+	}
+	set, err := indicators.DefaultRegistry().NewSet(specs)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return set.Specs(), nil
+}
+
+// requestedSymbols returns the de-duplicated, lower-cased symbols of a request,
+// falling back to the default symbol when none are given.
+func requestedSymbols(req *pb.StreamRequest, defaultSymbol string) []string {
+	symbols := normalizeSymbols(append([]string{req.GetSymbol()}, req.GetSymbols()...))
+	if len(symbols) == 0 {
+		symbols = []string{defaultSymbol}
+	}
+	return symbols
+}
 
-	// CRITICAL: Fetch historical klines FIRST to pre-populate indicators
-	// This ensures RSI/SMA/EMA are available from second 0
-	klineCount := rsiPeriod + 10 // Fetch extra candles for accurate calculation
-	if klineCount < 50 {
-		klineCount = 50
+// StreamPrices streams prices and indicators for one or more symbols.
+func (h *Handler) StreamPrices(req *pb.StreamRequest, stream pb.MarketDataService_StreamPricesServer) error {
+	symbols := requestedSymbols(req, h.defaultSymbol)
+	cfg, err := defaultStreamConfig().merge(req.GetInterval(), req.GetIndicators())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
-	klines, err := binance.FetchKlines(ctx, symbol, "1h", klineCount)
+	alertRules, err := alertRulesFromProto(req.GetAlerts())
 	if err != nil {
-		log.Printf("warning: failed to fetch historical klines for %s: %v", symbol, err)
-		// Continue anyway - indicators will warm up from real-time data
-	} else {
-		// Pre-populate aggregator with historical close prices
-		for _, k := range klines {
-			agg.Update(k.Close)
-		}
-		log.Printf("pre-populated indicators with %d historical candles for %s", len(klines), symbol)
-	}
-
-	// Create Binance client for requested symbol
-	binanceClient := binance.NewClient(symbol)
-	if err := binanceClient.Connect(ctx); err != nil {
-		log.Printf("failed to connect to binance for %s: %v", symbol, err)
-		return err
-	}
-	defer binanceClient.Close()
-
-	// Send initial indicator values immediately (from historical data)
-	if len(klines) > 0 {
-		vals := agg.Values()
-		history := agg.History()
-		lastKline := klines[len(klines)-1]
-		
-		// Send initial price
-		priceMsg := &pb.MarketUpdate{
-			Update: &pb.MarketUpdate_Price{
-				Price: &pb.PriceUpdate{
-					Symbol:    strings.ToUpper(symbol),
-					Price:     lastKline.Close,
-					Volume:    lastKline.Volume,
-					Timestamp: lastKline.CloseTime.UnixMilli(),
-				},
-			},
-		}
-		if err := stream.Send(priceMsg); err != nil {
-			return err
-		}
-		
-		// Send initial indicators
-		indicatorMsg := &pb.MarketUpdate{
-			Update: &pb.MarketUpdate_Indicators{
-				Indicators: &pb.IndicatorUpdate{
-					Rsi:        vals.RSI,
-					Sma:        vals.SMA,
-					Ema:        vals.EMA,
-					Timestamp:  time.Now().UnixMilli(),
-					RsiHistory: history.RSI,
-					SmaHistory: history.SMA,
-					EmaHistory: history.EMA,
-				},
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	book, err := alerts.NewBook(alertRules...)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	rate, err := updateRateFromProto(req.GetRate())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	depthLevels, err := resolveDepthLevels(req.GetDepthLevels())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	hub, err := h.exchangeHub(req.GetExchange(), depthLevels)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	send := lockedSend(stream)
+	newStream := func(symbol string) *symbolStream {
+		s := h.newSymbolStream(symbol, cfg, book, send)
+		s.throttle.rate = rate
+		s.depthLevels = depthLevels
+		h.onExchange(s, hub)
+		return s
+	}
+
+	if len(symbols) == 1 {
+		return newStream(symbols[0]).run(stream.Context(), nil)
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	log.Printf("streaming %d symbols on one stream: %s", len(symbols), strings.Join(symbols, ","))
+	errCh := make(chan error, len(symbols))
+	for _, symbol := range symbols {
+		go func(symbol string) {
+			errCh <- newStream(symbol).run(ctx, nil)
+		}(symbol)
+	}
+
+	// The first symbol to finish ends the whole stream
+	err = <-errCh
+	cancel()
+	for i := 1; i < len(symbols); i++ {
+		<-errCh
+	}
+	return err
+}
+
+// lockedSend serialises Send calls, since gRPC streams are not safe for concurrent use.
+func lockedSend(stream interface{ Send(*pb.MarketUpdate) error }) func(*pb.MarketUpdate) error {
+	var mu sync.Mutex
+	return func(msg *pb.MarketUpdate) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(msg)
+	}
+}
+
+func priceMessage(symbol string, price, volume float64, ts time.Time) *pb.MarketUpdate {
+	return &pb.MarketUpdate{
+		Update: &pb.MarketUpdate_Price{
+			Price: &pb.PriceUpdate{
+				Symbol:    strings.ToUpper(symbol),
+				Price:     price,
+				Volume:    volume,
+				Timestamp: ts.UnixMilli(),
 			},
+		},
+	}
+}
+
+// indicatorMessage builds an indicator update without history windows; see
+// symbolStream.sendIndicators for how snapshots and deltas add them.
+func indicatorMessage(symbol, interval string, agg *indicators.Aggregator, builder *candles.Builder, signals *signalRules, barClosed bool) *pb.MarketUpdate {
+	vals := agg.Values()
+	update := &pb.IndicatorUpdate{
+		Symbol:        strings.ToUpper(symbol),
+		Interval:      interval,
+		Rsi:           vals.RSI,
+		Sma:           vals.SMA,
+		Ema:           vals.EMA,
+		Macd:          vals.MACD,
+		MacdSignal:    vals.MACDSignal,
+		MacdHistogram: vals.MACDHistogram,
+		Timestamp:     time.Now().UnixMilli(),
+		BbUpper:       vals.BBUpper,
+		BbMiddle:      vals.BBMiddle,
+		BbLower:       vals.BBLower,
+		BbPercentB:    vals.BBPercentB,
+		Atr:           vals.ATR,
+		StochK:        vals.StochK,
+		StochD:        vals.StochD,
+		WilliamsR:     vals.WilliamsR,
+		Vwap:          vals.VWAP,
+		Obv:           vals.OBV,
+		VolumeSma:     vals.VolumeSMA,
+		Values:        valueMessages(agg.SpecValues()),
+		Rules:         signals.closedResults(barClosed),
+		BarClosed:     barClosed,
+	}
+
+	if bar, ok := builder.Live(); ok {
+		liveBar := indicatorBar(bar)
+		live := agg.Live(liveBar)
+		liveValues := agg.LiveSpecValues(liveBar)
+		update.Live = &pb.LiveIndicators{
+			Rsi:           live.RSI,
+			Sma:           live.SMA,
+			Ema:           live.EMA,
+			Macd:          live.MACD,
+			MacdSignal:    live.MACDSignal,
+			MacdHistogram: live.MACDHistogram,
+			BbUpper:       live.BBUpper,
+			BbMiddle:      live.BBMiddle,
+			BbLower:       live.BBLower,
+			BbPercentB:    live.BBPercentB,
+			Atr:           live.ATR,
+			StochK:        live.StochK,
+			StochD:        live.StochD,
+			WilliamsR:     live.WilliamsR,
+			Vwap:          live.VWAP,
+			Obv:           live.OBV,
+			VolumeSma:     live.VolumeSMA,
+			Values:        valueMessages(liveValues),
+			Rules:         signals.liveResults(liveBar, live, liveValues),
+			Bar:           candleMessage(bar),
 		}
-		if err := stream.Send(indicatorMsg); err != nil {
-			return err
-		}
-		log.Printf("sent initial indicators for %s: RSI=%.2f SMA=%.2f EMA=%.2f", symbol, vals.RSI, vals.SMA, vals.EMA)
-	}
-
-	log.Printf("streaming %s for client", symbol)
-	priceCh := binanceClient.Subscribe()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case update, ok := <-priceCh:
-			if !ok {
-				return nil
-			}
-
-			// Send price update
-			priceMsg := &pb.MarketUpdate{
-				Update: &pb.MarketUpdate_Price{
-					Price: &pb.PriceUpdate{
-						Symbol:    update.Symbol,
-						Price:     update.Price,
-						Volume:    update.Volume,
-						Timestamp: update.Timestamp.UnixMilli(),
-					},
-				},
-			}
-			if err := stream.Send(priceMsg); err != nil {
-				log.Printf("send price error: %v", err)
-				return err
-			}
-
-			// Calculate and send indicators
-			vals := agg.Update(update.Price)
-			history := agg.History()
-			indicatorMsg := &pb.MarketUpdate{
-				Update: &pb.MarketUpdate_Indicators{
-					Indicators: &pb.IndicatorUpdate{
-						Rsi:        vals.RSI,
-						Sma:        vals.SMA,
-						Ema:        vals.EMA,
-						Timestamp:  time.Now().UnixMilli(),
-						RsiHistory: history.RSI,
-						SmaHistory: history.SMA,
-						EmaHistory: history.EMA,
-					},
-				},
-			}
-			if err := stream.Send(indicatorMsg); err != nil {
-				log.Printf("send indicators error: %v", err)
-				return err
-			}
+	}
+
+	return &pb.MarketUpdate{
+		Update: &pb.MarketUpdate_Indicators{Indicators: update},
+	}
+}
+
+// setHistory fills the full history windows of a snapshot.
+func setHistory(update *pb.IndicatorUpdate, history indicators.IndicatorHistory) {
+	update.RsiHistory = history.RSI
+	update.SmaHistory = history.SMA
+	update.EmaHistory = history.EMA
+	update.MacdHistory = history.MACD
+	update.MacdSignalHistory = history.MACDSignal
+	update.MacdHistogramHistory = history.MACDHistogram
+	update.BbUpperHistory = history.BBUpper
+	update.BbLowerHistory = history.BBLower
+	update.BbPercentBHistory = history.BBPercentB
+	update.HistorySize = indicators.HistorySize
+}
+
+// historyPoints returns the last n points of the history windows, oldest first.
+func historyPoints(history indicators.IndicatorHistory, n int) []*pb.HistoryPoint {
+	n = min(n, len(history.RSI))
+	points := make([]*pb.HistoryPoint, 0, n)
+	for i := len(history.RSI) - n; i < len(history.RSI); i++ {
+		points = append(points, &pb.HistoryPoint{
+			Rsi:           history.RSI[i],
+			Sma:           history.SMA[i],
+			Ema:           history.EMA[i],
+			Macd:          history.MACD[i],
+			MacdSignal:    history.MACDSignal[i],
+			MacdHistogram: history.MACDHistogram[i],
+			BbUpper:       history.BBUpper[i],
+			BbLower:       history.BBLower[i],
+			BbPercentB:    history.BBPercentB[i],
+		})
+	}
+	return points
+}
+
+// valueMessages keys registry indicator values by their canonical spec.
+func valueMessages(values []indicators.Value) map[string]*pb.IndicatorValue {
+	if len(values) == 0 {
+		return nil
+	}
+	msgs := make(map[string]*pb.IndicatorValue, len(values))
+	for _, v := range values {
+		msgs[v.Spec.Key()] = &pb.IndicatorValue{
+			Name:    v.Spec.Name,
+			Params:  v.Spec.Params,
+			Value:   v.Value,
+			Outputs: v.Outputs,
 		}
 	}
+	return msgs
+}
+
+func candleMessage(c candles.Candle) *pb.Candle {
+	return &pb.Candle{
+		OpenTime:  c.OpenTime.UnixMilli(),
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+		CloseTime: c.CloseTime.UnixMilli(),
+	}
 }
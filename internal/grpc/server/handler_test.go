@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+func TestRequestedSymbols(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *pb.StreamRequest
+		want []string
+	}{
+		{"default symbol", &pb.StreamRequest{}, []string{"btcusdt"}},
+		{"single symbol", &pb.StreamRequest{Symbol: "ETHUSDT"}, []string{"ethusdt"}},
+		{
+			name: "symbol plus basket",
+			req:  &pb.StreamRequest{Symbol: "btcusdt", Symbols: []string{"ETHUSDT", "solusdt"}},
+			want: []string{"btcusdt", "ethusdt", "solusdt"},
+		},
+		{
+			name: "duplicates and blanks removed",
+			req:  &pb.StreamRequest{Symbols: []string{"ethusdt", " ", "ETHUSDT", "solusdt"}},
+			want: []string{"ethusdt", "solusdt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestedSymbols(tt.req, "btcusdt")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestedSymbols() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamConfigMerge(t *testing.T) {
+	got, err := defaultStreamConfig().merge("", nil)
+	if err != nil {
+		t.Fatalf("merge() error = %v", err)
+	}
+	if got != defaultStreamConfig() {
+		t.Errorf("merge(empty) = %+v, want defaults", got)
+	}
+
+	got, err = defaultStreamConfig().merge("5m", &pb.IndicatorConfig{RsiPeriod: 7, SmaPeriod: -1, EmaPeriod: 50})
+	if err != nil {
+		t.Fatalf("merge() error = %v", err)
+	}
+	want := streamConfig{interval: "5m", rsi: 7, sma: 14, ema: 50, macdFast: 12, macdSlow: 26, macdSignal: 9, bbPeriod: 20, bbStdDev: 2, volumeSMA: 20, vwapReset: true}
+	if got != want {
+		t.Errorf("merge() = %+v, want %+v", got, want)
+	}
+
+	// Unset fields keep the current value
+	got, _ = want.merge("", &pb.IndicatorConfig{SmaPeriod: 20})
+	if got.interval != "5m" || got.rsi != 7 || got.sma != 20 {
+		t.Errorf("merge() = %+v, want interval 5m, rsi 7, sma 20", got)
+	}
+
+	if _, err := defaultStreamConfig().merge("7m", nil); err == nil {
+		t.Error("merge() should reject unsupported intervals")
+	}
+	if _, err := defaultStreamConfig().merge("", &pb.IndicatorConfig{SmaPeriod: 1 << 30}); err == nil {
+		t.Error("merge() should reject periods above the maximum")
+	}
+	for _, k := range []float64{0.01, 11, math.Inf(1)} {
+		if _, err := defaultStreamConfig().merge("", &pb.IndicatorConfig{BbStddev: k}); err == nil {
+			t.Errorf("merge() should reject a Bollinger standard deviation of %v", k)
+		}
+	}
+	got, _ = want.merge("", &pb.IndicatorConfig{BbPeriod: 10, BbStddev: 2.5})
+	if got.bbPeriod != 10 || got.bbStdDev != 2.5 {
+		t.Errorf("merge() = %+v, want Bollinger 10/2.5", got)
+	}
+	noReset := false
+	got, _ = want.merge("", &pb.IndicatorConfig{VwapDailyReset: &noReset})
+	if got.vwapReset {
+		t.Error("merge() should apply an explicit VWAP reset setting")
+	}
+	got, err = want.merge("", &pb.IndicatorConfig{Specs: []*pb.IndicatorSpec{
+		{Name: "EMA", Params: []float64{200}},
+		{Name: "macd", Params: []float64{8}},
+	}})
+	if err != nil {
+		t.Fatalf("merge() error = %v", err)
+	}
+	if got.specs != "ema:200,macd:8:26:9" {
+		t.Errorf("merge() specs = %q, want ema:200,macd:8:26:9", got.specs)
+	}
+	if _, err := want.merge("", &pb.IndicatorConfig{Specs: []*pb.IndicatorSpec{{Name: "unknown"}}}); err == nil {
+		t.Error("merge() should reject unknown indicators")
+	}
+	got, err = want.merge("", &pb.IndicatorConfig{Rules: []*pb.SignalRule{
+		{Name: "oversold", Expression: "rsi(14) < 30"},
+		{Expression: " close crosses_above ema "},
+	}})
+	if err != nil {
+		t.Fatalf("merge() error = %v", err)
+	}
+	if want := "oversold: rsi(14) < 30\nclose crosses_above ema"; got.rules != want {
+		t.Errorf("merge() rules = %q, want %q", got.rules, want)
+	}
+	for _, bad := range []*pb.SignalRule{
+		{Expression: "rsi <"},
+		{Name: "two words", Expression: "rsi < 30"},
+		{Expression: "rsi < 30\nor rsi > 70"},
+	} {
+		if _, err := want.merge("", &pb.IndicatorConfig{Rules: []*pb.SignalRule{bad}}); err == nil {
+			t.Errorf("merge() should reject rule %+v", bad)
+		}
+	}
+	if _, err := defaultStreamConfig().merge("", &pb.IndicatorConfig{MacdFast: 30}); err == nil {
+		t.Error("merge() should reject a MACD fast period not below the slow period")
+	}
+}
+
+func TestStreamPricesIndicatorSpecs(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamPrices(ctx, &pb.StreamRequest{
+		Symbol: "btcusdt",
+		Indicators: &pb.IndicatorConfig{Specs: []*pb.IndicatorSpec{
+			{Name: "ema", Params: []float64{50}},
+			{Name: "bb"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("StreamPrices() error = %v", err)
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		u, ok := msg.Update.(*pb.MarketUpdate_Indicators)
+		if !ok {
+			continue
+		}
+		values := u.Indicators.GetValues()
+		if ema := values["ema:50"]; ema.GetValue() == 0 {
+			t.Errorf("ema:50 = %+v, want a warmed-up value", ema)
+		}
+		bb := values["bb:20:2"]
+		if bb.GetOutputs()["upper"] <= bb.GetOutputs()["lower"] {
+			t.Errorf("bb:20:2 outputs = %v, want upper above lower", bb.GetOutputs())
+		}
+		return
+	}
+}
+
+func TestStreamPricesEvaluatesRules(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamPrices(ctx, &pb.StreamRequest{
+		Symbol: "btcusdt",
+		Indicators: &pb.IndicatorConfig{Rules: []*pb.SignalRule{
+			{Name: "above", Expression: "close >= 100 and ema(50) > 90"},
+			{Name: "never", Expression: "close < 0"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("StreamPrices() error = %v", err)
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		u, ok := msg.Update.(*pb.MarketUpdate_Indicators)
+		if !ok {
+			continue
+		}
+		results := u.Indicators.GetRules()
+		if len(results) != 2 {
+			t.Fatalf("rules = %v, want 2 results", results)
+		}
+		if r := results[0]; r.GetName() != "above" || !r.GetValue() || r.GetTriggered() {
+			t.Errorf("above = %+v, want true and not triggered on the initial update", r)
+		}
+		if r := results[1]; r.GetName() != "never" || r.GetValue() {
+			t.Errorf("never = %+v, want false", r)
+		}
+		return
+	}
+}
+
+func TestIndicatorMessageCarriesOscillators(t *testing.T) {
+	agg, err := indicators.NewAggregator(14, 14, 14)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		price := 100 + float64(i%5)
+		agg.AddBar(indicators.Bar{Open: price, High: price + 2, Low: price - 2, Close: price, Volume: 1})
+	}
+	builder, _ := candles.NewBuilder(time.Minute)
+	builder.Add(103, 1, time.Now())
+
+	update := indicatorMessage("btcusdt", "1m", agg, builder, nil, false).GetIndicators()
+	vals := agg.Values()
+	if update.GetAtr() != vals.ATR || update.GetStochK() != vals.StochK || update.GetStochD() != vals.StochD || update.GetWilliamsR() != vals.WilliamsR {
+		t.Errorf("update = %v, want the aggregator's ATR, Stochastic and Williams %%R %+v", update, vals)
+	}
+	if update.GetAtr() == 0 || update.GetLive().GetAtr() == 0 || update.GetLive().GetWilliamsR() == 0 {
+		t.Errorf("oscillators missing from update %v", update)
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+const (
+	defaultHistoryLimit = 500
+	maxHistoryLimit     = 1000
+	// maxCachedCandles bounds the candles kept in memory per symbol and interval.
+	maxCachedCandles = 5000
+)
+
+// GetCandles returns closed candles from the cache, the store or Binance, in that order.
+func (h *Handler) GetCandles(ctx context.Context, req *pb.CandlesRequest) (*pb.CandlesResponse, error) {
+	r, err := h.resolveRange(req.GetSymbol(), req.GetInterval(), req.GetFrom(), req.GetTo(), req.GetLimit(), time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	cs, err := h.candles(ctx, r)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	resp := &pb.CandlesResponse{Symbol: strings.ToUpper(r.symbol), Interval: r.interval}
+	for _, c := range cs {
+		resp.Candles = append(resp.Candles, candleMessage(c))
+	}
+	return resp, nil
+}
+
+// GetIndicatorSeries computes registry indicators over the requested candles,
+// warming them up on the candles before the range.
+func (h *Handler) GetIndicatorSeries(ctx context.Context, req *pb.IndicatorSeriesRequest) (*pb.IndicatorSeriesResponse, error) {
+	r, err := h.resolveRange(req.GetSymbol(), req.GetInterval(), req.GetFrom(), req.GetTo(), req.GetLimit(), time.Now())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(req.GetSpecs()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no indicators requested")
+	}
+	specs, err := resolveSpecs(req.GetSpecs())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	set, err := indicators.DefaultRegistry().NewSet(specs)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	cs, err := h.candles(ctx, r)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	var warmup []candles.Candle
+	if n := min(set.Warmup(), maxHistoryLimit); n > 0 {
+		first := r.first()
+		if len(cs) > 0 {
+			first = cs[0].OpenTime
+		}
+		warmup, err = h.candles(ctx, historyRange{
+			symbol:   r.symbol,
+			interval: r.interval,
+			length:   r.length,
+			from:     first.Add(-time.Duration(n) * r.length),
+			to:       first,
+		})
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+	}
+
+	resp := &pb.IndicatorSeriesResponse{Symbol: strings.ToUpper(r.symbol), Interval: r.interval}
+	resp.Series = indicatorSeries(set, warmup, cs)
+	for _, c := range cs {
+		resp.Timestamps = append(resp.Timestamps, c.OpenTime.UnixMilli())
+	}
+	return resp, nil
+}
+
+// indicatorSeries feeds warmup and then cs to set, recording the values after every candle of cs.
+func indicatorSeries(set *indicators.Set, warmup, cs []candles.Candle) []*pb.IndicatorSeries {
+	specs := set.Specs()
+	series := make([]*pb.IndicatorSeries, len(specs))
+	needed := make([]int, len(specs))
+	for i, spec := range specs {
+		series[i] = &pb.IndicatorSeries{Key: spec.Key(), Name: spec.Name, Params: spec.Params}
+		if _, def, err := indicators.DefaultRegistry().Resolve(spec); err == nil && def.Warmup != nil {
+			needed[i] = def.Warmup(spec.Params)
+		}
+	}
+
+	fed := 0
+	feed := func(c candles.Candle) {
+		set.AddTrade((c.High+c.Low+c.Close)/3, c.Volume, c.OpenTime)
+		set.AddBar(indicatorBar(c))
+		fed++
+	}
+	for _, c := range warmup {
+		feed(c)
+	}
+	for _, c := range cs {
+		feed(c)
+		for i, v := range set.Values() {
+			warm := fed >= needed[i]
+			series[i].Values = append(series[i].Values, warmValue(v.Value, warm))
+			for name, out := range v.Outputs {
+				if series[i].Outputs == nil {
+					series[i].Outputs = make(map[string]*pb.SeriesValues)
+				}
+				if series[i].Outputs[name] == nil {
+					series[i].Outputs[name] = &pb.SeriesValues{}
+				}
+				series[i].Outputs[name].Values = append(series[i].Outputs[name].Values, warmValue(out, warm))
+			}
+		}
+	}
+	return series
+}
+
+func warmValue(v float64, warm bool) float64 {
+	if !warm {
+		return math.NaN()
+	}
+	return v
+}
+
+// historyRange selects the closed candles of a series opening in [from, to).
+type historyRange struct {
+	symbol   string
+	interval string
+	length   time.Duration
+	from, to time.Time
+}
+
+// first returns the open time of the first candle in the range.
+func (r historyRange) first() time.Time {
+	first := candles.OpenTime(r.from, r.length)
+	if first.Before(r.from) {
+		first = first.Add(r.length)
+	}
+	return first
+}
+
+// size returns the number of candles in the range.
+func (r historyRange) size() int {
+	first := r.first()
+	if !first.Before(r.to) {
+		return 0
+	}
+	return int((r.to.Sub(first) + r.length - 1) / r.length)
+}
+
+// resolveRange validates a history request. Times are Unix milliseconds; the
+// range never includes the bar still open at now.
+func (h *Handler) resolveRange(symbol, interval string, from, to int64, limit int32, now time.Time) (historyRange, error) {
+	r := historyRange{symbol: strings.ToLower(strings.TrimSpace(symbol)), interval: strings.TrimSpace(interval)}
+	if r.symbol == "" {
+		r.symbol = h.defaultSymbol
+	}
+	if r.interval == "" {
+		r.interval = defaultInterval
+	}
+	if !binance.ValidInterval(r.interval) {
+		return r, fmt.Errorf("unsupported interval %q", r.interval)
+	}
+	length, err := candles.ParseInterval(r.interval)
+	if err != nil {
+		return r, err
+	}
+	r.length = length
+
+	n := int(limit)
+	switch {
+	case n < 0:
+		return r, fmt.Errorf("limit must not be negative")
+	case n == 0:
+		n = defaultHistoryLimit
+	case n > maxHistoryLimit:
+		n = maxHistoryLimit
+	}
+
+	r.to = candles.OpenTime(now, length)
+	if to > 0 && time.UnixMilli(to).Before(r.to) {
+		r.to = time.UnixMilli(to)
+	}
+	if from > 0 {
+		r.from = time.UnixMilli(from)
+		if !r.from.Before(r.to) {
+			return r, fmt.Errorf("from must be before to and the current bar")
+		}
+		// Like the Binance API, a start time keeps the first limit candles
+		if end := r.first().Add(time.Duration(n) * length); end.Before(r.to) {
+			r.to = end
+		}
+		return r, nil
+	}
+
+	last := candles.OpenTime(r.to, length)
+	if last.Equal(r.to) {
+		last = last.Add(-length)
+	}
+	r.from = last.Add(-time.Duration(n-1) * length)
+	return r, nil
+}
+
+// candles returns the closed candles of r, oldest first. A range fully held by
+// the cache or the store is served without calling Binance; fetched candles are
+// added to both.
+func (h *Handler) candles(ctx context.Context, r historyRange) ([]candles.Candle, error) {
+	n := r.size()
+	if n == 0 {
+		return nil, nil
+	}
+	key := r.symbol + "|" + r.interval
+	first := r.first()
+
+	if cs := h.cache.get(key, first, r.to); len(cs) == n {
+		return cs, nil
+	}
+	st := h.hub.Store()
+	if cs, err := st.Candles(r.symbol, r.interval, first, r.to); err == nil && len(cs) == n {
+		h.cache.add(key, cs)
+		return cs, nil
+	}
+
+	klines, err := h.fetchKlineRange(ctx, r.symbol, r.interval, first, r.to.Add(-time.Millisecond), n)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s %s klines: %w", r.symbol, r.interval, err)
+	}
+	now := time.Now()
+	var cs []candles.Candle
+	for _, k := range klines {
+		if !k.OpenTime.Before(first) && k.OpenTime.Before(r.to) && k.CloseTime.Before(now) {
+			cs = append(cs, candleFromKline(k))
+		}
+	}
+	h.cache.add(key, cs)
+	st.AppendCandles(r.symbol, r.interval, cs...)
+	return cs, nil
+}
+
+// candleCache keeps closed candles in memory per symbol and interval. Closed
+// candles never change, so the oldest are only evicted to bound memory.
+type candleCache struct {
+	mu     sync.Mutex
+	series map[string][]candles.Candle // sorted by open time, without duplicates
+}
+
+func newCandleCache() *candleCache {
+	return &candleCache{series: make(map[string][]candles.Candle)}
+}
+
+// get returns the cached candles opening in [from, to).
+func (c *candleCache) get(key string, from, to time.Time) []candles.Candle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cs := c.series[key]
+	lo := sort.Search(len(cs), func(i int) bool { return !cs[i].OpenTime.Before(from) })
+	hi := sort.Search(len(cs), func(i int) bool { return !cs[i].OpenTime.Before(to) })
+	return append([]candles.Candle(nil), cs[lo:hi]...)
+}
+
+// add merges candles into the cache.
+func (c *candleCache) add(key string, add []candles.Candle) {
+	if len(add) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	byOpen := make(map[int64]candles.Candle, len(c.series[key])+len(add))
+	for _, cs := range [][]candles.Candle{c.series[key], add} {
+		for _, candle := range cs {
+			byOpen[candle.OpenTime.UnixMilli()] = candle
+		}
+	}
+	merged := make([]candles.Candle, 0, len(byOpen))
+	for _, candle := range byOpen {
+		merged = append(merged, candle)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].OpenTime.Before(merged[j].OpenTime) })
+	if len(merged) > maxCachedCandles {
+		merged = merged[len(merged)-maxCachedCandles:]
+	}
+	c.series[key] = merged
+}
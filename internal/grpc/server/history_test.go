@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// syntheticKlines serves hourly klines closing at 100, 101, 102, ... counted from the epoch hour.
+func syntheticKlines(calls *int) func(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]binance.Kline, error) {
+	return func(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]binance.Kline, error) {
+		*calls++
+		var klines []binance.Kline
+		for open := start; !open.After(end) && len(klines) < limit; open = open.Add(time.Hour) {
+			price := float64(100 + open.Unix()/3600%1000)
+			klines = append(klines, binance.Kline{
+				OpenTime: open, Open: price, High: price + 1, Low: price - 1, Close: price, Volume: 1,
+				CloseTime: open.Add(time.Hour - time.Millisecond),
+			})
+		}
+		return klines, nil
+	}
+}
+
+func TestResolveRange(t *testing.T) {
+	h := NewHandler("btcusdt", nil)
+	now := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	ms := func(t time.Time) int64 { return t.UnixMilli() }
+
+	tests := []struct {
+		name      string
+		from, to  int64
+		limit     int32
+		wantFirst time.Time
+		wantSize  int
+	}{
+		{"latest", 0, 0, 5, now.Add(-5*time.Hour - 30*time.Minute), 5},
+		{"until to", 0, ms(now.Add(-3 * time.Hour)), 2, now.Add(-4*time.Hour - 30*time.Minute), 2},
+		{"from keeps first limit", ms(now.Add(-10 * time.Hour)), 0, 3, now.Add(-9*time.Hour - 30*time.Minute), 3},
+		{"from to current bar", ms(now.Add(-4 * time.Hour)), 0, 0, now.Add(-3*time.Hour - 30*time.Minute), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := h.resolveRange("", "1h", tt.from, tt.to, tt.limit, now)
+			if err != nil {
+				t.Fatalf("resolveRange() error = %v", err)
+			}
+			if r.symbol != "btcusdt" || !r.first().Equal(tt.wantFirst) || r.size() != tt.wantSize {
+				t.Errorf("range = %s first %v size %d, want first %v size %d", r.symbol, r.first(), r.size(), tt.wantFirst, tt.wantSize)
+			}
+		})
+	}
+
+	if _, err := h.resolveRange("", "7m", 0, 0, 0, now); err == nil {
+		t.Error("unsupported interval should be rejected")
+	}
+	if _, err := h.resolveRange("", "1h", ms(now), 0, 0, now); err == nil {
+		t.Error("from inside the open bar should be rejected")
+	}
+}
+
+func TestGetCandlesUsesCache(t *testing.T) {
+	h := NewHandler("btcusdt", NewHub(0))
+	calls := 0
+	h.fetchKlineRange = syntheticKlines(&calls)
+
+	req := &pb.CandlesRequest{Symbol: "ETHUSDT", Interval: "1h", Limit: 24}
+	resp, err := h.GetCandles(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetCandles() error = %v", err)
+	}
+	if len(resp.GetCandles()) != 24 || resp.GetSymbol() != "ETHUSDT" {
+		t.Fatalf("GetCandles() = %d candles for %s, want 24 for ETHUSDT", len(resp.GetCandles()), resp.GetSymbol())
+	}
+	last := resp.GetCandles()[23]
+	if time.UnixMilli(last.GetCloseTime()).After(time.Now()) {
+		t.Error("the open bar should not be returned")
+	}
+
+	// A sub-range of what was fetched is served from memory
+	sub := &pb.CandlesRequest{Symbol: "ethusdt", Interval: "1h", From: resp.GetCandles()[5].GetOpenTime(), Limit: 10}
+	again, err := h.GetCandles(context.Background(), sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("klines fetched %d times, want 1", calls)
+	}
+	if len(again.GetCandles()) != 10 || again.GetCandles()[0].GetOpenTime() != sub.GetFrom() {
+		t.Errorf("cached range = %d candles starting %d", len(again.GetCandles()), again.GetCandles()[0].GetOpenTime())
+	}
+}
+
+func TestGetIndicatorSeries(t *testing.T) {
+	h := NewHandler("btcusdt", NewHub(0))
+	calls := 0
+	h.fetchKlineRange = syntheticKlines(&calls)
+
+	resp, err := h.GetIndicatorSeries(context.Background(), &pb.IndicatorSeriesRequest{
+		Interval: "1h",
+		Limit:    10,
+		Specs:    []*pb.IndicatorSpec{{Name: "sma", Params: []float64{3}}, {Name: "bb"}},
+	})
+	if err != nil {
+		t.Fatalf("GetIndicatorSeries() error = %v", err)
+	}
+	if len(resp.GetTimestamps()) != 10 || len(resp.GetSeries()) != 2 {
+		t.Fatalf("got %d timestamps and %d series", len(resp.GetTimestamps()), len(resp.GetSeries()))
+	}
+
+	sma := resp.GetSeries()[0]
+	if sma.GetKey() != "sma:3" || len(sma.GetValues()) != 10 {
+		t.Fatalf("series = %s with %d values", sma.GetKey(), len(sma.GetValues()))
+	}
+	// Warmed up on earlier candles: every value is the mean of the last three closes
+	for i, ts := range resp.GetTimestamps() {
+		hour := ts / 1000 / 3600 % 1000
+		if hour < 2 {
+			continue // synthetic closes wrap around here
+		}
+		if want := float64(100 + hour - 1); math.Abs(sma.GetValues()[i]-want) > 1e-9 {
+			t.Errorf("sma[%d] = %v, want %v", i, sma.GetValues()[i], want)
+		}
+	}
+
+	bb := resp.GetSeries()[1]
+	if len(bb.GetOutputs()["upper"].GetValues()) != 10 {
+		t.Errorf("bb outputs = %v, want 10 upper values", bb.GetOutputs())
+	}
+
+	if _, err := h.GetIndicatorSeries(context.Background(), &pb.IndicatorSeriesRequest{Specs: []*pb.IndicatorSpec{{Name: "nope"}}}); err == nil {
+		t.Error("unknown indicator should be rejected")
+	}
+}
@@ -0,0 +1,458 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	"github.com/rp4ri/quantacode/internal/infra/store"
+)
+
+const (
+	// DefaultLinger is how long an upstream feed stays open after its last subscriber leaves.
+	DefaultLinger = 30 * time.Second
+
+	// DefaultExchange is the exchange of NewHub, used by requests that name none.
+	DefaultExchange = "binance"
+
+	subscriberBufferSize = 100
+
+	// replayBlockTimeout is how long a replayed feed waits for room in a slow
+	// subscriber before dropping a tick.
+	replayBlockTimeout = time.Second
+)
+
+// Hub shares a single upstream connection per symbol of one exchange across all
+// gRPC streams. Subscribers are reference-counted; the upstream connection is
+// closed once the last subscriber leaves and the linger period has elapsed.
+type Hub struct {
+	mu        sync.RWMutex
+	feeds     map[string]*feed
+	linger    time.Duration
+	exchange  string
+	newSource func(symbol string) exchange.MarketDataSource
+	depth     bool         // sources stream order books
+	store     *store.Store // optional, records every upstream tick
+}
+
+// feed is one upstream connection and its downstream subscribers.
+type feed struct {
+	symbol      string
+	source      exchange.MarketDataSource
+	cancel      context.CancelFunc
+	ready       chan struct{}
+	err         error
+	subscribers map[chan exchange.Tick]*atomic.Uint64 // updates dropped per subscriber
+	depth       int                                   // order book subscribers
+	lingerTimer *time.Timer
+	replay      bool // slow subscribers hold the feed up instead of dropping ticks
+}
+
+// NewHub creates a Binance Hub that keeps idle feeds open for the given linger
+// period. A non-positive linger closes feeds as soon as their last subscriber leaves.
+func NewHub(linger time.Duration) *Hub {
+	return NewExchangeHub(DefaultExchange, func(symbol string) exchange.MarketDataSource {
+		return binance.NewClient(symbol)
+	}, linger)
+}
+
+// NewExchangeHub creates a Hub for the named exchange whose feeds connect
+// sources made by newSource. Making a source must not connect it.
+func NewExchangeHub(name string, newSource func(symbol string) exchange.MarketDataSource, linger time.Duration) *Hub {
+	_, depth := newSource("").(exchange.DepthSource)
+	return &Hub{
+		feeds:     make(map[string]*feed),
+		linger:    linger,
+		exchange:  strings.ToLower(name),
+		newSource: newSource,
+		depth:     depth,
+	}
+}
+
+// Exchange returns the name of the hub's exchange.
+func (h *Hub) Exchange() string {
+	return h.exchange
+}
+
+// SupportsDepth reports whether the hub's exchange streams order books.
+func (h *Hub) SupportsDepth() bool {
+	return h.depth
+}
+
+// FetchBars fetches historical bars of symbol from the hub's exchange, the
+// most recent limit ones.
+func (h *Hub) FetchBars(ctx context.Context, symbol, interval string, limit int) ([]exchange.Bar, error) {
+	return h.newSource(strings.ToLower(symbol)).FetchBars(ctx, interval, time.Time{}, time.Time{}, limit)
+}
+
+// Symbols lists the symbols the hub's exchange trades.
+func (h *Hub) Symbols(ctx context.Context) ([]string, error) {
+	return h.newSource("").Symbols(ctx)
+}
+
+// SetStore records every upstream tick in st and lets streams backfill from
+// it. It must be called before the first Subscribe.
+func (h *Hub) SetStore(st *store.Store) {
+	h.store = st
+}
+
+// Store returns the hub's tick and candle store, or nil.
+func (h *Hub) Store() *store.Store {
+	return h.store
+}
+
+// Subscribe returns a channel of price updates for symbol and a release function
+// that must be called when the caller is done. The upstream connection is created
+// on the first subscription and shared by all later ones.
+func (h *Hub) Subscribe(ctx context.Context, symbol string) (<-chan exchange.Tick, func(), error) {
+	symbol = strings.ToLower(symbol)
+	ch := make(chan exchange.Tick, subscriberBufferSize)
+
+	h.mu.Lock()
+	f := h.acquire(symbol)
+	f.subscribers[ch] = new(atomic.Uint64)
+	h.mu.Unlock()
+
+	release := func() { h.release(f, ch) }
+
+	select {
+	case <-f.ready:
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	}
+	if f.err != nil {
+		release()
+		return nil, nil, f.err
+	}
+
+	return ch, release, nil
+}
+
+// SubscribeDepth returns a channel of order book updates for symbol and a
+// release function that must be called when the caller is done. It shares the
+// symbol's upstream connection with the price subscribers.
+func (h *Hub) SubscribeDepth(ctx context.Context, symbol string) (<-chan exchange.DepthUpdate, func(), error) {
+	symbol = strings.ToLower(symbol)
+
+	h.mu.Lock()
+	f := h.acquire(symbol)
+	f.depth++
+	h.mu.Unlock()
+
+	var once sync.Once
+	var ch <-chan exchange.DepthUpdate
+	release := func() {
+		once.Do(func() {
+			if ch != nil {
+				f.source.(exchange.DepthSource).UnsubscribeDepth(ch)
+			}
+			h.mu.Lock()
+			f.depth--
+			stopped := h.idle(f)
+			h.mu.Unlock()
+			if stopped {
+				h.closeFeed(f)
+			}
+		})
+	}
+
+	select {
+	case <-f.ready:
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	}
+	if f.err != nil {
+		release()
+		return nil, nil, f.err
+	}
+
+	source, ok := f.source.(exchange.DepthSource)
+	if !ok {
+		release()
+		return nil, nil, fmt.Errorf("%s does not stream order books", h.exchange)
+	}
+	ch = source.SubscribeDepth()
+	return ch, release, nil
+}
+
+// SubscribeStatus returns the connection status of symbol's upstream feed,
+// starting with the current one, and a release function. The caller must
+// hold a price subscription to the symbol. The channel is nil when the feed
+// does not report its status.
+func (h *Hub) SubscribeStatus(symbol string) (<-chan exchange.Status, func()) {
+	h.mu.RLock()
+	f := h.feeds[strings.ToLower(symbol)]
+	h.mu.RUnlock()
+	if f == nil {
+		return nil, func() {}
+	}
+	source, ok := f.source.(exchange.StatusSource)
+	if !ok {
+		return nil, func() {}
+	}
+	ch := source.SubscribeStatus()
+	return ch, func() { source.UnsubscribeStatus(ch) }
+}
+
+// acquire returns the feed for symbol, starting it if needed, and cancels its
+// linger timer. Must be called with h.mu held.
+func (h *Hub) acquire(symbol string) *feed {
+	f, ok := h.feeds[symbol]
+	if !ok {
+		f = h.startFeed(symbol)
+	}
+	if f.lingerTimer != nil {
+		f.lingerTimer.Stop()
+		f.lingerTimer = nil
+	}
+	return f
+}
+
+// Subscribers returns the number of active subscribers for symbol.
+func (h *Hub) Subscribers(symbol string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	f, ok := h.feeds[strings.ToLower(symbol)]
+	if !ok {
+		return 0
+	}
+	return len(f.subscribers)
+}
+
+// Dropped returns how many updates were dropped for the subscriber channel ch
+// of symbol because it was full.
+func (h *Hub) Dropped(symbol string, ch <-chan exchange.Tick) uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	f, ok := h.feeds[strings.ToLower(symbol)]
+	if !ok {
+		return 0
+	}
+	for sub, dropped := range f.subscribers {
+		if sub == ch {
+			return dropped.Load()
+		}
+	}
+	return 0
+}
+
+// Close tears down every upstream feed and closes all subscriber channels.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	var stopped []*feed
+	for _, f := range h.feeds {
+		for ch := range f.subscribers {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+		h.stopFeed(f)
+		stopped = append(stopped, f)
+	}
+	h.mu.Unlock()
+
+	for _, f := range stopped {
+		h.closeFeed(f)
+	}
+}
+
+// startFeed registers a new feed and connects it in the background. Must be called with h.mu held.
+func (h *Hub) startFeed(symbol string) *feed {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &feed{
+		symbol:      symbol,
+		source:      h.newSource(symbol),
+		cancel:      cancel,
+		ready:       make(chan struct{}),
+		subscribers: make(map[chan exchange.Tick]*atomic.Uint64),
+	}
+	if source, ok := f.source.(exchange.ReplaySource); ok {
+		f.replay = source.Replaying()
+	}
+	h.feeds[symbol] = f
+
+	go func() {
+		if err := f.source.Connect(ctx); err != nil {
+			f.err = fmt.Errorf("connect %s upstream for %s: %w", h.exchange, symbol, err)
+			h.mu.Lock()
+			if h.feeds[symbol] == f {
+				delete(h.feeds, symbol)
+			}
+			h.mu.Unlock()
+			cancel()
+			close(f.ready)
+			return
+		}
+		var opts []exchange.SubscribeOption
+		if f.replay {
+			opts = append(opts, exchange.WithPolicy(exchange.Block), exchange.WithBlockTimeout(replayBlockTimeout))
+		}
+		upstream := f.source.Subscribe(opts...)
+		var gaps <-chan exchange.Gap
+		if source, ok := f.source.(exchange.GapSource); ok {
+			gaps = source.SubscribeGaps()
+		}
+		close(f.ready)
+		log.Printf("hub: opened %s upstream feed for %s", h.exchange, symbol)
+		h.pump(ctx, f, upstream, gaps)
+	}()
+
+	return f
+}
+
+// pump fans upstream updates out to every subscriber of the feed and logs the
+// gaps the upstream could not backfill, if it reports them.
+func (h *Hub) pump(ctx context.Context, f *feed, upstream <-chan exchange.Tick, gaps <-chan exchange.Gap) {
+	var storeFailing bool
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case gap, ok := <-gaps:
+			if !ok {
+				gaps = nil
+				continue
+			}
+			log.Printf("hub: %s feed for %s missed trades from %s to %s: %v", h.exchange, f.symbol,
+				gap.From.Format(time.RFC3339), gap.To.Format(time.RFC3339), gap.Err)
+		case update, ok := <-upstream:
+			if !ok {
+				h.upstreamClosed(f)
+				return
+			}
+			h.mu.RLock()
+			for ch, dropped := range f.subscribers {
+				if !deliver(ch, update, f.replay) {
+					dropped.Add(1)
+				}
+			}
+			h.mu.RUnlock()
+
+			// Log only the first of a run of storage failures
+			err := h.store.AppendTick(f.symbol, store.Tick{Time: update.Timestamp, Price: update.Price, Volume: update.Volume, Trade: update.IsTrade})
+			if err != nil && !storeFailing {
+				log.Printf("hub: failed to record %s tick: %v", f.symbol, err)
+			}
+			storeFailing = err != nil
+		}
+	}
+}
+
+// deliver writes update to a subscriber channel and reports whether it fit.
+// Live feeds drop the update right away when the subscriber is not keeping
+// up; with block set it waits up to replayBlockTimeout for room first.
+func deliver(ch chan exchange.Tick, update exchange.Tick, block bool) bool {
+	select {
+	case ch <- update:
+		return true
+	default:
+	}
+	if !block {
+		return false
+	}
+	timer := time.NewTimer(replayBlockTimeout)
+	defer timer.Stop()
+	select {
+	case ch <- update:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// upstreamClosed tears down a feed whose source ended on its own, as on a
+// close frame from the exchange. Its subscriber channels are closed so their
+// streams end, and the next Subscribe connects a new feed.
+func (h *Hub) upstreamClosed(f *feed) {
+	h.mu.Lock()
+	stopped := h.feeds[f.symbol] == f
+	for ch := range f.subscribers {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+	if stopped {
+		h.stopFeed(f)
+	}
+	h.mu.Unlock()
+
+	if stopped {
+		log.Printf("hub: %s upstream feed for %s ended", h.exchange, f.symbol)
+		h.closeFeed(f)
+	}
+}
+
+// release removes a subscriber and schedules the feed for teardown when it was the last one.
+func (h *Hub) release(f *feed, ch chan exchange.Tick) {
+	h.mu.Lock()
+	if _, ok := f.subscribers[ch]; !ok {
+		h.mu.Unlock()
+		return
+	}
+	delete(f.subscribers, ch)
+	close(ch)
+	stopped := h.idle(f)
+	h.mu.Unlock()
+
+	if stopped {
+		h.closeFeed(f)
+	}
+}
+
+// idle schedules the feed for teardown when it has no subscribers left, and
+// reports whether it stopped the feed right away, in which case the caller
+// closes it after releasing h.mu. Must be called with h.mu held.
+func (h *Hub) idle(f *feed) bool {
+	if len(f.subscribers) > 0 || f.depth > 0 || h.feeds[f.symbol] != f {
+		return false
+	}
+
+	if h.linger <= 0 {
+		h.stopFeed(f)
+		return true
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(h.linger, func() {
+		h.mu.Lock()
+		stopped := f.lingerTimer == timer && len(f.subscribers) == 0 && f.depth == 0 && h.feeds[f.symbol] == f
+		if stopped {
+			h.stopFeed(f)
+		}
+		h.mu.Unlock()
+		if stopped {
+			h.closeFeed(f)
+		}
+	})
+	f.lingerTimer = timer
+	return false
+}
+
+// stopFeed removes the feed from the hub and stops its pump. The caller must
+// then call closeFeed once h.mu is released, since closing a source can block
+// on a reconnect. Must be called with h.mu held.
+func (h *Hub) stopFeed(f *feed) {
+	if h.feeds[f.symbol] == f {
+		delete(h.feeds, f.symbol)
+	}
+	if f.lingerTimer != nil {
+		f.lingerTimer.Stop()
+		f.lingerTimer = nil
+	}
+	f.cancel()
+}
+
+// closeFeed closes the upstream connection of a stopped feed. Must be called
+// without h.mu held.
+func (h *Hub) closeFeed(f *feed) {
+	f.source.Close()
+	log.Printf("hub: closed %s upstream feed for %s", h.exchange, f.symbol)
+}
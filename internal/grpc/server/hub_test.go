@@ -0,0 +1,313 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	"github.com/rp4ri/quantacode/internal/infra/store"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+func newTestHub(linger time.Duration) (*Hub, *int) {
+	created := 0
+	hub := NewHub(linger)
+	hub.newSource = func(symbol string) exchange.MarketDataSource {
+		created++
+		return binance.NewSimulatedClient(symbol)
+	}
+	return hub, &created
+}
+
+// closed reports whether ch is closed within a few seconds, skipping the
+// updates still buffered in it.
+func closed(ch <-chan exchange.Tick) bool {
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func TestHubSharesUpstreamPerSymbol(t *testing.T) {
+	hub, created := newTestHub(0)
+	defer hub.Close()
+
+	ctx := context.Background()
+	ch1, release1, err := hub.Subscribe(ctx, "btcusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer release1()
+	ch2, release2, err := hub.Subscribe(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer release2()
+
+	if *created != 1 {
+		t.Errorf("upstream clients created = %d, want 1", *created)
+	}
+	if got := hub.Subscribers("btcusdt"); got != 2 {
+		t.Errorf("Subscribers() = %d, want 2", got)
+	}
+
+	for i, ch := range []<-chan binance.PriceUpdate{ch1, ch2} {
+		select {
+		case <-ch:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("subscriber %d did not receive an update", i+1)
+		}
+	}
+}
+
+func TestHubReleaseClosesFeed(t *testing.T) {
+	hub, _ := newTestHub(0)
+	defer hub.Close()
+
+	ch, release, err := hub.Subscribe(context.Background(), "ethusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	release()
+	release() // must be idempotent
+
+	if !closed(ch) {
+		t.Error("subscriber channel should be closed after release")
+	}
+	if got := hub.Subscribers("ethusdt"); got != 0 {
+		t.Errorf("Subscribers() = %d, want 0", got)
+	}
+	if _, ok := hub.feeds["ethusdt"]; ok {
+		t.Error("feed should be torn down when linger is zero")
+	}
+}
+
+func TestHubLingerKeepsFeed(t *testing.T) {
+	hub, created := newTestHub(200 * time.Millisecond)
+	defer hub.Close()
+
+	_, release, err := hub.Subscribe(context.Background(), "solusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	release()
+
+	// Re-subscribing within the linger period reuses the upstream client
+	_, release, err = hub.Subscribe(context.Background(), "solusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if *created != 1 {
+		t.Errorf("upstream clients created = %d, want 1", *created)
+	}
+	release()
+
+	time.Sleep(400 * time.Millisecond)
+
+	hub.mu.RLock()
+	_, ok := hub.feeds["solusdt"]
+	hub.mu.RUnlock()
+	if ok {
+		t.Error("feed should be torn down after linger period")
+	}
+}
+
+func TestHubReplayWaitsForSlowSubscribers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	rec, err := binance.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := 3 * exchange.DefaultBufferSize
+	t0 := time.Unix(1709251200, 0)
+	for i := 1; i <= frames; i++ {
+		frame := fmt.Sprintf(`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","s":"BTCUSDT","p":"%d","q":"1","T":%d}}`, i, t0.UnixMilli()+int64(i))
+		if err := rec.Record(t0.Add(time.Duration(i)*time.Millisecond), []byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.Close()
+
+	hub := NewExchangeHub(DefaultExchange, func(symbol string) exchange.MarketDataSource {
+		return binance.NewReplayClient(symbol, path, 0)
+	}, 0)
+	defer hub.Close()
+
+	ch, release, err := hub.Subscribe(context.Background(), "btcusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer release()
+
+	// Let the replay fill every buffer before reading
+	time.Sleep(100 * time.Millisecond)
+	for i := 1; i <= frames; i++ {
+		select {
+		case tick := <-ch:
+			if tick.Price != float64(i) {
+				t.Fatalf("tick %d price = %v, want %d", i, tick.Price, i)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("received %d of %d replayed ticks", i-1, frames)
+		}
+	}
+	if dropped := hub.Dropped("btcusdt", ch); dropped != 0 {
+		t.Errorf("Dropped() = %d, want 0", dropped)
+	}
+}
+
+func TestHubReconnectsAfterUpstreamEnds(t *testing.T) {
+	hub, created := newTestHub(time.Minute)
+	defer hub.Close()
+
+	ch, release, err := hub.Subscribe(context.Background(), "adausdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer release()
+
+	// The source ends on its own, as on a close frame from the exchange
+	hub.mu.RLock()
+	source := hub.feeds["adausdt"].source
+	hub.mu.RUnlock()
+	source.Close()
+
+	if !closed(ch) {
+		t.Fatal("subscriber channel should be closed when the upstream ends")
+	}
+
+	ch, release2, err := hub.Subscribe(context.Background(), "adausdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer release2()
+	if *created != 2 {
+		t.Errorf("upstream clients created = %d, want a new one", *created)
+	}
+	select {
+	case <-ch:
+	case <-time.After(3 * time.Second):
+		t.Error("new feed should deliver updates")
+	}
+}
+
+func TestHubRecordsTicksAndStreamsBackfill(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	hub, _ := newTestHub(0)
+	defer hub.Close()
+	hub.SetStore(st)
+
+	ch, release, err := hub.Subscribe(context.Background(), "btcusdt")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	update := <-ch
+	// The tick is recorded right after it is fanned out
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		ticks, _ := st.Ticks("btcusdt", update.Timestamp.Add(-time.Second), update.Timestamp.Add(time.Second))
+		if len(ticks) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("upstream tick was not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	release()
+
+	// A stream warms up from stored candles instead of fetching klines
+	now := time.Now()
+	length := time.Minute
+	var stored []candles.Candle
+	for i := 60; i >= 1; i-- {
+		open := now.Truncate(length).Add(-time.Duration(i) * length)
+		stored = append(stored, candles.Candle{OpenTime: open, Open: 100, High: 101, Low: 99, Close: 100, Volume: 1, CloseTime: open.Add(length - time.Millisecond)})
+	}
+	st.AppendCandles("ethusdt", "1m", stored...)
+
+	handler := NewHandler("btcusdt", hub)
+	handler.fetchKlines = func(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+		t.Errorf("fetchKlines(%s, %s) called despite stored history", symbol, interval)
+		return nil, nil
+	}
+	cfg, _ := defaultStreamConfig().merge("1m", nil)
+	s := handler.newSymbolStream("ethusdt", cfg, nil, nil)
+	_, _, _, klines, err := s.warmup(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("warmup() error = %v", err)
+	}
+	if len(klines) < 50 {
+		t.Errorf("warmup() returned %d klines, want at least 50 from the store", len(klines))
+	}
+}
+
+func TestWarmupSeedsVWAPFromClosedBars(t *testing.T) {
+	hub, _ := newTestHub(0)
+	defer hub.Close()
+
+	now := time.Now()
+	length := time.Minute
+	handler := NewHandler("btcusdt", hub)
+	handler.fetchKlines = func(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+		var klines []binance.Kline
+		for i := 3; i >= 0; i-- {
+			open := now.Truncate(length).Add(-time.Duration(i) * length)
+			k := binance.Kline{OpenTime: open, Open: 100, High: 101, Low: 99, Close: 100, Volume: 1, CloseTime: open.Add(length - time.Millisecond)}
+			if i == 0 {
+				// The open bar, which live trades extend
+				k.High, k.Close, k.Volume = 400, 400, 100
+			}
+			klines = append(klines, k)
+		}
+		return klines, nil
+	}
+	cfg, _ := defaultStreamConfig().merge("1m", nil)
+	s := handler.newSymbolStream("ethusdt", cfg, nil, nil)
+	agg, _, _, _, err := s.warmup(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("warmup() error = %v", err)
+	}
+	if vwap := agg.Values().VWAP; vwap != 100 {
+		t.Errorf("VWAP = %v, want 100 from the closed bars only", vwap)
+	}
+}
+
+func TestWarmupCapsKlineRequests(t *testing.T) {
+	hub, _ := newTestHub(0)
+	defer hub.Close()
+
+	var requested int
+	handler := NewHandler("btcusdt", hub)
+	handler.fetchKlines = func(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+		requested = limit
+		return nil, nil
+	}
+	cfg, err := defaultStreamConfig().merge("1m", &pb.IndicatorConfig{Specs: []*pb.IndicatorSpec{{Name: "macd", Params: []float64{12, 1000, 1000}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := handler.newSymbolStream("ethusdt", cfg, nil, nil)
+	if _, _, _, _, err := s.warmup(context.Background(), cfg); err != nil {
+		t.Fatalf("warmup() error = %v", err)
+	}
+	if requested != binance.MaxKlines {
+		t.Errorf("warmup() requested %d klines, want the maximum of %d", requested, binance.MaxKlines)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/rp4ri/quantacode/internal/domain/orderbook"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// resolveDepthLevels validates the requested order book depth, capping it at
+// the levels the upstream feed provides.
+func resolveDepthLevels(n int32) (int, error) {
+	if n < 0 {
+		return 0, fmt.Errorf("invalid depth levels %d", n)
+	}
+	return min(int(n), binance.DepthLevels), nil
+}
+
+// orderBookMessage builds an OrderBookUpdate with the top levels of a book.
+func orderBookMessage(update exchange.DepthUpdate, levels int) *pb.MarketUpdate {
+	bids, asks := update.Bids[:min(levels, len(update.Bids))], update.Asks[:min(levels, len(update.Asks))]
+	m := orderbook.Measure(bids, asks)
+	return &pb.MarketUpdate{
+		Update: &pb.MarketUpdate_OrderBook{
+			OrderBook: &pb.OrderBookUpdate{
+				Symbol:       update.Symbol,
+				Bids:         levelMessages(bids),
+				Asks:         levelMessages(asks),
+				BestBid:      m.BestBid,
+				BestAsk:      m.BestAsk,
+				Mid:          m.Mid,
+				Spread:       m.Spread,
+				SpreadBps:    m.SpreadBps,
+				Imbalance:    m.Imbalance,
+				LastUpdateId: update.LastUpdateID,
+				Timestamp:    update.Timestamp.UnixMilli(),
+			},
+		},
+	}
+}
+
+func levelMessages(levels []orderbook.Level) []*pb.PriceLevel {
+	out := make([]*pb.PriceLevel, len(levels))
+	for i, l := range levels {
+		out[i] = &pb.PriceLevel{Price: l.Price, Quantity: l.Quantity}
+	}
+	return out
+}
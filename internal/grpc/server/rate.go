@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// maxUpdatesPerSecond caps a requested update rate; faster ones are not throttled at all.
+const maxUpdatesPerSecond = 1000
+
+// updateRate limits how often a stream sends price and indicator updates.
+// The zero value sends an update for every tick.
+type updateRate struct {
+	every        time.Duration // minimum gap between two updates
+	barCloseOnly bool
+}
+
+// updateRateFromProto validates a requested rate. A nil rate is unlimited.
+func updateRateFromProto(r *pb.UpdateRate) (updateRate, error) {
+	perSecond := r.GetMaxPerSecond()
+	if perSecond < 0 || math.IsNaN(perSecond) || math.IsInf(perSecond, 0) {
+		return updateRate{}, fmt.Errorf("invalid update rate %v per second", perSecond)
+	}
+	rate := updateRate{barCloseOnly: r.GetBarCloseOnly()}
+	if perSecond > 0 && perSecond < maxUpdatesPerSecond {
+		rate.every = time.Duration(float64(time.Second) / perSecond)
+	}
+	return rate, nil
+}
+
+// limited reports whether some ticks may be coalesced.
+func (r updateRate) limited() bool {
+	return r.barCloseOnly || r.every > 0
+}
+
+// throttle decides which ticks of a stream are sent. Ticks that are held back
+// are coalesced: the next update carries the latest price and indicators.
+type throttle struct {
+	rate      updateRate
+	lastSent  time.Time
+	pending   bool // a tick is waiting for the flush timer
+	coalesced uint64
+}
+
+// tick reports whether the update for a new tick should be sent now. When it
+// returns false and wait is positive, the caller should flush the tick after wait.
+func (t *throttle) tick(barClosed bool, now time.Time) (send bool, wait time.Duration) {
+	if t.pending {
+		// The held back tick is superseded by this one
+		t.coalesced++
+		t.pending = false
+	}
+	switch {
+	case !t.rate.limited() || barClosed:
+		return true, 0
+	case t.rate.barCloseOnly:
+		t.coalesced++
+		return false, 0
+	}
+	if wait := t.lastSent.Add(t.rate.every).Sub(now); wait > 0 {
+		t.pending = true
+		return false, wait
+	}
+	return true, 0
+}
+
+// sent records that an update went out at now.
+func (t *throttle) sent(now time.Time) {
+	t.lastSent = now
+	t.pending = false
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+func TestUpdateRateFromProto(t *testing.T) {
+	rate, err := updateRateFromProto(&pb.UpdateRate{MaxPerSecond: 4})
+	if err != nil || rate.every != 250*time.Millisecond || rate.barCloseOnly {
+		t.Errorf("updateRateFromProto(4/s) = %+v, %v", rate, err)
+	}
+	if rate, _ := updateRateFromProto(nil); rate.limited() {
+		t.Error("nil rate should not be limited")
+	}
+	if rate, _ := updateRateFromProto(&pb.UpdateRate{MaxPerSecond: 1e6}); rate.limited() {
+		t.Error("rates above the cap should not be limited")
+	}
+	for _, bad := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if _, err := updateRateFromProto(&pb.UpdateRate{MaxPerSecond: bad}); err == nil {
+			t.Errorf("updateRateFromProto(%v) should fail", bad)
+		}
+	}
+}
+
+func TestThrottleCoalescesTicks(t *testing.T) {
+	th := throttle{rate: updateRate{every: time.Second}}
+	now := time.Now()
+
+	if send, _ := th.tick(false, now); !send {
+		t.Fatal("first tick should be sent")
+	}
+	th.sent(now)
+
+	send, wait := th.tick(false, now.Add(200*time.Millisecond))
+	if send || wait != 800*time.Millisecond {
+		t.Fatalf("tick within the interval = %v, %v; want held back for 800ms", send, wait)
+	}
+	th.tick(false, now.Add(400*time.Millisecond))
+	if th.coalesced != 1 {
+		t.Errorf("coalesced = %d after a held back tick was superseded, want 1", th.coalesced)
+	}
+
+	// A bar close is sent at once, superseding the held back tick
+	if send, _ := th.tick(true, now.Add(500*time.Millisecond)); !send {
+		t.Error("bar close should be sent immediately")
+	}
+	th.sent(now.Add(500 * time.Millisecond))
+	if th.coalesced != 2 || th.pending {
+		t.Errorf("coalesced = %d, pending = %v; want 2, false", th.coalesced, th.pending)
+	}
+
+	if send, _ := th.tick(false, now.Add(1500*time.Millisecond)); !send {
+		t.Error("tick after the interval should be sent")
+	}
+}
+
+func TestThrottleBarCloseOnly(t *testing.T) {
+	th := throttle{rate: updateRate{barCloseOnly: true}}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if send, wait := th.tick(false, now); send || wait != 0 {
+			t.Fatalf("tick %d = %v, %v; want dropped without a flush", i, send, wait)
+		}
+	}
+	if send, _ := th.tick(true, now); !send {
+		t.Error("bar close should be sent")
+	}
+	if th.coalesced != 3 {
+		t.Errorf("coalesced = %d, want 3", th.coalesced)
+	}
+}
+
+func TestStreamPricesBarCloseOnly(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamPrices(ctx, &pb.StreamRequest{Symbol: "btcusdt", Rate: &pb.UpdateRate{MaxPerSecond: -2}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("negative rate error = %v, want InvalidArgument", err)
+	}
+
+	// Only the initial snapshot is sent until the hourly bar closes
+	streamCtx, stop := context.WithTimeout(ctx, 2500*time.Millisecond)
+	defer stop()
+	stream, err = client.StreamPrices(streamCtx, &pb.StreamRequest{Symbol: "btcusdt", Rate: &pb.UpdateRate{BarCloseOnly: true}})
+	if err != nil {
+		t.Fatalf("StreamPrices() error = %v", err)
+	}
+	var updates int
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if _, ok := msg.Update.(*pb.MarketUpdate_Indicators); ok {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Errorf("received %d indicator updates in bar-close-only mode, want only the initial snapshot", updates)
+	}
+}
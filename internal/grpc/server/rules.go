@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// resolveRules validates the requested signal rules and returns them in the
+// canonical newline-separated "name: expression" form stored in streamConfig.
+func resolveRules(requested []*pb.SignalRule) (string, error) {
+	lines := make([]string, 0, len(requested))
+	for _, r := range requested {
+		name := strings.TrimSpace(r.GetName())
+		expr := strings.TrimSpace(r.GetExpression())
+		if strings.ContainsAny(expr, "\n\r") {
+			return "", fmt.Errorf("rule %q: expression must be a single line", expr)
+		}
+		if name != "" && !rules.ValidName(name) {
+			return "", fmt.Errorf("invalid rule name %q", name)
+		}
+		line := expr
+		if name != "" && name != expr {
+			line = name + ": " + expr
+		}
+		lines = append(lines, line)
+	}
+
+	if _, err := rules.ParseList(lines); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// signalRules evaluates a stream's rules: committed on every closed bar and
+// previewed against the live bar.
+type signalRules struct {
+	rules []*rules.Rule
+	last  []rules.Result
+}
+
+func newSignalRules(cfg string) (*signalRules, error) {
+	var lines []string
+	if cfg != "" {
+		lines = strings.Split(cfg, "\n")
+	}
+	rs, err := rules.ParseList(lines)
+	if err != nil {
+		return nil, err
+	}
+	return &signalRules{rules: rs, last: make([]rules.Result, len(rs))}, nil
+}
+
+// specs returns the registry indicators the rules depend on.
+func (s *signalRules) specs() []indicators.Spec {
+	return rules.SpecsOf(s.rules)
+}
+
+// closeBar evaluates every rule on a bar that just closed.
+func (s *signalRules) closeBar(bar indicators.Bar, vals indicators.AggregatedValues, specVals []indicators.Value) {
+	env := rules.Snapshot{Bar: bar, Values: vals, Indicators: specVals}
+	for i, r := range s.rules {
+		s.last[i] = r.Evaluate(env)
+	}
+}
+
+// closedResults returns the results of the last closed bar. Triggers are only
+// reported on the update that closed the bar.
+func (s *signalRules) closedResults(barClosed bool) []*pb.RuleResult {
+	if s == nil || len(s.rules) == 0 {
+		return nil
+	}
+	results := make([]*pb.RuleResult, len(s.rules))
+	for i, r := range s.rules {
+		results[i] = &pb.RuleResult{Name: r.Name, Value: s.last[i].Value, Triggered: barClosed && s.last[i].Triggered}
+	}
+	return results
+}
+
+// liveResults previews every rule against the in-progress bar.
+func (s *signalRules) liveResults(bar indicators.Bar, vals indicators.AggregatedValues, specVals []indicators.Value) []*pb.RuleResult {
+	if s == nil || len(s.rules) == 0 {
+		return nil
+	}
+	env := rules.Snapshot{Bar: bar, Values: vals, Indicators: specVals}
+	results := make([]*pb.RuleResult, len(s.rules))
+	for i, r := range s.rules {
+		res := r.Peek(env)
+		results[i] = &pb.RuleResult{Name: r.Name, Value: res.Value, Triggered: res.Triggered}
+	}
+	return results
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// statusMessage builds a StatusUpdate of an upstream feed of exchangeName as
+// of now.
+func statusMessage(exchangeName string, status exchange.Status, now time.Time) *pb.MarketUpdate {
+	age := int64(-1)
+	if !status.LastMessage.IsZero() {
+		age = max(now.Sub(status.LastMessage).Milliseconds(), 0)
+	}
+	var errMsg string
+	if status.Err != nil {
+		errMsg = status.Err.Error()
+	}
+	return &pb.MarketUpdate{
+		Update: &pb.MarketUpdate_Status{
+			Status: &pb.StatusUpdate{
+				Symbol:           status.Symbol,
+				Exchange:         exchangeName,
+				State:            status.State.String(),
+				Endpoint:         status.Endpoint,
+				LastMessageAgeMs: age,
+				Simulated:        status.Simulated,
+				Error:            errMsg,
+				Timestamp:        status.Time.UnixMilli(),
+			},
+		},
+	}
+}
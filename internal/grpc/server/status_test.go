@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+func TestStreamPricesReportsStatus(t *testing.T) {
+	client := startTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamPrices(ctx, &pb.StreamRequest{Symbol: "btcusdt"})
+	if err != nil {
+		t.Fatalf("StreamPrices() error = %v", err)
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		status := msg.GetStatus()
+		if status == nil {
+			continue
+		}
+		if status.GetSymbol() != "BTCUSDT" || status.GetExchange() != "binance" || status.GetState() != "live" ||
+			!status.GetSimulated() || status.GetEndpoint() != "simulator" {
+			t.Errorf("status = %v, want the live simulated feed", status)
+		}
+		return
+	}
+}
+
+func TestStatusMessage(t *testing.T) {
+	now := time.Now()
+	msg := statusMessage("binance", exchange.Status{
+		Symbol:   "BTCUSDT",
+		State:    exchange.Reconnecting,
+		Endpoint: "wss://stream.binance.com:9443/stream",
+		Err:      errors.New("connection reset"),
+		Time:     now,
+	}, now).GetStatus()
+	if msg.GetState() != "reconnecting" || msg.GetLastMessageAgeMs() != -1 || msg.GetError() != "connection reset" || msg.GetTimestamp() != now.UnixMilli() {
+		t.Errorf("statusMessage() = %v", msg)
+	}
+
+	msg = statusMessage("binance", exchange.Status{State: exchange.Degraded, LastMessage: now.Add(-7 * time.Second)}, now).GetStatus()
+	if msg.GetState() != "degraded" || msg.GetLastMessageAgeMs() != 7000 {
+		t.Errorf("statusMessage() = %v, want degraded for 7s", msg)
+	}
+}
@@ -0,0 +1,429 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+	"github.com/rp4ri/quantacode/internal/domain/indicators"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// symbolStream runs the price/indicator pipeline for a single symbol with its own aggregator.
+type symbolStream struct {
+	hub         *Hub
+	fetchKlines func(ctx context.Context, symbol, interval string, limit int) ([]exchange.Bar, error)
+	symbol      string
+	cfg         streamConfig
+	alerts      *alerts.Book
+	onAlert     func(alerts.Alert) // optional, called for every alert before it is sent
+	throttle    throttle           // set its rate before run to coalesce ticks
+	depthLevels int                // order book levels to stream, 0 for none; set before run
+	send        func(*pb.MarketUpdate) error
+	reconfigure chan reconfigureRequest
+	resync      chan struct{}
+
+	seq           uint64               // sequence number of the last indicator update
+	sinceSnapshot int                  // deltas sent since the last snapshot
+	prices        <-chan exchange.Tick // hub subscription, for its drop counter
+}
+
+// snapshotEvery bounds the deltas sent between two snapshots, so clients that
+// cannot ask for a resync, such as StreamPrices ones, still recover from a gap.
+const snapshotEvery = 500
+
+// reconfigureRequest asks a running symbolStream to swap its timeframe and aggregator.
+// Zero-valued fields keep the stream's current setting.
+type reconfigureRequest struct {
+	interval   string
+	indicators *pb.IndicatorConfig
+	done       chan error
+}
+
+func (h *Handler) newSymbolStream(symbol string, cfg streamConfig, book *alerts.Book, send func(*pb.MarketUpdate) error) *symbolStream {
+	return &symbolStream{
+		hub:         h.hub,
+		fetchKlines: h.fetchKlines,
+		symbol:      symbol,
+		cfg:         cfg,
+		alerts:      book,
+		send:        send,
+		reconfigure: make(chan reconfigureRequest),
+		resync:      make(chan struct{}, 1),
+	}
+}
+
+// requestSnapshot makes the stream send an indicator snapshot as soon as possible.
+func (s *symbolStream) requestSnapshot() {
+	select {
+	case s.resync <- struct{}{}:
+	default:
+	}
+}
+
+// run streams until ctx is cancelled or the upstream feed closes. If ready is
+// non-nil it is called once the upstream subscription succeeded or failed.
+func (s *symbolStream) run(ctx context.Context, ready func(error)) error {
+	if ready == nil {
+		ready = func(error) {}
+	}
+
+	agg, builder, signals, klines, err := s.warmup(ctx, s.cfg)
+	if err != nil {
+		ready(err)
+		return err
+	}
+
+	// Attach to the shared upstream feed for the requested symbol
+	priceCh, release, err := s.hub.Subscribe(ctx, s.symbol)
+	if err != nil {
+		log.Printf("failed to subscribe to %s: %v", s.symbol, err)
+		ready(err)
+		return err
+	}
+	defer release()
+	s.prices = priceCh
+
+	var depthCh <-chan exchange.DepthUpdate
+	if s.depthLevels > 0 {
+		ch, releaseDepth, err := s.hub.SubscribeDepth(ctx, s.symbol)
+		if err != nil {
+			log.Printf("failed to subscribe to %s order book: %v", s.symbol, err)
+			ready(err)
+			return err
+		}
+		defer releaseDepth()
+		depthCh = ch
+	}
+	statusCh, releaseStatus := s.hub.SubscribeStatus(s.symbol)
+	defer releaseStatus()
+	ready(nil)
+
+	// Send initial indicator values immediately (from historical data)
+	if len(klines) > 0 {
+		lastKline := klines[len(klines)-1]
+
+		// Send initial price
+		if err := s.send(priceMessage(s.symbol, lastKline.Close, lastKline.Volume, lastKline.CloseTime)); err != nil {
+			return err
+		}
+
+		// Send initial indicators
+		if err := s.sendIndicators(agg, builder, signals, 0, true); err != nil {
+			return err
+		}
+		vals := agg.Values()
+		log.Printf("sent initial indicators for %s: RSI=%.2f SMA=%.2f EMA=%.2f", s.symbol, vals.RSI, vals.SMA, vals.EMA)
+	}
+
+	log.Printf("streaming %s for client (%d subscribers)", s.symbol, s.hub.Subscribers(s.symbol))
+
+	// latest is the last tick, sent by the flush timer when the throttle held it back
+	var latest exchange.Tick
+	var flush <-chan time.Time
+	var lastBook time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case req := <-s.reconfigure:
+			cfg, err := s.cfg.merge(req.interval, req.indicators)
+			if err != nil {
+				req.done <- err
+				continue
+			}
+			newAgg, newBuilder, newSignals, _, err := s.warmup(ctx, cfg)
+			if err != nil {
+				req.done <- err
+				continue
+			}
+			agg, builder, signals = newAgg, newBuilder, newSignals
+			s.cfg = cfg
+			req.done <- nil
+			log.Printf("reconfigured %s: %s RSI(%d) SMA(%d) EMA(%d)", s.symbol, cfg.interval, cfg.rsi, cfg.sma, cfg.ema)
+			if err := s.sendIndicators(agg, builder, signals, 0, true); err != nil {
+				return err
+			}
+		case <-s.resync:
+			if err := s.sendIndicators(agg, builder, signals, 0, true); err != nil {
+				return err
+			}
+		case <-flush:
+			flush = nil
+			if !s.throttle.pending {
+				continue
+			}
+			if err := s.sendTick(latest, agg, builder, signals, 0); err != nil {
+				return err
+			}
+		case status, ok := <-statusCh:
+			if !ok {
+				statusCh = nil
+				continue
+			}
+			if err := s.send(statusMessage(s.hub.Exchange(), status, time.Now())); err != nil {
+				return err
+			}
+		case book, ok := <-depthCh:
+			if !ok {
+				depthCh = nil
+				continue
+			}
+			// Books are complete, so throttling simply skips the ones in between
+			now := time.Now()
+			if now.Sub(lastBook) < s.throttle.rate.every {
+				continue
+			}
+			lastBook = now
+			if err := s.send(orderBookMessage(book, s.depthLevels)); err != nil {
+				return err
+			}
+		case update, ok := <-priceCh:
+			if !ok {
+				return nil
+			}
+
+			// Fold the tick into the current bar; indicators only advance when a bar closes
+			var volume float64
+			if update.IsTrade {
+				volume = update.Volume
+				agg.AddTrade(update.Price, update.Volume, update.Timestamp)
+			}
+			closed := builder.Add(update.Price, volume, update.Timestamp)
+			if err := s.hub.Store().AppendCandles(s.symbol, s.cfg.interval, closed...); err != nil {
+				log.Printf("warning: failed to store %s %s candles: %v", s.symbol, s.cfg.interval, err)
+			}
+			for _, bar := range closed {
+				s.closeBar(agg, signals, indicatorBar(bar))
+			}
+
+			// Every tick advances the pipeline, but the throttle decides which ones
+			// are sent; bar closes always are, so no history point is skipped
+			latest = update
+			send, wait := s.throttle.tick(len(closed) > 0, time.Now())
+			if send {
+				flush = nil
+				if err := s.sendTick(update, agg, builder, signals, len(closed)); err != nil {
+					return err
+				}
+			} else if wait > 0 && flush == nil {
+				flush = time.After(wait)
+			}
+
+			for _, alert := range s.checkAlerts(agg, builder, update.Price, update.Timestamp) {
+				log.Printf("alert %s on %s: %s", alert.RuleID, alert.Symbol, alert.Message)
+				if s.onAlert != nil {
+					s.onAlert(alert)
+				}
+				if err := s.send(alertMessage(alert)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// sendTick sends the price and indicator updates for a tick after which closed bars closed.
+func (s *symbolStream) sendTick(update exchange.Tick, agg *indicators.Aggregator, builder *candles.Builder, signals *signalRules, closed int) error {
+	s.throttle.sent(time.Now())
+	if err := s.send(priceMessage(s.symbol, update.Price, update.Volume, update.Timestamp)); err != nil {
+		log.Printf("send price error: %v", err)
+		return err
+	}
+	if err := s.sendIndicators(agg, builder, signals, closed, false); err != nil {
+		log.Printf("send indicators error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// sendIndicators sends an indicator update after closed bars closed. Snapshots
+// carry the full history windows and are sent when requested, for the first
+// update and every snapshotEvery updates; other updates are deltas that only
+// carry the history points of the closed bars.
+func (s *symbolStream) sendIndicators(agg *indicators.Aggregator, builder *candles.Builder, signals *signalRules, closed int, snapshot bool) error {
+	msg := indicatorMessage(s.symbol, s.cfg.interval, agg, builder, signals, closed > 0)
+	update := msg.GetIndicators()
+
+	snapshot = snapshot || s.seq == 0 || s.sinceSnapshot >= snapshotEvery
+	s.seq++
+	update.Sequence = s.seq
+	update.Snapshot = snapshot
+	update.Coalesced = s.throttle.coalesced
+	if s.prices != nil {
+		update.Dropped = s.hub.Dropped(s.symbol, s.prices)
+	}
+	switch {
+	case snapshot:
+		setHistory(update, agg.History())
+		s.sinceSnapshot = 0
+	case closed > 0:
+		update.Appended = historyPoints(agg.History(), closed)
+		s.sinceSnapshot++
+	default:
+		s.sinceSnapshot++
+	}
+	return s.send(msg)
+}
+
+// warmup builds an aggregator and signal rules for the given configuration and
+// pre-populates them from historical klines. Closed klines advance the
+// indicators; a kline that is still open seeds the candle builder's live bar so
+// live ticks continue it.
+func (s *symbolStream) warmup(ctx context.Context, cfg streamConfig) (*indicators.Aggregator, *candles.Builder, *signalRules, []exchange.Bar, error) {
+	specs, err := indicators.ParseSpecs(cfg.specs)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	signals, err := newSignalRules(cfg.rules)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	agg, err := indicators.NewAggregator(cfg.rsi, cfg.sma, cfg.ema,
+		indicators.WithMACD(cfg.macdFast, cfg.macdSlow, cfg.macdSignal),
+		indicators.WithBollinger(cfg.bbPeriod, cfg.bbStdDev),
+		indicators.WithVolumeSMA(cfg.volumeSMA),
+		indicators.WithVWAPDailyReset(cfg.vwapReset),
+		indicators.WithSpecs(append(append(specs, signals.specs()...), s.alerts.Specs(s.symbol)...)...))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	interval, err := candles.ParseInterval(cfg.interval)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	builder, err := candles.NewBuilder(interval)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// CRITICAL: Fetch historical klines FIRST to pre-populate indicators
+	// This ensures RSI/SMA/EMA are available from second 0
+	klineCount := max(cfg.rsi, cfg.macdSlow+cfg.macdSignal, cfg.bbPeriod, cfg.volumeSMA, agg.SpecWarmup()) + 10 // Fetch extra candles for accurate calculation
+	// Specs may want more than one request holds; they warm up on what it does
+	klineCount = min(max(klineCount, 50), binance.MaxKlines)
+	klines, err := s.history(ctx, cfg.interval, interval, klineCount)
+	if err != nil {
+		log.Printf("warning: failed to fetch historical klines for %s: %v", s.symbol, err)
+		// Continue anyway - indicators will warm up from real-time data
+		return agg, builder, signals, nil, nil
+	}
+
+	// Pre-populate aggregator with historical closed candles
+	history := make([]candles.Candle, len(klines))
+	for i, k := range klines {
+		history[i] = candleFromKline(k)
+	}
+	for _, bar := range builder.Seed(history, time.Now()) {
+		// Approximate the session VWAP from the typical price of past bars; the
+		// VWAP resets itself on bars from previous UTC days. The open bar is
+		// left to live trades, which would otherwise count it twice
+		agg.AddTrade((bar.High+bar.Low+bar.Close)/3, bar.Volume, bar.OpenTime)
+		s.closeBar(agg, signals, indicatorBar(bar))
+	}
+	log.Printf("pre-populated indicators with %d historical %s candles for %s", len(klines), cfg.interval, s.symbol)
+	return agg, builder, signals, klines, nil
+}
+
+// history returns the last limit klines of the stream, oldest first. They come
+// from the hub's store when it holds an unbroken run up to now; otherwise they
+// are fetched from Binance and the closed ones are stored for the next time.
+func (s *symbolStream) history(ctx context.Context, interval string, length time.Duration, limit int) ([]exchange.Bar, error) {
+	now := time.Now()
+	st := s.hub.Store()
+	stored, ok, err := st.Backfill(s.symbol, interval, length, limit, now)
+	if err != nil {
+		log.Printf("warning: failed to read stored %s %s candles: %v", s.symbol, interval, err)
+	}
+	if ok {
+		klines := make([]exchange.Bar, len(stored))
+		for i, c := range stored {
+			klines[i] = klineFromCandle(c)
+		}
+		log.Printf("backfilled %d %s candles for %s from the store", len(stored), interval, s.symbol)
+		return klines, nil
+	}
+
+	klines, err := s.fetchKlines(ctx, s.symbol, interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	var closed []candles.Candle
+	for _, k := range klines {
+		if k.CloseTime.Before(now) {
+			closed = append(closed, candleFromKline(k))
+		}
+	}
+	if err := st.AppendCandles(s.symbol, interval, closed...); err != nil {
+		log.Printf("warning: failed to store %s %s candles: %v", s.symbol, interval, err)
+	}
+	return klines, nil
+}
+
+// closeBar advances the indicators with a closed bar and evaluates the signal
+// rules and alert crossovers on it.
+func (s *symbolStream) closeBar(agg *indicators.Aggregator, signals *signalRules, bar indicators.Bar) {
+	vals := agg.AddBar(bar)
+	specVals := agg.SpecValues()
+	signals.closeBar(bar, vals, specVals)
+	s.alerts.CloseBar(s.symbol, rules.Snapshot{Bar: bar, Values: vals, Indicators: specVals})
+}
+
+// checkAlerts evaluates the alert rules for the symbol at a new price.
+func (s *symbolStream) checkAlerts(agg *indicators.Aggregator, builder *candles.Builder, price float64, ts time.Time) []alerts.Alert {
+	if !s.alerts.Watching(s.symbol) {
+		return nil
+	}
+	closed := rules.Snapshot{Values: agg.Values(), Indicators: agg.SpecValues()}
+	if last, ok := builder.Last(); ok {
+		closed.Bar = indicatorBar(last)
+	}
+	live := closed
+	if bar, ok := builder.Live(); ok {
+		liveBar := indicatorBar(bar)
+		live = rules.Snapshot{Bar: liveBar, Values: agg.Live(liveBar), Indicators: agg.LiveSpecValues(liveBar)}
+	}
+	return s.alerts.Tick(s.symbol, price, ts, closed, live)
+}
+
+// indicatorBar converts a candle into the bar consumed by the indicators.
+func indicatorBar(c candles.Candle) indicators.Bar {
+	return indicators.Bar{
+		Open:   c.Open,
+		High:   c.High,
+		Low:    c.Low,
+		Close:  c.Close,
+		Volume: c.Volume,
+	}
+}
+
+func klineFromCandle(c candles.Candle) exchange.Bar {
+	return exchange.Bar{
+		OpenTime:  c.OpenTime,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+		CloseTime: c.CloseTime,
+	}
+}
+
+func candleFromKline(k exchange.Bar) candles.Candle {
+	return candles.Candle{
+		OpenTime:  k.OpenTime,
+		Open:      k.Open,
+		High:      k.High,
+		Low:       k.Low,
+		Close:     k.Close,
+		Volume:    k.Volume,
+		CloseTime: k.CloseTime,
+	}
+}
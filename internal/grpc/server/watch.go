@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	pb "github.com/rp4ri/quantacode/proto"
+)
+
+// watchRetryDelay is how long a failed alert pipeline waits before restarting.
+const watchRetryDelay = 10 * time.Second
+
+// WatchAlerts evaluates server-configured alert rules without a connected
+// client and hands every alert to deliver. The watched symbols are those named
+// by the rules, plus the default symbol when a rule names none; such rules
+// apply to every watched symbol. Each symbol runs the same pipeline as a client stream on the
+// given interval and is restarted when it fails. WatchAlerts blocks until ctx
+// is cancelled.
+func (h *Handler) WatchAlerts(ctx context.Context, book *alerts.Book, interval string, deliver func(alerts.Alert)) error {
+	cfg, err := defaultStreamConfig().merge(interval, nil)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, symbol := range h.watchedSymbols(book) {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			// Only alerts leave a headless stream
+			discard := func(*pb.MarketUpdate) error { return nil }
+			for {
+				s := h.newSymbolStream(symbol, cfg, book, discard)
+				s.onAlert = deliver
+				err := s.run(ctx, nil)
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("alert watch for %s stopped: %v; restarting in %v", symbol, err, watchRetryDelay)
+				select {
+				case <-time.After(watchRetryDelay):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(symbol)
+	}
+
+	log.Printf("watching %d server alert rules", len(book.Rules()))
+	wg.Wait()
+	return ctx.Err()
+}
+
+// watchedSymbols returns the distinct symbols named by the book's rules.
+func (h *Handler) watchedSymbols(book *alerts.Book) []string {
+	var symbols []string
+	for _, r := range book.Rules() {
+		symbol := r.Symbol
+		if symbol == "" {
+			symbol = h.defaultSymbol
+		}
+		symbols = append(symbols, symbol)
+	}
+	return normalizeSymbols(symbols)
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+	"github.com/rp4ri/quantacode/internal/domain/rules"
+	"github.com/rp4ri/quantacode/internal/infra/binance"
+)
+
+func TestWatchAlertsDeliversWithoutClients(t *testing.T) {
+	hub, _ := newTestHub(0)
+	defer hub.Close()
+	handler := NewHandler("btcusdt", hub)
+	handler.fetchKlines = func(ctx context.Context, symbol, interval string, limit int) ([]binance.Kline, error) {
+		return nil, nil
+	}
+
+	cond, err := rules.Parse("close > 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := alerts.NewBook(
+		alerts.Rule{ID: "any", Condition: cond},
+		alerts.Rule{ID: "eth-move", Symbol: "ETHUSDT", Move: &alerts.Move{Percent: 50, Window: time.Minute}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := handler.watchedSymbols(book); len(got) != 2 || got[0] != "btcusdt" || got[1] != "ethusdt" {
+		t.Errorf("watchedSymbols() = %v, want [btcusdt ethusdt]", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	delivered := make(chan alerts.Alert, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.WatchAlerts(ctx, book, "1m", func(a alerts.Alert) { delivered <- a })
+	}()
+
+	select {
+	case a := <-delivered:
+		if a.RuleID != "any" || a.Price <= 0 {
+			t.Errorf("alert = %+v", a)
+		}
+	case <-ctx.Done():
+		t.Fatal("no alert delivered")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("WatchAlerts() = %v, want context.Canceled", err)
+	}
+}
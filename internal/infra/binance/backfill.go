@@ -0,0 +1,174 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+const (
+	// aggTradesLimit is the most trades one aggTrades request returns.
+	aggTradesLimit = 1000
+	// maxBackfillTrades bounds a backfill; longer outages are reported as
+	// gaps rather than stalling live data behind pages of history.
+	maxBackfillTrades = 10 * aggTradesLimit
+)
+
+// tradeCursor is the last aggregate trade the client delivered, where a
+// backfill resumes. A zero id means no trade has been delivered yet.
+type tradeCursor struct {
+	id   int64
+	time time.Time
+}
+
+// SubscribeGaps adds a subscriber for the gaps the client reports when it
+// cannot backfill the trades missed while reconnecting.
+func (c *Client) SubscribeGaps(opts ...exchange.SubscribeOption) <-chan exchange.Gap {
+	sub := exchange.NewSubscriber[exchange.Gap]("binance "+c.symbol+" gaps", exchange.DropOldest, opts)
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closed {
+		sub.Close()
+		return sub.C()
+	}
+	c.gapSubscribers = append(c.gapSubscribers, sub)
+	return sub.C()
+}
+
+// UnsubscribeGaps removes a gap subscriber and closes its channel.
+func (c *Client) UnsubscribeGaps(ch <-chan exchange.Gap) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if i := c.gapSubscribers.Find(ch); i >= 0 {
+		c.gapSubscribers.Remove(i)
+	}
+}
+
+// backfill delivers the trades after last that were made while the client
+// reconnected, oldest first, and returns the new last trade. Live trades up
+// to it are then duplicates. Trades it cannot recover are reported as a gap.
+func (c *Client) backfill(ctx context.Context, last tradeCursor) tradeCursor {
+	if last.id == 0 {
+		return last
+	}
+	c.mu.Lock()
+	endpoint := c.endpoint
+	c.mu.Unlock()
+
+	var fetched int
+	for {
+		select {
+		case <-ctx.Done():
+			return last
+		case <-c.done:
+			return last
+		default:
+		}
+
+		trades, err := fetchAggTrades(ctx, endpoint.REST+"/api/v3/aggTrades", c.symbol, last.id+1)
+		if err != nil {
+			c.reportGap(last, fmt.Errorf("fetch missed trades: %w", err))
+			return last
+		}
+		if len(trades) > 0 && trades[0].id > last.id+1 {
+			c.reportGap(last, fmt.Errorf("trades %d to %d are no longer available", last.id+1, trades[0].id-1))
+		}
+		for _, t := range trades {
+			c.broadcast(t.update)
+			last = tradeCursor{id: t.id, time: t.update.Timestamp}
+		}
+		fetched += len(trades)
+
+		if len(trades) < aggTradesLimit {
+			log.Printf("binance: backfilled %d %s trades missed while reconnecting", fetched, c.symbol)
+			return last
+		}
+		if fetched >= maxBackfillTrades {
+			c.reportGap(last, fmt.Errorf("more than %d trades missed", maxBackfillTrades))
+			return last
+		}
+	}
+}
+
+// reportGap tells gap subscribers that trades after last are missing.
+func (c *Client) reportGap(last tradeCursor, err error) {
+	gap := exchange.Gap{Symbol: strings.ToUpper(c.symbol), From: last.time, To: time.Now(), Err: err}
+	log.Printf("binance: %s trades since %s are missing: %v", c.symbol, last.time.Format(time.RFC3339), err)
+
+	c.subMu.RLock()
+	disconnect := c.gapSubscribers.Broadcast(gap)
+	c.subMu.RUnlock()
+
+	if len(disconnect) > 0 {
+		c.subMu.Lock()
+		c.gapSubscribers.Disconnect(disconnect)
+		c.subMu.Unlock()
+	}
+}
+
+// missedTrade is a trade fetched by a backfill.
+type missedTrade struct {
+	id     int64
+	update PriceUpdate
+}
+
+// aggTradeRow is one entry of the REST aggTrades response.
+type aggTradeRow struct {
+	ID       int64  `json:"a"`
+	Price    string `json:"p"`
+	Quantity string `json:"q"`
+	Time     int64  `json:"T"`
+}
+
+// fetchAggTrades fetches up to aggTradesLimit aggregate trades of symbol from
+// fromID on, oldest first.
+func fetchAggTrades(ctx context.Context, baseURL, symbol string, fromID int64) ([]missedTrade, error) {
+	reqURL := fmt.Sprintf("%s?symbol=%s&fromId=%d&limit=%d", baseURL, strings.ToUpper(symbol), fromID, aggTradesLimit)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	var rows []aggTradeRow
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decode aggTrades: %w", err)
+	}
+
+	trades := make([]missedTrade, 0, len(rows))
+	for _, row := range rows {
+		price, err := strconv.ParseFloat(row.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse aggTrade %d price: %w", row.ID, err)
+		}
+		quantity, err := strconv.ParseFloat(row.Quantity, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse aggTrade %d quantity: %w", row.ID, err)
+		}
+		trades = append(trades, missedTrade{id: row.ID, update: PriceUpdate{
+			Symbol:    strings.ToUpper(symbol),
+			Price:     price,
+			Volume:    quantity,
+			Timestamp: time.UnixMilli(row.Time),
+			IsTrade:   true,
+		}})
+	}
+	return trades, nil
+}
@@ -0,0 +1,100 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var tradeTime = time.UnixMilli(1709251200000)
+
+// tradeFrame is the aggTrade frame of trade id at price id.
+func tradeFrame(id int) string {
+	return fmt.Sprintf(`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","s":"BTCUSDT","a":%d,"p":"%d","q":"1","T":%d}}`,
+		id, id, tradeTime.Add(time.Duration(id)*time.Second).UnixMilli())
+}
+
+// tradeRow is the REST aggTrades entry of trade id at price id.
+func tradeRow(id int) string {
+	return fmt.Sprintf(`{"a":%d,"p":"%d","q":"1","f":%d,"l":%d,"T":%d,"m":true}`,
+		id, id, id, id, tradeTime.Add(time.Duration(id)*time.Second).UnixMilli())
+}
+
+func TestBackfillAfterReconnect(t *testing.T) {
+	const missed = "/api/v3/aggTrades?symbol=BTCUSDT&fromId=2&limit=1000"
+	tests := []struct {
+		name       string
+		rest       map[string]string
+		wantPrices []float64
+		wantGap    bool
+	}{
+		{
+			name:       "backfilled",
+			rest:       map[string]string{missed: "[" + tradeRow(2) + "," + tradeRow(3) + "]"},
+			wantPrices: []float64{1, 2, 3, 4},
+		},
+		{
+			name:       "aggTrades unavailable",
+			wantPrices: []float64{1, 3, 4},
+			wantGap:    true,
+		},
+		{
+			name:       "malformed trades",
+			rest:       map[string]string{missed: `[{"a":2,"p":"oops","q":"1","T":1709251202000}]`},
+			wantPrices: []float64{1, 3, 4},
+			wantGap:    true,
+		},
+		{
+			name:       "trades no longer served",
+			rest:       map[string]string{missed: "[" + tradeRow(3) + "]"},
+			wantPrices: []float64{1, 3, 4},
+			wantGap:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &standIn{
+				rest:     tt.rest,
+				messages: []string{tradeFrame(1)},
+				resumed:  []string{tradeFrame(3), tradeFrame(4)},
+			}
+			client := NewClient("btcusdt", WithEndpoints(s.start(t)))
+			ch := client.Subscribe()
+			gaps := client.SubscribeGaps()
+			if err := client.Connect(context.Background()); err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer client.Close()
+
+			var prices []float64
+			for len(prices) < len(tt.wantPrices) {
+				select {
+				case u := <-ch:
+					prices = append(prices, u.Price)
+				case <-time.After(5 * time.Second):
+					t.Fatalf("received %v, want %v", prices, tt.wantPrices)
+				}
+			}
+			for i, want := range tt.wantPrices {
+				if prices[i] != want {
+					t.Fatalf("received %v, want %v", prices, tt.wantPrices)
+				}
+			}
+
+			select {
+			case gap := <-gaps:
+				if !tt.wantGap {
+					t.Errorf("unexpected gap %+v", gap)
+				} else if gap.Symbol != "BTCUSDT" || !gap.From.Equal(tradeTime.Add(time.Second)) || gap.Err == nil {
+					t.Errorf("gap = %+v, want one from trade 1", gap)
+				}
+			default:
+				if tt.wantGap {
+					t.Error("no gap reported")
+				}
+			}
+		})
+	}
+}
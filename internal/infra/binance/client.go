@@ -1,148 +1,308 @@
 package binance
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math"
 	"math/rand"
 	"net/http"
-	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	"github.com/rp4ri/quantacode/internal/infra/simulator"
 )
 
 // Kline represents a single candlestick from Binance.
-type Kline struct {
-	OpenTime  time.Time
-	Open      float64
-	High      float64
-	Low       float64
-	Close     float64
-	Volume    float64
-	CloseTime time.Time
-}
+type Kline = exchange.Bar
 
 // PriceUpdate represents a price tick from Binance.
-type PriceUpdate struct {
-	Symbol    string
-	Price     float64
-	Volume    float64
-	Timestamp time.Time
+// For trade ticks (IsTrade) Volume is the traded quantity; for miniTicker
+// updates it is the rolling 24h base volume.
+type PriceUpdate = exchange.Tick
+
+// Endpoint is one Binance deployment: the base URL of its combined stream
+// websocket and of its REST API.
+type Endpoint struct {
+	Stream string
+	REST   string
+}
+
+// defaultEndpoints are tried in order. binance.com comes first for its
+// liquidity; binance.us serves regions where .com is blocked.
+var defaultEndpoints = []Endpoint{
+	{Stream: "wss://stream.binance.com:9443/stream", REST: "https://api.binance.com"},
+	{Stream: "wss://stream.binance.us:9443/stream", REST: "https://api.binance.us"},
 }
 
 // Client manages WebSocket connection to Binance.
 type Client struct {
 	symbol      string
+	endpoints   []Endpoint
 	conn        *websocket.Conn
+	endpoint    Endpoint // deployment of conn
 	mu          sync.Mutex
-	subscribers []chan PriceUpdate
+	subscribers exchange.Subscribers[PriceUpdate]
 	subMu       sync.RWMutex
+	closed      bool // subscriber channels have been closed
 	done        chan struct{}
 	simulate    bool
+	simulation  simulator.Config
+	replay      *replayConfig // replays a recording instead of connecting
+	recorder    *Recorder     // optional, records every received frame
+
+	// Order book state, guarded by subMu. depthEvents is created by the first
+	// SubscribeDepth; ctx is set by Connect.
+	depthSubscribers exchange.Subscribers[DepthUpdate]
+	depthEvents      chan depthEvent
+	ctx              context.Context
+
+	gapSubscribers exchange.Subscribers[exchange.Gap] // guarded by subMu
+
+	// Connection state. statusMu serialises state changes; status subscribers
+	// are guarded by subMu.
+	statusMu          sync.Mutex
+	status            exchange.Status
+	statusSubscribers exchange.Subscribers[exchange.Status]
+	lastMessage       atomic.Int64 // Unix nanoseconds of the last frame
+	degraded          atomic.Bool  // status.State is Degraded, read on every frame
+	staleAfter        time.Duration
+}
+
+var (
+	_ exchange.MarketDataSource = (*Client)(nil)
+	_ exchange.DepthSource      = (*Client)(nil)
+	_ exchange.GapSource        = (*Client)(nil)
+	_ exchange.StatusSource     = (*Client)(nil)
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithEndpoints replaces the Binance deployments the client connects to, for
+// instance to point it at a local stand-in.
+func WithEndpoints(endpoints ...Endpoint) Option {
+	return func(c *Client) { c.endpoints = endpoints }
+}
+
+// WithRecorder records every frame the client receives to r.
+func WithRecorder(r *Recorder) Option {
+	return func(c *Client) { c.recorder = r }
+}
+
+// WithSimulation makes the client simulate its market with cfg instead of
+// connecting. A zero cfg.Start starts the simulation when the client connects.
+func WithSimulation(cfg simulator.Config) Option {
+	return func(c *Client) { c.simulate, c.simulation = true, cfg }
 }
 
 // NewClient creates a new Binance WebSocket client.
-func NewClient(symbol string) *Client {
-	return &Client{
-		symbol: symbol,
-		done:   make(chan struct{}),
+func NewClient(symbol string, opts ...Option) *Client {
+	c := &Client{
+		symbol:     symbol,
+		endpoints:  defaultEndpoints,
+		done:       make(chan struct{}),
+		staleAfter: staleAfter,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
-// NewSimulatedClient creates a client that generates fake price data.
-func NewSimulatedClient(symbol string) *Client {
+// NewSimulatedClient creates a client that trades a simulated market, one tick
+// a second by default. The market is deterministic for each symbol; see
+// simulator.DefaultConfig and WithSimulation. History is still fetched from
+// Binance.
+func NewSimulatedClient(symbol string, opts ...Option) *Client {
+	return NewClient(symbol, append([]Option{WithSimulation(simulator.DefaultConfig(symbol))}, opts...)...)
+}
+
+// replayConfig selects a recording and the speed to replay it at, 0 for max.
+type replayConfig struct {
+	path  string
+	speed float64
+}
+
+// NewReplayClient creates a client that replays the frames of symbol recorded
+// in path by a Recorder instead of connecting. speed scales the recorded pace;
+// 0 replays as fast as subscribers allow. See ParseSpeed.
+func NewReplayClient(symbol, path string, speed float64) *Client {
 	return &Client{
-		symbol:   symbol,
-		done:     make(chan struct{}),
-		simulate: true,
+		symbol:    symbol,
+		endpoints: defaultEndpoints,
+		done:      make(chan struct{}),
+		replay:    &replayConfig{path: path, speed: speed},
+	}
+}
+
+// Replaying reports whether the client replays a recording.
+func (c *Client) Replaying() bool {
+	return c.replay != nil
+}
+
+// Subscribe adds a subscriber channel for price updates. By default it buffers
+// exchange.DefaultBufferSize updates and drops new ones while full; see
+// exchange.WithPolicy. The channel is closed by Unsubscribe, by the Disconnect
+// policy or by Close.
+func (c *Client) Subscribe(opts ...exchange.SubscribeOption) <-chan PriceUpdate {
+	sub := exchange.NewSubscriber[PriceUpdate]("binance "+c.symbol, exchange.DropNewest, opts)
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closed {
+		sub.Close()
+		return sub.C()
 	}
+	c.subscribers = append(c.subscribers, sub)
+	return sub.C()
 }
 
-// Subscribe adds a subscriber channel for price updates.
-func (c *Client) Subscribe() <-chan PriceUpdate {
-	ch := make(chan PriceUpdate, 100)
+// Unsubscribe removes a subscriber and closes its channel. Unknown channels are ignored.
+func (c *Client) Unsubscribe(ch <-chan PriceUpdate) {
 	c.subMu.Lock()
-	c.subscribers = append(c.subscribers, ch)
-	c.subMu.Unlock()
-	return ch
+	defer c.subMu.Unlock()
+	if i := c.subscribers.Find(ch); i >= 0 {
+		c.subscribers.Remove(i)
+	}
+}
+
+// Stats returns the delivery metrics of the subscriber channel ch.
+func (c *Client) Stats(ch <-chan PriceUpdate) (exchange.SubscriberStats, bool) {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	if i := c.subscribers.Find(ch); i >= 0 {
+		return c.subscribers[i].Stats(), true
+	}
+	return exchange.SubscriberStats{}, false
 }
 
 // Connect establishes WebSocket connection and starts reading.
-// Tries binance.us first (for US servers), then falls back to binance.com
+// It tries the client's endpoints in order until one accepts the connection.
 func (c *Client) Connect(ctx context.Context) error {
 	if c.simulate {
+		c.setState(exchange.Live, "simulator", nil)
+		c.started(ctx)
 		go c.simulateLoop(ctx)
 		return nil
 	}
+	if c.replay != nil {
+		rec, err := openRecording(c.replay.path)
+		if err != nil {
+			c.setState(exchange.Failed, "", err)
+			return err
+		}
+		log.Printf("replaying %s from %s", c.symbol, c.replay.path)
+		c.setState(exchange.Live, "replay of "+c.replay.path, nil)
+		go c.replayLoop(ctx, rec)
+		c.started(ctx)
+		return nil
+	}
+
+	conn, endpoint, err := c.dial(ctx)
+	if err != nil {
+		err = fmt.Errorf("connect to binance (tried all endpoints): %w", err)
+		c.setState(exchange.Failed, "", err)
+		return err
+	}
+	c.mu.Lock()
+	c.conn, c.endpoint = conn, endpoint
+	c.mu.Unlock()
+	log.Printf("connected to binance via %s", endpoint.Stream)
+	c.setState(exchange.Live, endpoint.Stream, nil)
+	go c.readLoop(ctx)
+	c.started(ctx)
+	return nil
+}
 
+// dial opens the combined stream on the first endpoint that accepts it.
+// Use combined stream for more frequent updates: miniTicker (1s) + aggTrade (every trade)
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, Endpoint, error) {
 	dialer := websocket.Dialer{
 		HandshakeTimeout: 10 * time.Second,
 	}
-
 	header := make(map[string][]string)
 	header["User-Agent"] = []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"}
 
-	// Try binance.com first (more liquidity/trades), then binance.us
-	// Use combined stream for more frequent updates: miniTicker (1s) + aggTrade (every trade)
-	endpoints := []string{
-		"stream.binance.com:9443",
-		"stream.binance.us:9443",
-	}
-
 	var lastErr error
-	for _, host := range endpoints {
-		wsURL := url.URL{
-			Scheme:   "wss",
-			Host:     host,
-			Path:     "/stream",
-			RawQuery: fmt.Sprintf("streams=%s@miniTicker/%s@aggTrade", c.symbol, c.symbol),
-		}
-
-		conn, _, err := dialer.DialContext(ctx, wsURL.String(), header)
+	for _, endpoint := range c.endpoints {
+		conn, _, err := dialer.DialContext(ctx, endpoint.Stream+"?streams="+c.streams(), header)
 		if err == nil {
-			c.conn = conn
-			log.Printf("connected to binance via %s", host)
-			go c.readLoop(ctx)
-			return nil
+			return conn, endpoint, nil
 		}
 		lastErr = err
-		log.Printf("failed to connect to %s: %v, trying next...", host, err)
+		log.Printf("failed to connect to %s: %v, trying next...", endpoint.Stream, err)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no endpoints configured")
+	}
+	return nil, Endpoint{}, lastErr
+}
+
+// started records the connection context and starts the order book if a
+// depth subscriber came first.
+func (c *Client) started(ctx context.Context) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	c.ctx = ctx
+	if c.depthEvents != nil {
+		go c.startDepth(ctx)
 	}
+}
 
-	return fmt.Errorf("connect to binance (tried all endpoints): %w", lastErr)
+// streams returns the combined stream names of the connection.
+func (c *Client) streams() string {
+	streams := fmt.Sprintf("%s@miniTicker/%s@aggTrade", c.symbol, c.symbol)
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	if c.depthEvents != nil {
+		streams += "/" + c.symbol + "@depth@100ms"
+	}
+	return streams
 }
 
+// simulateLoop broadcasts the simulated market's ticks, the first one right
+// away and the others paced by the simulation's step.
 func (c *Client) simulateLoop(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Second)
+	cfg := c.simulation
+	if cfg.Start.IsZero() {
+		cfg.Start = time.Now()
+	}
+	sim := simulator.New(c.symbol, cfg)
+	rng := rand.New(rand.NewSource(cfg.Seed)) // order book quantities
+	var depthID int64
+
+	ticker := time.NewTicker(sim.Step())
 	defer ticker.Stop()
+	for {
+		update := sim.Next()
+		c.received()
+		c.broadcast(update)
 
-	price := 48000.0
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		c.subMu.RLock()
+		depth := len(c.depthSubscribers) > 0
+		c.subMu.RUnlock()
+		if depth {
+			depthID++
+			c.broadcastDepth(simulateDepth(c.symbol, update.Price, depthID, rng))
+		}
 
-	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-c.done:
 			return
 		case <-ticker.C:
-			delta := (rng.Float64()*2 - 1) * 50
-			price = math.Max(1, price+delta)
-
-			update := PriceUpdate{
-				Symbol:    strings.ToUpper(c.symbol),
-				Price:     price,
-				Volume:    rng.Float64() * 1000,
-				Timestamp: time.Now(),
-			}
-			c.broadcast(update)
 		}
 	}
 }
@@ -156,9 +316,11 @@ func (c *Client) readLoop(ctx context.Context) {
 		return nil
 	})
 
-	// Start ping ticker to keep connection alive
+	// Start ping ticker to keep connection alive, and watch for silence
 	pingTicker := time.NewTicker(20 * time.Second)
 	defer pingTicker.Stop()
+	staleTicker := time.NewTicker(c.staleAfter / 4)
+	defer staleTicker.Stop()
 
 	go func() {
 		for {
@@ -173,10 +335,14 @@ func (c *Client) readLoop(ctx context.Context) {
 					c.conn.WriteMessage(websocket.PingMessage, nil)
 				}
 				c.mu.Unlock()
+			case <-staleTicker.C:
+				c.checkStale()
 			}
 		}
 	}()
 
+	var recordFailing bool
+	var last tradeCursor
 	for {
 		select {
 		case <-ctx.Done():
@@ -193,15 +359,47 @@ func (c *Client) readLoop(ctx context.Context) {
 				return
 			}
 			log.Printf("binance read error: %v, reconnecting...", err)
+			c.setState(exchange.Reconnecting, "", err)
 			c.reconnect(ctx)
+			last = c.backfill(ctx, last)
+			continue
+		}
+
+		c.received()
+
+		if c.recorder != nil {
+			// Log only the first of a run of recording failures
+			err := c.recorder.Record(time.Now(), message)
+			if err != nil && !recordFailing {
+				log.Printf("binance: failed to record %s frame: %v", c.symbol, err)
+			}
+			recordFailing = err != nil
+		}
+
+		if isDepthMessage(message) {
+			event, err := parseDepthEvent(message)
+			if err != nil {
+				log.Printf("parse depth error: %v", err)
+				continue
+			}
+			c.handleDepth(event)
 			continue
 		}
+		if bytes.HasPrefix(message, []byte(`{"result"`)) {
+			continue // reply to the depth SUBSCRIBE request
+		}
 
-		update, err := parseCombinedStream(message)
+		update, tradeID, err := parseStreamFrame(message)
 		if err != nil {
 			log.Printf("parse stream error: %v", err)
 			continue
 		}
+		if tradeID != 0 {
+			if tradeID <= last.id {
+				continue // already delivered by a backfill
+			}
+			last = tradeCursor{id: tradeID, time: update.Timestamp}
+		}
 
 		c.broadcast(update)
 	}
@@ -209,39 +407,32 @@ func (c *Client) readLoop(ctx context.Context) {
 
 func (c *Client) broadcast(update PriceUpdate) {
 	c.subMu.RLock()
-	defer c.subMu.RUnlock()
+	disconnect := c.subscribers.Broadcast(update)
+	c.subMu.RUnlock()
 
-	for _, ch := range c.subscribers {
-		select {
-		case ch <- update:
-		default:
-			// drop if channel full
-		}
+	if len(disconnect) == 0 {
+		return
 	}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	for _, sub := range disconnect {
+		log.Printf("binance: disconnected slow %s subscriber after %d updates", c.symbol, sub.Stats().Delivered)
+	}
+	c.subscribers.Disconnect(disconnect)
 }
 
+// reconnect dials until a connection is up or the client stops. c.mu is only
+// held to swap connections, so Close does not wait out the backoff.
 func (c *Client) reconnect(ctx context.Context) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if c.conn != nil {
 		c.conn.Close()
 	}
+	c.mu.Unlock()
 
 	backoff := time.Second
 	maxBackoff := 30 * time.Second
 
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-	header := make(map[string][]string)
-	header["User-Agent"] = []string{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"}
-
-	endpoints := []string{
-		"stream.binance.com:9443",
-		"stream.binance.us:9443",
-	}
-
 	for {
 		select {
 		case <-ctx.Done():
@@ -251,31 +442,35 @@ func (c *Client) reconnect(ctx context.Context) {
 		default:
 		}
 
-		var lastErr error
-		for _, host := range endpoints {
-			wsURL := url.URL{
-				Scheme:   "wss",
-				Host:     host,
-				Path:     "/stream",
-				RawQuery: fmt.Sprintf("streams=%s@miniTicker/%s@aggTrade", c.symbol, c.symbol),
-			}
-
-			var err error
-			c.conn, _, err = dialer.DialContext(ctx, wsURL.String(), header)
-			if err == nil {
-				log.Printf("binance reconnected via %s", host)
+		conn, endpoint, err := c.dial(ctx)
+		if err == nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			select {
+			case <-c.done:
+				conn.Close()
 				return
+			default:
 			}
-			lastErr = err
+			c.conn, c.endpoint = conn, endpoint
+			log.Printf("binance reconnected via %s", endpoint.Stream)
+			c.setState(exchange.Live, endpoint.Stream, nil)
+			return
 		}
 
-		log.Printf("reconnect failed: %v, retrying in %v", lastErr, backoff)
-		time.Sleep(backoff)
+		log.Printf("reconnect failed: %v, retrying in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		case <-time.After(backoff):
+		}
 		backoff = time.Duration(math.Min(float64(backoff*2), float64(maxBackoff)))
 	}
 }
 
-// Close shuts down the client.
+// Close shuts down the client and closes every subscriber channel.
 func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -286,6 +481,14 @@ func (c *Client) Close() error {
 		close(c.done)
 	}
 
+	c.subMu.Lock()
+	c.subscribers.RemoveAll()
+	c.depthSubscribers.RemoveAll()
+	c.gapSubscribers.RemoveAll()
+	c.statusSubscribers.RemoveAll()
+	c.closed = true
+	c.subMu.Unlock()
+
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -316,22 +519,30 @@ type aggTradeData struct {
 	EventType string `json:"e"`
 	EventTime int64  `json:"E"`
 	Symbol    string `json:"s"`
+	TradeID   int64  `json:"a"`
 	Price     string `json:"p"`
 	Quantity  string `json:"q"`
 	TradeTime int64  `json:"T"`
 }
 
 func parseCombinedStream(data []byte) (PriceUpdate, error) {
+	update, _, err := parseStreamFrame(data)
+	return update, err
+}
+
+// parseStreamFrame parses a miniTicker or aggTrade frame, also returning the
+// aggregate trade id of trades and 0 for ticker updates.
+func parseStreamFrame(data []byte) (PriceUpdate, int64, error) {
 	var wrapper combinedStreamWrapper
 	if err := json.Unmarshal(data, &wrapper); err != nil {
-		return PriceUpdate{}, fmt.Errorf("unmarshal wrapper: %w", err)
+		return PriceUpdate{}, 0, fmt.Errorf("unmarshal wrapper: %w", err)
 	}
 
 	// Determine stream type from stream name
 	if strings.Contains(wrapper.Stream, "@miniTicker") {
 		var msg miniTickerData
 		if err := json.Unmarshal(wrapper.Data, &msg); err != nil {
-			return PriceUpdate{}, fmt.Errorf("unmarshal miniTicker: %w", err)
+			return PriceUpdate{}, 0, fmt.Errorf("unmarshal miniTicker: %w", err)
 		}
 		price, _ := strconv.ParseFloat(msg.ClosePrice, 64)
 		volume, _ := strconv.ParseFloat(msg.BaseVolume, 64)
@@ -344,13 +555,13 @@ func parseCombinedStream(data []byte) (PriceUpdate, error) {
 			Price:     price,
 			Volume:    volume,
 			Timestamp: timestamp,
-		}, nil
+		}, 0, nil
 	}
 
 	if strings.Contains(wrapper.Stream, "@aggTrade") {
 		var msg aggTradeData
 		if err := json.Unmarshal(wrapper.Data, &msg); err != nil {
-			return PriceUpdate{}, fmt.Errorf("unmarshal aggTrade: %w", err)
+			return PriceUpdate{}, 0, fmt.Errorf("unmarshal aggTrade: %w", err)
 		}
 		price, _ := strconv.ParseFloat(msg.Price, 64)
 		quantity, _ := strconv.ParseFloat(msg.Quantity, 64)
@@ -363,19 +574,115 @@ func parseCombinedStream(data []byte) (PriceUpdate, error) {
 			Price:     price,
 			Volume:    quantity,
 			Timestamp: timestamp,
-		}, nil
+			IsTrade:   true,
+		}, msg.TradeID, nil
 	}
 
-	return PriceUpdate{}, fmt.Errorf("unknown stream type: %s", wrapper.Stream)
+	return PriceUpdate{}, 0, fmt.Errorf("unknown stream type: %s", wrapper.Stream)
+}
+
+// MaxKlines is the most candles one klines request returns; larger limits are
+// reduced to it.
+const MaxKlines = 1000
+
+// validIntervals lists the kline intervals supported by the Binance REST and WebSocket APIs.
+var validIntervals = map[string]bool{
+	"1s": true, "1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
+	"1h": true, "2h": true, "4h": true, "6h": true, "8h": true, "12h": true,
+	"1d": true, "3d": true, "1w": true,
+}
+
+// ValidInterval reports whether interval is a kline interval Binance accepts.
+func ValidInterval(interval string) bool {
+	return validIntervals[interval]
+}
+
+// FetchBars fetches the client's symbol's candles from the first endpoint that
+// answers. See FetchKlinesRange.
+func (c *Client) FetchBars(ctx context.Context, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, MaxKlines)
+	var lastErr error
+	for _, endpoint := range c.endpoints {
+		klines, err := fetchKlinesFromEndpoint(ctx, endpoint.REST+"/api/v3/klines", c.symbol, interval, start, end, limit)
+		if err == nil {
+			return klines, nil
+		}
+		lastErr = err
+		log.Printf("klines fetch from %s failed: %v, trying next", endpoint.REST, err)
+	}
+	return nil, fmt.Errorf("all kline endpoints failed: %v", lastErr)
+}
+
+// Symbols lists the symbols currently trading on the first endpoint that answers.
+func (c *Client) Symbols(ctx context.Context) ([]string, error) {
+	var lastErr error
+	for _, endpoint := range c.endpoints {
+		symbols, err := fetchSymbolsFromEndpoint(ctx, endpoint.REST+"/api/v3/exchangeInfo")
+		if err == nil {
+			return symbols, nil
+		}
+		lastErr = err
+		log.Printf("exchange info fetch from %s failed: %v, trying next", endpoint.REST, err)
+	}
+	return nil, fmt.Errorf("all exchange info endpoints failed: %v", lastErr)
+}
+
+// exchangeInfoData is the part of the REST exchange info response Symbols uses.
+type exchangeInfoData struct {
+	Symbols []struct {
+		Symbol string `json:"symbol"`
+		Status string `json:"status"`
+	} `json:"symbols"`
+}
+
+func fetchSymbolsFromEndpoint(ctx context.Context, reqURL string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var data exchangeInfoData
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode exchange info: %w", err)
+	}
+	symbols := make([]string, 0, len(data.Symbols))
+	for _, s := range data.Symbols {
+		if s.Status == "TRADING" {
+			symbols = append(symbols, strings.ToLower(s.Symbol))
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
 }
 
 // FetchKlines fetches historical candlestick data from Binance REST API.
 // interval: 1m, 5m, 15m, 30m, 1h, 4h, 1d, etc.
-// limit: number of candles to fetch (max 1000)
+// limit: number of candles to fetch (at most MaxKlines)
 func FetchKlines(ctx context.Context, symbol, interval string, limit int) ([]Kline, error) {
-	if limit <= 0 || limit > 1000 {
+	return FetchKlinesRange(ctx, symbol, interval, time.Time{}, time.Time{}, limit)
+}
+
+// FetchKlinesRange fetches up to limit candles opening between start and end,
+// oldest first. A zero start or end leaves that side of the range open.
+func FetchKlinesRange(ctx context.Context, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
+	if limit <= 0 {
 		limit = 50
 	}
+	limit = min(limit, MaxKlines)
 
 	// Try binance.us first, then binance.com
 	endpoints := []string{
@@ -385,7 +692,7 @@ func FetchKlines(ctx context.Context, symbol, interval string, limit int) ([]Kli
 
 	var lastErr error
 	for _, baseURL := range endpoints {
-		klines, err := fetchKlinesFromEndpoint(ctx, baseURL, symbol, interval, limit)
+		klines, err := fetchKlinesFromEndpoint(ctx, baseURL, symbol, interval, start, end, limit)
 		if err == nil {
 			return klines, nil
 		}
@@ -396,9 +703,15 @@ func FetchKlines(ctx context.Context, symbol, interval string, limit int) ([]Kli
 	return nil, fmt.Errorf("all kline endpoints failed: %v", lastErr)
 }
 
-func fetchKlinesFromEndpoint(ctx context.Context, baseURL, symbol, interval string, limit int) ([]Kline, error) {
+func fetchKlinesFromEndpoint(ctx context.Context, baseURL, symbol, interval string, start, end time.Time, limit int) ([]Kline, error) {
 	reqURL := fmt.Sprintf("%s?symbol=%s&interval=%s&limit=%d",
 		baseURL, strings.ToUpper(symbol), interval, limit)
+	if !start.IsZero() {
+		reqURL += fmt.Sprintf("&startTime=%d", start.UnixMilli())
+	}
+	if !end.IsZero() {
+		reqURL += fmt.Sprintf("&endTime=%d", end.UnixMilli())
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
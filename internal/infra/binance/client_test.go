@@ -1,8 +1,19 @@
 package binance
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+	"github.com/rp4ri/quantacode/internal/infra/simulator"
 )
 
 func TestParseCombinedStream(t *testing.T) {
@@ -12,6 +23,7 @@ func TestParseCombinedStream(t *testing.T) {
 		wantPrice float64
 		wantVol   float64
 		wantSym   string
+		wantTrade bool
 		wantErr   bool
 	}{
 		{
@@ -28,6 +40,7 @@ func TestParseCombinedStream(t *testing.T) {
 			wantPrice: 2500.00,
 			wantVol:   0.5,
 			wantSym:   "ETHUSDT",
+			wantTrade: true,
 			wantErr:   false,
 		},
 		{
@@ -69,6 +82,9 @@ func TestParseCombinedStream(t *testing.T) {
 			if got.Symbol != tt.wantSym {
 				t.Errorf("parseCombinedStream() Symbol = %v, want %v", got.Symbol, tt.wantSym)
 			}
+			if got.IsTrade != tt.wantTrade {
+				t.Errorf("parseCombinedStream() IsTrade = %v, want %v", got.IsTrade, tt.wantTrade)
+			}
 		})
 	}
 }
@@ -99,6 +115,44 @@ func TestNewSimulatedClient(t *testing.T) {
 	}
 }
 
+func TestSimulatedClientIsDeterministic(t *testing.T) {
+	sc, err := simulator.ParseScenario(strings.NewReader("drop 50% at 0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func() []float64 {
+		cfg := simulator.DefaultConfig("btcusdt")
+		cfg.Step, cfg.Scenario = 10*time.Millisecond, sc
+		client := NewSimulatedClient("btcusdt", WithSimulation(cfg))
+		ch := client.Subscribe()
+		if err := client.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+
+		var prices []float64
+		for len(prices) < 5 {
+			select {
+			case u := <-ch:
+				prices = append(prices, u.Price)
+			case <-time.After(3 * time.Second):
+				t.Fatalf("simulated %v, want 5 ticks", prices)
+			}
+		}
+		return prices
+	}
+
+	first, second := run(), run()
+	if first[0] != 48000 || first[1] > 25000 {
+		t.Errorf("prices = %v, want 48000 halved by the scenario", first)
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("runs differ: %v and %v", first, second)
+		}
+	}
+}
+
 func TestSubscribe(t *testing.T) {
 	client := NewSimulatedClient("btcusdt")
 	ch := client.Subscribe()
@@ -149,3 +203,257 @@ func TestBroadcast(t *testing.T) {
 		t.Error("ch2 did not receive broadcast")
 	}
 }
+
+func TestBackpressurePolicies(t *testing.T) {
+	client := NewSimulatedClient("btcusdt")
+	newest := client.Subscribe(exchange.WithBufferSize(2))
+	oldest := client.Subscribe(exchange.WithBufferSize(2), exchange.WithPolicy(exchange.DropOldest))
+	blocked := client.Subscribe(exchange.WithBufferSize(2), exchange.WithPolicy(exchange.Block), exchange.WithBlockTimeout(10*time.Millisecond))
+	dropped := client.Subscribe(exchange.WithBufferSize(2), exchange.WithPolicy(exchange.Disconnect))
+
+	for i := 1; i <= 4; i++ {
+		client.broadcast(PriceUpdate{Price: float64(i)})
+	}
+
+	drain := func(ch <-chan PriceUpdate) []float64 {
+		var prices []float64
+		for {
+			select {
+			case u, ok := <-ch:
+				if !ok {
+					return prices
+				}
+				prices = append(prices, u.Price)
+			default:
+				return prices
+			}
+		}
+	}
+	for name, tc := range map[string]struct {
+		ch                 <-chan PriceUpdate
+		want               []float64
+		delivered, dropped uint64
+	}{
+		"drop-newest": {newest, []float64{1, 2}, 2, 2},
+		"drop-oldest": {oldest, []float64{3, 4}, 4, 2}, // 1 and 2 were evicted
+		"block":       {blocked, []float64{1, 2}, 2, 2},
+	} {
+		stats, ok := client.Stats(tc.ch)
+		if !ok || stats.Delivered != tc.delivered || stats.Dropped != tc.dropped {
+			t.Errorf("%s: Stats() = %+v, %v", name, stats, ok)
+		}
+		got := drain(tc.ch)
+		if len(got) != len(tc.want) || got[0] != tc.want[0] || got[1] != tc.want[1] {
+			t.Errorf("%s: received %v, want %v", name, got, tc.want)
+		}
+	}
+
+	if got := drain(dropped); len(got) != 2 {
+		t.Errorf("disconnect: received %v before the channel closed, want 2 updates", got)
+	}
+	if _, ok := <-dropped; ok {
+		t.Error("disconnect: channel should be closed")
+	}
+	if _, ok := client.Stats(dropped); ok {
+		t.Error("disconnect: subscriber should be removed")
+	}
+}
+
+func TestBlockPolicyWaitsForConsumer(t *testing.T) {
+	client := NewSimulatedClient("btcusdt")
+	ch := client.Subscribe(exchange.WithBufferSize(1), exchange.WithPolicy(exchange.Block), exchange.WithBlockTimeout(time.Second))
+	client.broadcast(PriceUpdate{Price: 1})
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-ch
+	}()
+	client.broadcast(PriceUpdate{Price: 2})
+
+	if stats, _ := client.Stats(ch); stats.Dropped != 0 || stats.Delivered != 2 {
+		t.Errorf("Stats() = %+v, want both updates delivered", stats)
+	}
+}
+
+func TestUnsubscribeAndClose(t *testing.T) {
+	client := NewSimulatedClient("btcusdt")
+	ch1 := client.Subscribe()
+	ch2 := client.Subscribe()
+
+	client.Unsubscribe(ch1)
+	client.Unsubscribe(ch1) // must be idempotent
+	if _, ok := <-ch1; ok {
+		t.Error("Unsubscribe() should close the channel")
+	}
+	if len(client.subscribers) != 1 {
+		t.Errorf("subscribers = %d after Unsubscribe, want 1", len(client.subscribers))
+	}
+	client.broadcast(PriceUpdate{Price: 1})
+
+	client.Close()
+	if u, ok := <-ch2; !ok || u.Price != 1 {
+		t.Error("buffered update should survive Close")
+	}
+	if _, ok := <-ch2; ok {
+		t.Error("Close() should close every subscriber channel")
+	}
+	if _, ok := <-client.Subscribe(); ok {
+		t.Error("Subscribe() after Close() should return a closed channel")
+	}
+}
+
+func TestFetchKlinesFromEndpointRange(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`[[1709251200000,"100.5","101","99","100.75","12.5",1709254799999]]`))
+	}))
+	defer srv.Close()
+
+	start := time.UnixMilli(1709251200000)
+	klines, err := fetchKlinesFromEndpoint(context.Background(), srv.URL, "btcusdt", "1h", start, start.Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("fetchKlinesFromEndpoint() error = %v", err)
+	}
+	if query.Get("symbol") != "BTCUSDT" || query.Get("startTime") != "1709251200000" || query.Get("endTime") != "1709254800000" || query.Get("limit") != "10" {
+		t.Errorf("query = %v", query)
+	}
+	if len(klines) != 1 || klines[0].Close != 100.75 || !klines[0].OpenTime.Equal(start) {
+		t.Errorf("klines = %+v", klines)
+	}
+}
+
+// standIn serves the parts of the Binance stream and REST APIs the client
+// uses. Each websocket connection receives messages, then onMessage is called
+// with every message the client writes, its return values sent back.
+type standIn struct {
+	rest      map[string]string // REST path, or path and query, to JSON response
+	messages  []string
+	resumed   []string // messages of later connections; when set the first one hangs up after its messages
+	refuse    bool     // refuse connections after the first, which hangs up after its messages
+	onMessage func(msg string) []string
+	streams   chan string // streams query of each connection
+
+	mu    sync.Mutex
+	conns int
+}
+
+func (s *standIn) start(t *testing.T) Endpoint {
+	s.streams = make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/stream" {
+			body, ok := s.rest[r.URL.RequestURI()]
+			if !ok {
+				body, ok = s.rest[r.URL.Path]
+			}
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(body))
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		first := s.conns == 1
+		s.mu.Unlock()
+		if !first && s.refuse {
+			http.Error(w, "refused", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		s.streams <- r.URL.Query().Get("streams")
+		write := func(msgs []string) bool {
+			for _, m := range msgs {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+					return false
+				}
+			}
+			return true
+		}
+		messages := s.messages
+		if !first && s.resumed != nil {
+			messages = s.resumed
+		}
+		if !write(messages) || first && (s.resumed != nil || s.refuse) {
+			return
+		}
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if s.onMessage != nil && !write(s.onMessage(string(msg))) {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return Endpoint{Stream: "ws" + strings.TrimPrefix(srv.URL, "http") + "/stream", REST: srv.URL}
+}
+
+func TestClientAgainstStandIn(t *testing.T) {
+	s := &standIn{
+		rest: map[string]string{
+			"/api/v3/klines":       `[[1709251200000,"100.5","101","99","100.75","12.5",1709254799999]]`,
+			"/api/v3/exchangeInfo": `{"symbols":[{"symbol":"ETHUSDT","status":"TRADING"},{"symbol":"BTCUSDT","status":"TRADING"},{"symbol":"LUNAUSDT","status":"BREAK"}]}`,
+		},
+		messages: []string{`{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","s":"BTCUSDT","p":"50000.5","q":"0.25","T":1709251200000}}`},
+	}
+	unreachable := Endpoint{Stream: "ws://127.0.0.1:1/stream", REST: "http://127.0.0.1:1"}
+	client := NewClient("btcusdt", WithEndpoints(unreachable, s.start(t)))
+	ch := client.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	if streams := <-s.streams; streams != "btcusdt@miniTicker/btcusdt@aggTrade" {
+		t.Errorf("streams = %q", streams)
+	}
+	select {
+	case u := <-ch:
+		if u.Symbol != "BTCUSDT" || u.Price != 50000.5 || u.Volume != 0.25 || !u.IsTrade {
+			t.Errorf("tick = %+v", u)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no tick from the stand-in")
+	}
+
+	bars, err := client.FetchBars(ctx, "1h", time.Time{}, time.Time{}, 10)
+	if err != nil || len(bars) != 1 || bars[0].Close != 100.75 {
+		t.Errorf("FetchBars() = %+v, %v", bars, err)
+	}
+	symbols, err := client.Symbols(ctx)
+	if err != nil || strings.Join(symbols, ",") != "btcusdt,ethusdt" {
+		t.Errorf("Symbols() = %v, %v", symbols, err)
+	}
+}
+
+func TestCloseDuringReconnectBackoff(t *testing.T) {
+	s := &standIn{messages: []string{tradeFrame(1)}, refuse: true}
+	client := NewClient("btcusdt", WithEndpoints(s.start(t)))
+	statuses := client.SubscribeStatus()
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for status := nextStatus(t, statuses); status.State != exchange.Reconnecting; status = nextStatus(t, statuses) {
+	}
+
+	// Past the first retry, the client waits two seconds before the next one
+	time.Sleep(1200 * time.Millisecond)
+	start := time.Now()
+	client.Close()
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("Close() took %v, want it not to wait out the backoff", waited)
+	}
+}
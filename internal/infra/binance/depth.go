@@ -0,0 +1,283 @@
+package binance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/orderbook"
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+const (
+	// DepthLevels is the number of levels per side in a DepthUpdate.
+	DepthLevels = 20
+
+	depthSnapshotLimit = 1000
+	depthEventBuffer   = 1000
+)
+
+// DepthUpdate is the top of a symbol's order book after a diff was applied.
+type DepthUpdate = exchange.DepthUpdate
+
+// depthEvent is one message of the @depth diff stream.
+type depthEvent struct {
+	diff orderbook.Diff
+	time time.Time
+}
+
+// SubscribeDepth adds a subscriber channel for order book updates. The first
+// call subscribes to the @depth diff stream and starts keeping a local book in
+// sync with REST snapshots. By default the oldest buffered update is dropped
+// when the channel is full; see exchange.WithPolicy.
+func (c *Client) SubscribeDepth(opts ...exchange.SubscribeOption) <-chan DepthUpdate {
+	sub := exchange.NewSubscriber[DepthUpdate]("binance "+c.symbol+" depth", exchange.DropOldest, opts)
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closed {
+		sub.Close()
+		return sub.C()
+	}
+	c.depthSubscribers = append(c.depthSubscribers, sub)
+	if c.depthEvents == nil {
+		c.depthEvents = make(chan depthEvent, depthEventBuffer)
+		if c.ctx != nil {
+			go c.startDepth(c.ctx)
+		}
+	}
+	return sub.C()
+}
+
+// UnsubscribeDepth removes an order book subscriber and closes its channel.
+func (c *Client) UnsubscribeDepth(ch <-chan DepthUpdate) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if i := c.depthSubscribers.Find(ch); i >= 0 {
+		c.depthSubscribers.Remove(i)
+	}
+}
+
+// startDepth subscribes the live connection to the diff stream and keeps the
+// book in sync. It runs once, from Connect or the first SubscribeDepth.
+func (c *Client) startDepth(ctx context.Context) {
+	if c.simulate {
+		return // simulateLoop publishes books itself
+	}
+	if c.replay != nil {
+		return // recordings hold no snapshots to rebuild books from
+	}
+	c.mu.Lock()
+	if c.conn != nil {
+		msg := map[string]any{"method": "SUBSCRIBE", "params": []string{c.symbol + "@depth@100ms"}, "id": 1}
+		if err := c.conn.WriteJSON(msg); err != nil {
+			log.Printf("binance: failed to subscribe to %s depth: %v", c.symbol, err)
+		}
+	}
+	c.mu.Unlock()
+	c.syncDepth(ctx)
+}
+
+// syncDepth rebuilds the book from a REST snapshot whenever the diff sequence
+// breaks, as after a reconnect or a dropped event.
+func (c *Client) syncDepth(ctx context.Context) {
+	backoff := time.Second
+	for {
+		c.mu.Lock()
+		// Snapshot and diff update ids only match on the same deployment
+		endpoint := c.endpoint
+		c.mu.Unlock()
+
+		book, err := fetchDepthFromEndpoint(ctx, endpoint.REST+"/api/v3/depth", c.symbol, depthSnapshotLimit)
+		if err != nil {
+			log.Printf("binance: %s depth snapshot failed: %v, retrying in %v", c.symbol, err, backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			case <-time.After(backoff):
+			}
+			backoff = time.Duration(math.Min(float64(backoff*2), float64(30*time.Second)))
+			continue
+		}
+		backoff = time.Second
+
+		if !c.followDepth(ctx, book) {
+			return
+		}
+	}
+}
+
+// followDepth applies diff events on top of book until the sequence breaks,
+// returning true, or the client shuts down, returning false.
+func (c *Client) followDepth(ctx context.Context, book *orderbook.Book) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.done:
+			return false
+		case event := <-c.depthEvents:
+			err := book.Apply(event.diff)
+			if errors.Is(err, orderbook.ErrStale) {
+				continue
+			}
+			if err != nil {
+				log.Printf("binance: %s order book out of sync (%v), fetching a new snapshot", c.symbol, err)
+				return true
+			}
+			bids, asks := book.Top(DepthLevels)
+			c.broadcastDepth(DepthUpdate{
+				Symbol:       strings.ToUpper(c.symbol),
+				Bids:         bids,
+				Asks:         asks,
+				LastUpdateID: book.LastID(),
+				Timestamp:    event.time,
+			})
+		}
+	}
+}
+
+// handleDepth queues a diff for syncDepth. When the queue is full the event is
+// dropped, which syncDepth notices as a sequence gap.
+func (c *Client) handleDepth(event depthEvent) {
+	c.subMu.RLock()
+	events := c.depthEvents
+	c.subMu.RUnlock()
+	select {
+	case events <- event:
+	default:
+	}
+}
+
+func (c *Client) broadcastDepth(update DepthUpdate) {
+	c.subMu.RLock()
+	disconnect := c.depthSubscribers.Broadcast(update)
+	c.subMu.RUnlock()
+
+	if len(disconnect) > 0 {
+		c.subMu.Lock()
+		c.depthSubscribers.Disconnect(disconnect)
+		c.subMu.Unlock()
+	}
+}
+
+// simulateDepth builds a synthetic book around price for simulated clients.
+func simulateDepth(symbol string, price float64, id int64, rng interface{ Float64() float64 }) DepthUpdate {
+	tick := math.Max(price*0.0001, 0.01)
+	update := DepthUpdate{Symbol: strings.ToUpper(symbol), LastUpdateID: id, Timestamp: time.Now()}
+	for i := 0; i < DepthLevels; i++ {
+		offset := float64(i+1) * tick
+		update.Bids = append(update.Bids, orderbook.Level{Price: price - offset, Quantity: rng.Float64() * 5})
+		update.Asks = append(update.Asks, orderbook.Level{Price: price + offset, Quantity: rng.Float64() * 5})
+	}
+	return update
+}
+
+// depthSnapshotData is the REST depth response.
+type depthSnapshotData struct {
+	LastUpdateID int64       `json:"lastUpdateId"`
+	Bids         [][2]string `json:"bids"`
+	Asks         [][2]string `json:"asks"`
+}
+
+// depthUpdateData represents diff depth event data
+type depthUpdateData struct {
+	EventType string      `json:"e"`
+	EventTime int64       `json:"E"`
+	Symbol    string      `json:"s"`
+	FirstID   int64       `json:"U"`
+	FinalID   int64       `json:"u"`
+	Bids      [][2]string `json:"b"`
+	Asks      [][2]string `json:"a"`
+}
+
+func fetchDepthFromEndpoint(ctx context.Context, baseURL, symbol string, limit int) (*orderbook.Book, error) {
+	reqURL := fmt.Sprintf("%s?symbol=%s&limit=%d", baseURL, strings.ToUpper(symbol), limit)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch depth: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("binance API error: %s - %s", resp.Status, string(body))
+	}
+
+	var data depthSnapshotData
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode depth: %w", err)
+	}
+	bids, err := parseLevels(data.Bids)
+	if err != nil {
+		return nil, err
+	}
+	asks, err := parseLevels(data.Asks)
+	if err != nil {
+		return nil, err
+	}
+	return orderbook.New(data.LastUpdateID, bids, asks), nil
+}
+
+// isDepthMessage reports whether a combined stream message is a depth diff.
+func isDepthMessage(data []byte) bool {
+	return bytes.Contains(data, []byte(`@depth`))
+}
+
+func parseDepthEvent(data []byte) (depthEvent, error) {
+	var wrapper combinedStreamWrapper
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return depthEvent{}, fmt.Errorf("unmarshal wrapper: %w", err)
+	}
+	var msg depthUpdateData
+	if err := json.Unmarshal(wrapper.Data, &msg); err != nil {
+		return depthEvent{}, fmt.Errorf("unmarshal depthUpdate: %w", err)
+	}
+	bids, err := parseLevels(msg.Bids)
+	if err != nil {
+		return depthEvent{}, err
+	}
+	asks, err := parseLevels(msg.Asks)
+	if err != nil {
+		return depthEvent{}, err
+	}
+	timestamp := time.UnixMilli(msg.EventTime)
+	if msg.EventTime == 0 {
+		timestamp = time.Now()
+	}
+	return depthEvent{
+		diff: orderbook.Diff{FirstID: msg.FirstID, FinalID: msg.FinalID, Bids: bids, Asks: asks},
+		time: timestamp,
+	}, nil
+}
+
+func parseLevels(raw [][2]string) ([]orderbook.Level, error) {
+	levels := make([]orderbook.Level, len(raw))
+	for i, l := range raw {
+		price, err := strconv.ParseFloat(l[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse level price %q: %w", l[0], err)
+		}
+		qty, err := strconv.ParseFloat(l[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse level quantity %q: %w", l[1], err)
+		}
+		levels[i] = orderbook.Level{Price: price, Quantity: qty}
+	}
+	return levels, nil
+}
@@ -0,0 +1,116 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDepthEvent(t *testing.T) {
+	msg := []byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1700000000000,"s":"BTCUSDT","U":157,"u":160,"b":[["50000.10","1.5"],["49999.00","0"]],"a":[["50001.00","2"]]}}`)
+	if !isDepthMessage(msg) {
+		t.Fatal("isDepthMessage() = false for a depth diff")
+	}
+	event, err := parseDepthEvent(msg)
+	if err != nil {
+		t.Fatalf("parseDepthEvent() error = %v", err)
+	}
+	d := event.diff
+	if d.FirstID != 157 || d.FinalID != 160 || len(d.Bids) != 2 || len(d.Asks) != 1 {
+		t.Fatalf("diff = %+v", d)
+	}
+	if d.Bids[0].Price != 50000.10 || d.Bids[0].Quantity != 1.5 || d.Bids[1].Quantity != 0 {
+		t.Errorf("bids = %+v", d.Bids)
+	}
+	if !event.time.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("time = %v", event.time)
+	}
+
+	if isDepthMessage([]byte(`{"stream":"btcusdt@aggTrade","data":{}}`)) {
+		t.Error("isDepthMessage() = true for a trade")
+	}
+	if _, err := parseDepthEvent([]byte(`{"stream":"btcusdt@depth","data":{"b":[["x","1"]]}}`)); err == nil {
+		t.Error("parseDepthEvent() should reject bad prices")
+	}
+}
+
+func TestFetchDepthFromEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("symbol") != "BTCUSDT" || q.Get("limit") != "1000" {
+			t.Errorf("query = %v", q)
+		}
+		w.Write([]byte(`{"lastUpdateId":1027024,"bids":[["4.0","431.0"]],"asks":[["4.000002","12.0"]]}`))
+	}))
+	defer srv.Close()
+
+	book, err := fetchDepthFromEndpoint(context.Background(), srv.URL, "btcusdt", depthSnapshotLimit)
+	if err != nil {
+		t.Fatalf("fetchDepthFromEndpoint() error = %v", err)
+	}
+	bids, asks := book.Top(5)
+	if book.LastID() != 1027024 || len(bids) != 1 || bids[0].Quantity != 431 || asks[0].Price != 4.000002 {
+		t.Errorf("book = %d %+v %+v", book.LastID(), bids, asks)
+	}
+}
+
+func TestDepthSyncsWithStandIn(t *testing.T) {
+	s := &standIn{
+		rest: map[string]string{
+			"/api/v3/depth": `{"lastUpdateId":100,"bids":[["99","1"]],"asks":[["101","1"]]}`,
+		},
+		onMessage: func(msg string) []string {
+			if !strings.Contains(msg, `"SUBSCRIBE"`) {
+				return nil
+			}
+			return []string{
+				`{"result":null,"id":1}`,
+				`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1700000000000,"U":95,"u":101,"b":[["100","2"]],"a":[]}}`,
+			}
+		},
+	}
+	client := NewClient("btcusdt", WithEndpoints(s.start(t)))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	ch := client.SubscribeDepth()
+	select {
+	case update := <-ch:
+		if update.LastUpdateID != 101 || len(update.Bids) != 2 || update.Bids[0].Price != 100 || update.Asks[0].Price != 101 {
+			t.Errorf("update = %+v", update)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no depth update from the stand-in")
+	}
+}
+
+func TestSimulatedDepth(t *testing.T) {
+	client := NewSimulatedClient("btcusdt")
+	ch := client.SubscribeDepth()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	select {
+	case update := <-ch:
+		if len(update.Bids) != DepthLevels || len(update.Asks) != DepthLevels || update.Bids[0].Price >= update.Asks[0].Price {
+			t.Errorf("update = %+v", update)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no depth update from the simulated client")
+	}
+
+	client.UnsubscribeDepth(ch)
+	if _, ok := <-ch; ok {
+		t.Error("UnsubscribeDepth() should close the channel")
+	}
+}
@@ -0,0 +1,290 @@
+package binance
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+// recorderFlushInterval bounds how much of a recording a crash can lose.
+const recorderFlushInterval = time.Second
+
+// Recorder writes the raw combined stream frames clients receive to a gzip
+// compressed file of JSON lines, {"t":<receive time in Unix nanoseconds>,
+// "frame":<frame>}, so `zcat file | jq` shows them. Reopening a recording
+// appends to it, first dropping the unflushed tail a crash left behind. A
+// Recorder is safe for concurrent use by several clients.
+type Recorder struct {
+	mu        sync.Mutex
+	file      *os.File
+	gz        *gzip.Writer
+	lastFlush time.Time
+}
+
+// recordedFrame is one line of a recording.
+type recordedFrame struct {
+	Time  int64           `json:"t"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+// NewRecorder opens path for recording, creating it if needed.
+func NewRecorder(path string) (*Recorder, error) {
+	if err := repairRecording(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	return &Recorder{file: f, gz: gzip.NewWriter(f), lastFlush: time.Now()}, nil
+}
+
+// Record appends a frame received at t.
+func (r *Recorder) Record(t time.Time, frame []byte) error {
+	if !json.Valid(frame) {
+		return errors.New("record frame: not JSON")
+	}
+	line, err := json.Marshal(recordedFrame{Time: t.UnixNano(), Frame: frame})
+	if err != nil {
+		return fmt.Errorf("record frame: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.gz.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("record frame: %w", err)
+	}
+	if time.Since(r.lastFlush) >= recorderFlushInterval {
+		r.lastFlush = time.Now()
+		return r.gz.Flush()
+	}
+	return nil
+}
+
+// Close flushes the recording and closes its file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// repairRecording rewrites a recording whose last gzip member was cut short by
+// a crash before its Recorder closed, keeping the complete frames. Replays
+// would otherwise stop at the truncated member instead of reading the ones
+// appended after it.
+func repairRecording(path string) error {
+	intact, err := recordingIntact(path)
+	if err != nil || intact {
+		return err
+	}
+	log.Printf("binance: recording %s was cut short, keeping its complete frames", path)
+
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	defer src.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".repair-*")
+	if err != nil {
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once renamed
+
+	gz := gzip.NewWriter(tmp)
+	// A crash right after creating the file may have cut even the gzip header
+	if r, err := gzip.NewReader(src); err == nil {
+		lines := bufio.NewReader(r)
+		for {
+			line, err := lines.ReadBytes('\n')
+			if err != nil {
+				break // the rest is a partial frame or nothing
+			}
+			if _, err := gz.Write(line); err != nil {
+				tmp.Close()
+				return fmt.Errorf("repair recording: %w", err)
+			}
+		}
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("repair recording: %w", err)
+	}
+	return nil
+}
+
+// recordingIntact reports whether the recording at path is missing, empty or
+// ends with a complete gzip member. Files that are not recordings are errors,
+// so they are never rewritten.
+func recordingIntact(path string) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("open recording: %w", err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil {
+		return false, fmt.Errorf("open recording: %w", err)
+	} else if info.Size() == 0 {
+		return true, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err == nil {
+		_, err = io.Copy(io.Discard, gz)
+	}
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		return false, nil
+	default:
+		return false, fmt.Errorf("recording %s is corrupt, record to a new file: %w", path, err)
+	}
+}
+
+// recording reads the frames of a recording in order.
+type recording struct {
+	file    *os.File
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+}
+
+func openRecording(path string) (*recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open recording %s: %w", path, err)
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // depth frames can be large
+	return &recording{file: f, gz: gz, scanner: scanner}, nil
+}
+
+// next returns the next frame and its receive time, or io.EOF. A recording
+// cut short by a crash ends at its last complete frame.
+func (r *recording) next() (time.Time, []byte, error) {
+	if !r.scanner.Scan() {
+		err := r.scanner.Err()
+		if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+			return time.Time{}, nil, io.EOF
+		}
+		return time.Time{}, nil, err
+	}
+	var frame recordedFrame
+	if err := json.Unmarshal(r.scanner.Bytes(), &frame); err != nil {
+		return time.Time{}, nil, fmt.Errorf("decode recorded frame: %w", err)
+	}
+	return time.Unix(0, frame.Time), frame.Frame, nil
+}
+
+func (r *recording) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
+
+// ParseSpeed parses a replay speed: "max" replays as fast as possible,
+// returned as 0, and a positive factor such as "1", "10" or "10x" scales the
+// recorded pace.
+func ParseSpeed(s string) (float64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "max" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid replay speed %q (want max or a positive factor such as 10x)", s)
+	}
+	return speed, nil
+}
+
+// replayLoop feeds the recorded frames of the client's symbol through
+// parseCombinedStream, keeping their recorded spacing divided by the speed.
+// Depth frames are skipped since recordings hold no REST snapshots.
+func (c *Client) replayLoop(ctx context.Context, rec *recording) {
+	defer rec.Close()
+
+	prefix := []byte(`"stream":"` + c.symbol + `@`)
+	var first, start time.Time
+	var frames int
+	for {
+		received, frame, err := rec.next()
+		if err == io.EOF {
+			log.Printf("binance: replay of %s finished after %d frames", c.symbol, frames)
+			c.setState(exchange.Failed, "", errors.New("recording ended"))
+			return
+		}
+		if err != nil {
+			log.Printf("binance: replay of %s stopped: %v", c.symbol, err)
+			c.setState(exchange.Failed, "", err)
+			return
+		}
+		if !bytes.Contains(frame, prefix) || isDepthMessage(frame) {
+			continue
+		}
+
+		if c.replay.speed > 0 {
+			if first.IsZero() {
+				first, start = received, time.Now()
+			}
+			due := start.Add(time.Duration(float64(received.Sub(first)) / c.replay.speed))
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			case <-time.After(time.Until(due)):
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			default:
+			}
+		}
+
+		update, err := parseCombinedStream(frame)
+		if err != nil {
+			log.Printf("parse recorded frame error: %v", err)
+			continue
+		}
+		frames++
+		c.received()
+		c.broadcast(update)
+	}
+}
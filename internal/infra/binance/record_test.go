@@ -0,0 +1,216 @@
+package binance
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func aggTrade(symbol, price string) []byte {
+	return []byte(`{"stream":"` + symbol + `@aggTrade","data":{"e":"aggTrade","s":"` + symbol + `","p":"` + price + `","q":"1","T":1709251200000}}`)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	t0 := time.Unix(1709251200, 0)
+
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, frame := range [][]byte{
+		aggTrade("btcusdt", "1"),
+		aggTrade("ethusdt", "2"),
+		[]byte(`{"stream":"btcusdt@miniTicker","data":{"e":"24hrMiniTicker","s":"BTCUSDT","c":"3","v":"100"}}`),
+	} {
+		if err := rec.Record(t0.Add(time.Duration(i)*10*time.Millisecond), frame); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := rec.Record(t0, []byte("{cut")); err == nil {
+		t.Error("Record() should reject frames that are not JSON")
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second session appends to the same recording
+	rec, err = NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Record(t0.Add(30*time.Millisecond), []byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","U":1,"u":2,"b":[],"a":[]}}`))
+	rec.Record(t0.Add(40*time.Millisecond), aggTrade("btcusdt", "5"))
+	rec.Close()
+
+	client := NewReplayClient("btcusdt", path, 0)
+	ch := client.Subscribe()
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	var prices []float64
+	for len(prices) < 3 {
+		select {
+		case u := <-ch:
+			prices = append(prices, u.Price)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("replayed %v, want 3 btcusdt updates", prices)
+		}
+	}
+	if prices[0] != 1 || prices[1] != 3 || prices[2] != 5 {
+		t.Errorf("replayed prices = %v, want [1 3 5]", prices)
+	}
+	select {
+	case u := <-ch:
+		t.Errorf("unexpected replayed update %+v", u)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := NewReplayClient("btcusdt", filepath.Join(t.TempDir(), "missing"), 0).Connect(context.Background()); err == nil {
+		t.Error("Connect() should fail for a missing recording")
+	}
+}
+
+func TestReplayKeepsRecordedPace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Now()
+	rec.Record(t0, aggTrade("btcusdt", "1"))
+	rec.Record(t0.Add(400*time.Millisecond), aggTrade("btcusdt", "2"))
+	rec.Close()
+
+	client := NewReplayClient("btcusdt", path, 2)
+	ch := client.Subscribe()
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	<-ch
+	start := time.Now()
+	<-ch
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("second frame after %v, want about 200ms at 2x", elapsed)
+	}
+}
+
+func TestRecorderRecordsLiveFrames(t *testing.T) {
+	frame := `{"stream":"btcusdt@aggTrade","data":{"e":"aggTrade","s":"BTCUSDT","p":"50000.5","q":"0.25","T":1709251200000}}`
+	s := &standIn{messages: []string{frame}}
+	path := filepath.Join(t.TempDir(), "live.jsonl.gz")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("btcusdt", WithEndpoints(s.start(t)), WithRecorder(rec))
+	ch := client.Subscribe()
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(3 * time.Second):
+		t.Fatal("no tick from the stand-in")
+	}
+	client.Close()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := openRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	received, got, err := r.next()
+	if err != nil || string(got) != frame || time.Since(received) > time.Minute {
+		t.Errorf("next() = %v, %s, %v, want the live frame", received, got, err)
+	}
+	if _, _, err := r.next(); err != io.EOF {
+		t.Errorf("next() error = %v, want io.EOF", err)
+	}
+}
+
+func TestRecorderRepairsCrashedRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crashed.jsonl.gz")
+	t0 := time.Unix(1709251200, 0)
+
+	// The process dies after a periodic flush, leaving the member unterminated
+	// and a frame written after the flush unflushed
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Record(t0, aggTrade("btcusdt", "1"))
+	rec.Record(t0.Add(time.Millisecond), aggTrade("btcusdt", "2"))
+	if err := rec.gz.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	rec.gz.Write([]byte(`{"t":1,"frame":{"stream":"btcusdt@aggTr`))
+	rec.file.Close()
+
+	// The next run appends after repairing the tail
+	rec, err = NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder() after a crash error = %v", err)
+	}
+	rec.Record(t0.Add(2*time.Millisecond), aggTrade("btcusdt", "3"))
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := openRecording(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var frames []string
+	for {
+		_, frame, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next() error = %v after %d frames", err, len(frames))
+		}
+		frames = append(frames, string(frame))
+	}
+	want := []string{string(aggTrade("btcusdt", "1")), string(aggTrade("btcusdt", "2")), string(aggTrade("btcusdt", "3"))}
+	if len(frames) != len(want) {
+		t.Fatalf("replayed %d frames %v, want %d", len(frames), frames, len(want))
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Errorf("frame %d = %s, want %s", i, frames[i], want[i])
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("not a recording"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRecorder(path); err == nil {
+		t.Error("NewRecorder() should refuse to append to a file that is not a recording")
+	}
+}
+
+func TestParseSpeed(t *testing.T) {
+	for in, want := range map[string]float64{"max": 0, "1": 1, "10x": 10, " 0.5X ": 0.5} {
+		if got, err := ParseSpeed(in); err != nil || got != want {
+			t.Errorf("ParseSpeed(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "-2", "fast"} {
+		if _, err := ParseSpeed(in); err == nil {
+			t.Errorf("ParseSpeed(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package binance
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+// staleAfter is how long a live connection may stay silent before it is
+// reported degraded; the miniTicker stream alone sends a frame every second.
+const staleAfter = 5 * time.Second
+
+// Status returns the client's connection status.
+func (c *Client) Status() exchange.Status {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	return c.currentStatus()
+}
+
+// SubscribeStatus adds a subscriber for connection state changes. The current
+// status is delivered first.
+func (c *Client) SubscribeStatus(opts ...exchange.SubscribeOption) <-chan exchange.Status {
+	sub := exchange.NewSubscriber[exchange.Status]("binance "+c.symbol+" status", exchange.DropOldest, opts)
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closed {
+		sub.Close()
+		return sub.C()
+	}
+	exchange.Subscribers[exchange.Status]{sub}.Broadcast(c.currentStatus())
+	c.statusSubscribers = append(c.statusSubscribers, sub)
+	return sub.C()
+}
+
+// UnsubscribeStatus removes a status subscriber and closes its channel.
+func (c *Client) UnsubscribeStatus(ch <-chan exchange.Status) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if i := c.statusSubscribers.Find(ch); i >= 0 {
+		c.statusSubscribers.Remove(i)
+	}
+}
+
+// setState moves the connection to state, through endpoint if it is not
+// empty, and tells status subscribers. Repeated states are not reported again.
+func (c *Client) setState(state exchange.ConnState, endpoint string, err error) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.transition(state, endpoint, err)
+}
+
+// transition implements setState. Must be called with statusMu held.
+func (c *Client) transition(state exchange.ConnState, endpoint string, err error) {
+	if endpoint == "" {
+		endpoint = c.status.Endpoint
+	}
+	if state == c.status.State && endpoint == c.status.Endpoint {
+		return
+	}
+	c.status.State, c.status.Endpoint, c.status.Err, c.status.Time = state, endpoint, err, time.Now()
+	c.degraded.Store(state == exchange.Degraded)
+	status := c.currentStatus()
+
+	c.subMu.RLock()
+	disconnect := c.statusSubscribers.Broadcast(status)
+	c.subMu.RUnlock()
+
+	if len(disconnect) > 0 {
+		c.subMu.Lock()
+		c.statusSubscribers.Disconnect(disconnect)
+		c.subMu.Unlock()
+	}
+}
+
+// currentStatus returns a copy of the status. Must be called with statusMu held.
+func (c *Client) currentStatus() exchange.Status {
+	status := c.status
+	status.Symbol = strings.ToUpper(c.symbol)
+	status.Simulated = c.simulate || c.replay != nil
+	if last := c.lastMessage.Load(); last != 0 {
+		status.LastMessage = time.Unix(0, last)
+	}
+	return status
+}
+
+// received notes that data arrived, reviving a degraded connection.
+func (c *Client) received() {
+	c.lastMessage.Store(time.Now().UnixNano())
+	if !c.degraded.Load() {
+		return
+	}
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.status.State == exchange.Degraded {
+		log.Printf("binance: %s feed recovered", c.symbol)
+		c.transition(exchange.Live, "", nil)
+	}
+}
+
+// checkStale reports a live connection that has been silent for longer than
+// the client's staleAfter as degraded.
+func (c *Client) checkStale() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	// A connection that just came up has not been silent for long either
+	silent := time.Since(time.Unix(0, max(c.lastMessage.Load(), c.status.Time.UnixNano())))
+	if c.status.State != exchange.Live || silent < c.staleAfter {
+		return
+	}
+	log.Printf("binance: no %s data for %v, feed degraded", c.symbol, silent.Round(time.Second))
+	c.transition(exchange.Degraded, "", fmt.Errorf("no data for %v", silent.Round(time.Second)))
+}
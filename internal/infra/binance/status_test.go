@@ -0,0 +1,104 @@
+package binance
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+// nextStatus waits for the next status on ch.
+func nextStatus(t *testing.T, ch <-chan exchange.Status) exchange.Status {
+	t.Helper()
+	select {
+	case status := <-ch:
+		return status
+	case <-time.After(3 * time.Second):
+		t.Fatal("no status change")
+		return exchange.Status{}
+	}
+}
+
+func TestStatusFollowsConnection(t *testing.T) {
+	s := &standIn{
+		messages: []string{tradeFrame(1)},
+		resumed:  []string{tradeFrame(2)},
+	}
+	endpoint := s.start(t)
+	client := NewClient("btcusdt", WithEndpoints(endpoint))
+	statuses := client.SubscribeStatus()
+
+	if status := nextStatus(t, statuses); status.State != exchange.Connecting || status.Symbol != "BTCUSDT" || status.Simulated {
+		t.Errorf("initial status = %+v, want connecting", status)
+	}
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if status := nextStatus(t, statuses); status.State != exchange.Live || status.Endpoint != endpoint.Stream {
+		t.Errorf("status = %+v, want live via %s", status, endpoint.Stream)
+	}
+	// The stand-in hangs up after its first messages
+	if status := nextStatus(t, statuses); status.State != exchange.Reconnecting || status.Err == nil || status.LastMessage.IsZero() {
+		t.Errorf("status = %+v, want reconnecting after a message", status)
+	}
+	if status := nextStatus(t, statuses); status.State != exchange.Live {
+		t.Errorf("status = %+v, want live again", status)
+	}
+
+	client.Close()
+	if _, ok := <-statuses; ok {
+		t.Error("Close() should close status channels")
+	}
+}
+
+func TestStatusDegradesWhenSilent(t *testing.T) {
+	s := &standIn{
+		rest:     map[string]string{"/api/v3/depth": `{"lastUpdateId":1,"bids":[],"asks":[]}`},
+		messages: []string{tradeFrame(1)},
+		onMessage: func(msg string) []string {
+			if strings.Contains(msg, "SUBSCRIBE") {
+				return []string{`{"result":null,"id":1}`}
+			}
+			return nil
+		},
+	}
+	client := NewClient("btcusdt", WithEndpoints(s.start(t)))
+	client.staleAfter = 100 * time.Millisecond
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	statuses := client.SubscribeStatus()
+	for status := nextStatus(t, statuses); status.State != exchange.Degraded; status = nextStatus(t, statuses) {
+		if status.State != exchange.Live {
+			t.Fatalf("status = %+v, want live until degraded", status)
+		}
+	}
+	if got := client.Status(); got.Err == nil || time.Since(got.LastMessage) < 100*time.Millisecond {
+		t.Errorf("Status() = %+v, want silent for the stale period", got)
+	}
+
+	// Any frame revives the connection, here the reply to a depth subscription
+	client.SubscribeDepth()
+	if status := nextStatus(t, statuses); status.State != exchange.Live {
+		t.Errorf("status = %+v, want live after a frame", status)
+	}
+}
+
+func TestSimulatedStatus(t *testing.T) {
+	client := NewSimulatedClient("btcusdt")
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	status := nextStatus(t, client.SubscribeStatus())
+	if status.State != exchange.Live || !status.Simulated || status.Endpoint != "simulator" {
+		t.Errorf("status = %+v, want a live simulation", status)
+	}
+}
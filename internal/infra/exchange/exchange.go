@@ -0,0 +1,143 @@
+// Package exchange defines what the server needs from a market data provider,
+// independently of the exchange behind it, and the subscriber fan-out the
+// adapters publish through.
+package exchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/orderbook"
+)
+
+// Tick is a price update. For trade ticks (IsTrade) Volume is the traded
+// quantity; for ticker updates it is the rolling 24h base volume.
+type Tick struct {
+	Symbol    string
+	Price     float64
+	Volume    float64
+	Timestamp time.Time
+	IsTrade   bool
+}
+
+// Bar is a historical candlestick.
+type Bar struct {
+	OpenTime  time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	CloseTime time.Time
+}
+
+// DepthUpdate is the top of a symbol's order book.
+type DepthUpdate struct {
+	Symbol       string
+	Bids         []orderbook.Level // highest first
+	Asks         []orderbook.Level // lowest first
+	LastUpdateID int64
+	Timestamp    time.Time
+}
+
+// Gap reports trades a source missed and could not recover, as when a
+// reconnect outlasts what the exchange lets it backfill. Trades between From
+// and To may be missing from the source's ticks.
+type Gap struct {
+	Symbol string
+	From   time.Time // last trade delivered before the gap
+	To     time.Time // when delivery resumed
+	Err    error     // why the missed trades could not be recovered
+}
+
+// ConnState is the state of a source's upstream connection.
+type ConnState int
+
+const (
+	// Connecting is the state of a source that has not connected yet.
+	Connecting ConnState = iota
+	// Live sources receive data.
+	Live
+	// Degraded sources are connected but have been silent for longer than
+	// the exchange normally is.
+	Degraded
+	// Reconnecting sources lost their connection and are redialling.
+	Reconnecting
+	// Failed sources gave up; they deliver no more data.
+	Failed
+)
+
+var connStateNames = [...]string{
+	Connecting:   "connecting",
+	Live:         "live",
+	Degraded:     "degraded",
+	Reconnecting: "reconnecting",
+	Failed:       "failed",
+}
+
+func (s ConnState) String() string {
+	if s < Connecting || s > Failed {
+		return "unknown"
+	}
+	return connStateNames[s]
+}
+
+// Status is the connection status of a source.
+type Status struct {
+	Symbol      string
+	State       ConnState
+	Endpoint    string    // stream URL in use, or what feeds a simulated source
+	Simulated   bool      // fed by a simulation or a recording, not the exchange
+	LastMessage time.Time // when data last arrived, zero if it never did
+	Err         error     // why the source is reconnecting or failed
+	Time        time.Time // when the state was entered
+}
+
+// MarketDataSource is one exchange's public market data for a symbol. Symbols
+// use the lower-case concatenated form, as in "btcusdt", whatever the exchange
+// calls them. Creating a source must not do any I/O; Connect opens the feed.
+type MarketDataSource interface {
+	// Connect opens the live feed. Ticks are published to subscribers until
+	// Close or until ctx is cancelled.
+	Connect(ctx context.Context) error
+	// Subscribe adds a tick subscriber; see SubscribeOption.
+	Subscribe(opts ...SubscribeOption) <-chan Tick
+	// Unsubscribe removes a subscriber and closes its channel.
+	Unsubscribe(ch <-chan Tick)
+	// FetchBars returns up to limit bars of the source's symbol opening between
+	// start and end, oldest first. A zero start returns the most recent bars; a
+	// zero end leaves the range open.
+	FetchBars(ctx context.Context, interval string, start, end time.Time, limit int) ([]Bar, error)
+	// Symbols lists the symbols the exchange trades.
+	Symbols(ctx context.Context) ([]string, error)
+	// Close shuts the feed down and closes every subscriber channel.
+	Close() error
+}
+
+// DepthSource is implemented by sources that also stream order books.
+type DepthSource interface {
+	SubscribeDepth(opts ...SubscribeOption) <-chan DepthUpdate
+	UnsubscribeDepth(ch <-chan DepthUpdate)
+}
+
+// GapSource is implemented by sources that backfill the trades they miss
+// while reconnecting and report the gaps they cannot fill.
+type GapSource interface {
+	SubscribeGaps(opts ...SubscribeOption) <-chan Gap
+	UnsubscribeGaps(ch <-chan Gap)
+}
+
+// ReplaySource is implemented by sources that can replay recorded data
+// instead of streaming it live. A replay has no exchange to fall behind, so
+// consumers hold it up with the Block policy rather than drop its ticks.
+type ReplaySource interface {
+	// Replaying reports whether the source replays a recording.
+	Replaying() bool
+}
+
+// StatusSource is implemented by sources that report their connection state.
+// A new subscriber first receives the current status, then every change.
+type StatusSource interface {
+	SubscribeStatus(opts ...SubscribeOption) <-chan Status
+	UnsubscribeStatus(ch <-chan Status)
+}
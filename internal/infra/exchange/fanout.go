@@ -0,0 +1,245 @@
+package exchange
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Policy decides what happens to an update when a subscriber's buffer is full.
+type Policy int
+
+const (
+	// DropNewest discards the update that does not fit. It is the default.
+	DropNewest Policy = iota
+	// DropOldest discards the oldest buffered update to make room for the new one.
+	DropOldest
+	// Block waits up to the subscriber's block timeout for room, holding up
+	// every other subscriber meanwhile, then drops the update.
+	Block
+	// Disconnect unsubscribes the subscriber, closing its channel.
+	Disconnect
+)
+
+const (
+	// DefaultBufferSize is the channel capacity of a subscriber.
+	DefaultBufferSize = 100
+	// DefaultBlockTimeout bounds how long the Block policy waits for room.
+	DefaultBlockTimeout = 100 * time.Millisecond
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Block:
+		return "block"
+	case Disconnect:
+		return "disconnect"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// ParsePolicy parses a policy name as returned by Policy.String.
+func ParsePolicy(s string) (Policy, error) {
+	for _, p := range []Policy{DropNewest, DropOldest, Block, Disconnect} {
+		if s == p.String() {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown backpressure policy %q (want drop-newest, drop-oldest, block or disconnect)", s)
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	policy  Policy
+	size    int
+	timeout time.Duration
+}
+
+// WithPolicy sets the backpressure policy applied when the subscriber falls behind.
+func WithPolicy(p Policy) SubscribeOption {
+	return func(s *subscribeConfig) { s.policy = p }
+}
+
+// WithBufferSize sets the subscriber's channel capacity.
+func WithBufferSize(n int) SubscribeOption {
+	return func(s *subscribeConfig) {
+		if n > 0 {
+			s.size = n
+		}
+	}
+}
+
+// WithBlockTimeout sets how long the Block policy waits for room.
+func WithBlockTimeout(d time.Duration) SubscribeOption {
+	return func(s *subscribeConfig) {
+		if d > 0 {
+			s.timeout = d
+		}
+	}
+}
+
+// SubscriberStats reports how a subscriber keeps up with the feed.
+type SubscriberStats struct {
+	Policy    Policy
+	Delivered uint64 // updates written to the channel
+	Dropped   uint64 // updates discarded by the policy, including evicted old ones
+	Buffered  int    // updates waiting in the channel
+	Capacity  int
+}
+
+// Subscriber is one channel of a fan-out, of ticks or order books.
+type Subscriber[T any] struct {
+	subscribeConfig
+	name string // feed name for logs
+	ch   chan T
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	behind    bool // the last update was dropped; only touched by Broadcast
+}
+
+// NewSubscriber applies opts on top of the default policy. name identifies
+// the feed in logs, as in "binance btcusdt".
+func NewSubscriber[T any](name string, policy Policy, opts []SubscribeOption) *Subscriber[T] {
+	s := &Subscriber[T]{
+		subscribeConfig: subscribeConfig{policy: policy, size: DefaultBufferSize, timeout: DefaultBlockTimeout},
+		name:            name,
+	}
+	for _, opt := range opts {
+		opt(&s.subscribeConfig)
+	}
+	s.ch = make(chan T, s.size)
+	return s
+}
+
+// C returns the channel the subscriber reads.
+func (s *Subscriber[T]) C() <-chan T {
+	return s.ch
+}
+
+// Close closes the subscriber's channel. It must not be in a fan-out.
+func (s *Subscriber[T]) Close() {
+	close(s.ch)
+}
+
+// deliver writes update to the subscriber according to its policy. It reports
+// false when the subscriber must be disconnected.
+func (s *Subscriber[T]) deliver(update T) bool {
+	select {
+	case s.ch <- update:
+		s.delivered.Add(1)
+		s.behind = false
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case DropOldest:
+		// The consumer may drain the channel concurrently, so eviction can fail
+		// harmlessly; the retry then finds room
+		select {
+		case <-s.ch:
+			s.drop()
+		default:
+		}
+		select {
+		case s.ch <- update:
+			s.delivered.Add(1)
+			return true
+		default:
+		}
+	case Block:
+		timer := time.NewTimer(s.timeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- update:
+			s.delivered.Add(1)
+			s.behind = false
+			return true
+		case <-timer.C:
+		}
+	case Disconnect:
+		s.drop()
+		return false
+	}
+	s.drop()
+	return true
+}
+
+// drop counts a discarded update, logging the first of a run.
+func (s *Subscriber[T]) drop() {
+	n := s.dropped.Add(1)
+	if !s.behind {
+		log.Printf("%s: slow subscriber (%s, %d buffered), dropping updates (%d dropped so far)", s.name, s.policy, len(s.ch), n)
+	}
+	s.behind = true
+}
+
+// Stats returns the subscriber's delivery metrics.
+func (s *Subscriber[T]) Stats() SubscriberStats {
+	return SubscriberStats{
+		Policy:    s.policy,
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Buffered:  len(s.ch),
+		Capacity:  cap(s.ch),
+	}
+}
+
+// Subscribers is a set of subscribers of one kind of update. It is not safe
+// for concurrent use: adapters guard it with a RWMutex, holding the read lock
+// for Broadcast and the write lock for every other method.
+type Subscribers[T any] []*Subscriber[T]
+
+// Find returns the index of the subscriber reading ch, or -1.
+func (f Subscribers[T]) Find(ch <-chan T) int {
+	for i, s := range f {
+		if s.ch == ch {
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove closes and removes the i-th subscriber.
+func (f *Subscribers[T]) Remove(i int) {
+	close((*f)[i].ch)
+	*f = append((*f)[:i], (*f)[i+1:]...)
+}
+
+// RemoveAll closes and removes every subscriber.
+func (f *Subscribers[T]) RemoveAll() {
+	for len(*f) > 0 {
+		f.Remove(len(*f) - 1)
+	}
+}
+
+// Broadcast delivers update to every subscriber and returns the ones to disconnect.
+func (f Subscribers[T]) Broadcast(update T) []*Subscriber[T] {
+	var disconnect []*Subscriber[T]
+	for _, s := range f {
+		if !s.deliver(update) {
+			disconnect = append(disconnect, s)
+		}
+	}
+	return disconnect
+}
+
+// Disconnect removes the given subscribers, closing their channels.
+func (f *Subscribers[T]) Disconnect(subs []*Subscriber[T]) {
+	for _, s := range subs {
+		for i, sub := range *f {
+			if sub == s {
+				f.Remove(i)
+				break
+			}
+		}
+	}
+}
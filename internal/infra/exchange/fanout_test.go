@@ -0,0 +1,45 @@
+package exchange
+
+import "testing"
+
+func TestParsePolicy(t *testing.T) {
+	for _, p := range []Policy{DropNewest, DropOldest, Block, Disconnect} {
+		if got, err := ParsePolicy(p.String()); err != nil || got != p {
+			t.Errorf("ParsePolicy(%q) = %v, %v", p, got, err)
+		}
+	}
+	if _, err := ParsePolicy("newest"); err == nil {
+		t.Error("ParsePolicy() should reject unknown names")
+	}
+}
+
+func TestSubscribersDisconnect(t *testing.T) {
+	var subs Subscribers[int]
+	keep := NewSubscriber[int]("test", DropNewest, []SubscribeOption{WithBufferSize(1)})
+	slow := NewSubscriber[int]("test", Disconnect, []SubscribeOption{WithBufferSize(1)})
+	subs = append(subs, keep, slow)
+
+	if disconnect := subs.Broadcast(1); len(disconnect) != 0 {
+		t.Fatalf("Broadcast() disconnected %d subscribers with room left", len(disconnect))
+	}
+	disconnect := subs.Broadcast(2)
+	if len(disconnect) != 1 || disconnect[0] != slow {
+		t.Fatalf("Broadcast() disconnected %v, want the Disconnect subscriber", disconnect)
+	}
+	subs.Disconnect(disconnect)
+	if len(subs) != 1 || subs.Find(keep.C()) != 0 || subs.Find(slow.C()) != -1 {
+		t.Errorf("subscribers after Disconnect = %v", subs)
+	}
+	if stats := keep.Stats(); stats.Delivered != 1 || stats.Dropped != 1 || stats.Buffered != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	<-slow.C()
+	if _, ok := <-slow.C(); ok {
+		t.Error("disconnected channel should be closed")
+	}
+	subs.RemoveAll()
+	if _, ok := <-keep.C(); !ok || len(subs) != 0 {
+		t.Error("RemoveAll() should keep buffered updates and empty the set")
+	}
+}
@@ -0,0 +1,340 @@
+// Package kraken is a market data source for Kraken's public WebSocket v2 and
+// REST APIs.
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+const (
+	// DefaultStreamURL is Kraken's public WebSocket v2 endpoint.
+	DefaultStreamURL = "wss://ws.kraken.com/v2"
+	// DefaultRESTURL is the base URL of Kraken's REST API.
+	DefaultRESTURL = "https://api.kraken.com"
+
+	// readTimeout bounds the wait for the next message; Kraken sends a
+	// heartbeat every second while a channel is subscribed.
+	readTimeout = 30 * time.Second
+)
+
+// Client streams trades and ticker updates of one Kraken pair.
+type Client struct {
+	symbol    string // app form, as in btcusdt
+	pair      string // Kraken form, as in BTC/USDT
+	streamURL string
+	restURL   string
+	http      *http.Client
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+
+	subMu       sync.RWMutex
+	subscribers exchange.Subscribers[exchange.Tick]
+	closed      bool // subscriber channels have been closed
+	done        chan struct{}
+}
+
+var _ exchange.MarketDataSource = (*Client)(nil)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithEndpoints replaces the WebSocket and REST base URLs, for instance to
+// point the client at a local stand-in.
+func WithEndpoints(streamURL, restURL string) Option {
+	return func(c *Client) {
+		c.streamURL, c.restURL = streamURL, restURL
+	}
+}
+
+// NewClient creates a client for symbol, as in "btcusdt". Symbols Kraken
+// cannot map to a pair fail on Connect and FetchBars.
+func NewClient(symbol string, opts ...Option) *Client {
+	c := &Client{
+		symbol:    strings.ToLower(symbol),
+		streamURL: DefaultStreamURL,
+		restURL:   DefaultRESTURL,
+		http:      &http.Client{Timeout: 10 * time.Second},
+		done:      make(chan struct{}),
+	}
+	c.pair, _ = Pair(c.symbol)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Subscribe adds a subscriber channel for ticks, trades and ticker updates
+// alike. By default it drops new ticks while full; see exchange.WithPolicy.
+func (c *Client) Subscribe(opts ...exchange.SubscribeOption) <-chan exchange.Tick {
+	sub := exchange.NewSubscriber[exchange.Tick]("kraken "+c.symbol, exchange.DropNewest, opts)
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.closed {
+		sub.Close()
+		return sub.C()
+	}
+	c.subscribers = append(c.subscribers, sub)
+	return sub.C()
+}
+
+// Unsubscribe removes a subscriber and closes its channel. Unknown channels are ignored.
+func (c *Client) Unsubscribe(ch <-chan exchange.Tick) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if i := c.subscribers.Find(ch); i >= 0 {
+		c.subscribers.Remove(i)
+	}
+}
+
+// Connect opens the WebSocket, subscribes to the pair's trade and ticker
+// channels and starts reading. Dropped connections are re-established.
+func (c *Client) Connect(ctx context.Context) error {
+	if c.pair == "" {
+		return fmt.Errorf("kraken: no pair for symbol %q", c.symbol)
+	}
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to kraken: %w", err)
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	log.Printf("connected to kraken for %s", c.pair)
+	go c.readLoop(ctx, conn)
+	return nil
+}
+
+// dial opens a connection and sends the channel subscriptions.
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	conn, _, err := dialer.DialContext(ctx, c.streamURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, channel := range []string{"ticker", "trade"} {
+		msg := subscribeRequest{Method: "subscribe"}
+		msg.Params.Channel = channel
+		msg.Params.Symbol = []string{c.pair}
+		if err := conn.WriteJSON(msg); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("subscribe to %s: %w", channel, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *Client) readLoop(ctx context.Context, conn *websocket.Conn) {
+	defer c.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.done:
+			return
+		default:
+		}
+
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return
+			}
+			log.Printf("kraken read error: %v, reconnecting...", err)
+			if conn = c.reconnect(ctx); conn == nil {
+				return
+			}
+			continue
+		}
+
+		ticks, err := parseMessage(message)
+		if err != nil {
+			log.Printf("kraken: %v", err)
+			continue
+		}
+		for _, tick := range ticks {
+			c.broadcast(tick)
+		}
+	}
+}
+
+// reconnect redials with backoff until it succeeds, returning nil once the
+// client is closed or ctx is done.
+func (c *Client) reconnect(ctx context.Context) *websocket.Conn {
+	c.mu.Lock()
+	if c.conn != nil {
+		c.conn.Close()
+	}
+	c.mu.Unlock()
+
+	backoff := time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.done:
+			return nil
+		default:
+		}
+		conn, err := c.dial(ctx)
+		if err == nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			select {
+			case <-c.done:
+				conn.Close()
+				return nil
+			default:
+			}
+			c.conn = conn
+			log.Printf("kraken reconnected for %s", c.pair)
+			return conn
+		}
+		log.Printf("kraken reconnect failed: %v, retrying in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.done:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = time.Duration(math.Min(float64(backoff*2), float64(30*time.Second)))
+	}
+}
+
+func (c *Client) broadcast(tick exchange.Tick) {
+	tick.Symbol = strings.ToUpper(c.symbol)
+
+	c.subMu.RLock()
+	disconnect := c.subscribers.Broadcast(tick)
+	c.subMu.RUnlock()
+
+	if len(disconnect) == 0 {
+		return
+	}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	for _, sub := range disconnect {
+		log.Printf("kraken: disconnected slow %s subscriber after %d updates", c.symbol, sub.Stats().Delivered)
+	}
+	c.subscribers.Disconnect(disconnect)
+}
+
+// Close shuts down the client and closes every subscriber channel.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+
+	c.subMu.Lock()
+	c.subscribers.RemoveAll()
+	c.closed = true
+	c.subMu.Unlock()
+
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// subscribeRequest is a WebSocket v2 channel subscription.
+type subscribeRequest struct {
+	Method string `json:"method"`
+	Params struct {
+		Channel string   `json:"channel"`
+		Symbol  []string `json:"symbol"`
+	} `json:"params"`
+}
+
+// streamMessage is a WebSocket v2 message: channel data, a heartbeat or a
+// reply to a request.
+type streamMessage struct {
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+
+	Method  string `json:"method"`
+	Success *bool  `json:"success"`
+	Error   string `json:"error"`
+}
+
+// tradeData is one trade of the trade channel.
+type tradeData struct {
+	Price     float64 `json:"price"`
+	Qty       float64 `json:"qty"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// tickerData is the ticker channel's summary of a pair.
+type tickerData struct {
+	Last      float64 `json:"last"`
+	Volume    float64 `json:"volume"`
+	Timestamp string  `json:"timestamp"`
+}
+
+// parseMessage returns the ticks of a WebSocket message. Heartbeats, status
+// updates and the snapshot of past trades sent on subscription yield none;
+// rejected subscriptions yield an error.
+func parseMessage(data []byte) ([]exchange.Tick, error) {
+	var msg streamMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("unmarshal message: %w", err)
+	}
+	if msg.Success != nil && !*msg.Success {
+		return nil, fmt.Errorf("%s failed: %s", msg.Method, msg.Error)
+	}
+
+	switch msg.Channel {
+	case "trade":
+		if msg.Type != "update" {
+			return nil, nil
+		}
+		var trades []tradeData
+		if err := json.Unmarshal(msg.Data, &trades); err != nil {
+			return nil, fmt.Errorf("unmarshal trades: %w", err)
+		}
+		ticks := make([]exchange.Tick, len(trades))
+		for i, t := range trades {
+			ticks[i] = exchange.Tick{Price: t.Price, Volume: t.Qty, Timestamp: parseTime(t.Timestamp), IsTrade: true}
+		}
+		return ticks, nil
+	case "ticker":
+		var tickers []tickerData
+		if err := json.Unmarshal(msg.Data, &tickers); err != nil {
+			return nil, fmt.Errorf("unmarshal ticker: %w", err)
+		}
+		ticks := make([]exchange.Tick, len(tickers))
+		for i, t := range tickers {
+			ticks[i] = exchange.Tick{Price: t.Last, Volume: t.Volume, Timestamp: parseTime(t.Timestamp)}
+		}
+		return ticks, nil
+	}
+	return nil, nil
+}
+
+// parseTime parses an RFC 3339 timestamp, falling back to the current time.
+func parseTime(s string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t
+	}
+	return time.Now()
+}
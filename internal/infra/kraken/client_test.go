@@ -0,0 +1,203 @@
+package kraken
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestPair(t *testing.T) {
+	for symbol, want := range map[string]string{
+		"btcusdt": "BTC/USDT",
+		"ETHUSD":  "ETH/USD",
+		"soleur":  "SOL/EUR",
+		"ethbtc":  "ETH/BTC",
+	} {
+		if got, err := Pair(symbol); err != nil || got != want {
+			t.Errorf("Pair(%q) = %q, %v, want %q", symbol, got, err, want)
+		}
+	}
+	for _, symbol := range []string{"usdt", "btcxyz"} {
+		if _, err := Pair(symbol); err == nil {
+			t.Errorf("Pair(%q) should fail", symbol)
+		}
+	}
+	if got := restPair("BTC/USDT"); got != "XBTUSDT" {
+		t.Errorf("restPair() = %q, want XBTUSDT", got)
+	}
+	if got := symbolFromPair("XBT/USD"); got != "btcusd" {
+		t.Errorf("symbolFromPair() = %q, want btcusd", got)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantTicks int
+		wantPrice float64
+		wantTrade bool
+		wantErr   bool
+	}{
+		{
+			name:      "trade update",
+			input:     `{"channel":"trade","type":"update","data":[{"symbol":"BTC/USD","side":"buy","price":50000.5,"qty":0.1,"ord_type":"market","trade_id":1,"timestamp":"2024-03-01T00:00:00.5Z"}]}`,
+			wantTicks: 1,
+			wantPrice: 50000.5,
+			wantTrade: true,
+		},
+		{
+			name:  "trade snapshot is history",
+			input: `{"channel":"trade","type":"snapshot","data":[{"symbol":"BTC/USD","price":1,"qty":1,"timestamp":"2024-03-01T00:00:00Z"}]}`,
+		},
+		{
+			name:      "ticker",
+			input:     `{"channel":"ticker","type":"update","data":[{"symbol":"BTC/USD","bid":49999,"ask":50001,"last":50000,"volume":1234.5}]}`,
+			wantTicks: 1,
+			wantPrice: 50000,
+		},
+		{
+			name:  "heartbeat",
+			input: `{"channel":"heartbeat"}`,
+		},
+		{
+			name:    "rejected subscription",
+			input:   `{"method":"subscribe","success":false,"error":"Currency pair not supported"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			input:   `{invalid}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticks, err := parseMessage([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMessage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(ticks) != tt.wantTicks {
+				t.Fatalf("parseMessage() = %d ticks, want %d", len(ticks), tt.wantTicks)
+			}
+			if tt.wantTicks > 0 && (ticks[0].Price != tt.wantPrice || ticks[0].IsTrade != tt.wantTrade) {
+				t.Errorf("parseMessage() = %+v", ticks[0])
+			}
+		})
+	}
+}
+
+// standIn serves the parts of Kraken's WebSocket and REST APIs the client
+// uses. Every subscription is acknowledged and followed by data, the trade
+// channel's preceded by a snapshot the client must skip.
+func standIn(t *testing.T, subscriptions chan<- subscribeRequest) (streamURL, restURL string) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/0/public/OHLC":
+			if q := r.URL.Query(); q.Get("pair") != "XBTUSDT" || q.Get("interval") != "60" {
+				t.Errorf("OHLC query = %v", q)
+			}
+			w.Write([]byte(`{"error":[],"result":{"XBTUSDT":[
+				[1709247600,"100","101","99","100.5","100.2","3.5",12],
+				[1709251200,"100.5","102","100","101.5","101.1","4.25",20]],"last":1709251200}}`))
+			return
+		case "/0/public/AssetPairs":
+			w.Write([]byte(`{"error":[],"result":{
+				"XXBTZUSD":{"altname":"XBTUSD","wsname":"XBT/USD","status":"online"},
+				"ETHUSDT":{"altname":"ETHUSDT","wsname":"ETH/USDT","status":"online"},
+				"LUNAUSD":{"altname":"LUNAUSD","wsname":"LUNA/USD","status":"delisted"}}}`))
+			return
+		case "/v2":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req subscribeRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			subscriptions <- req
+			replies := []string{`{"method":"subscribe","success":true,"result":{"channel":"` + req.Params.Channel + `"}}`}
+			switch req.Params.Channel {
+			case "trade":
+				replies = append(replies,
+					`{"channel":"trade","type":"snapshot","data":[{"price":1,"qty":1,"timestamp":"2024-03-01T00:00:00Z"}]}`,
+					`{"channel":"trade","type":"update","data":[{"price":50000.5,"qty":0.25,"timestamp":"2024-03-01T00:00:00Z"}]}`)
+			case "ticker":
+				replies = append(replies, `{"channel":"heartbeat"}`)
+			}
+			for _, m := range replies {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(m)); err != nil {
+					return
+				}
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/v2", srv.URL
+}
+
+func TestClientAgainstStandIn(t *testing.T) {
+	subscriptions := make(chan subscribeRequest, 10)
+	client := NewClient("btcusdt", WithEndpoints(standIn(t, subscriptions)))
+	ch := client.Subscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer client.Close()
+
+	for _, channel := range []string{"ticker", "trade"} {
+		req := <-subscriptions
+		if req.Method != "subscribe" || req.Params.Channel != channel || len(req.Params.Symbol) != 1 || req.Params.Symbol[0] != "BTC/USDT" {
+			t.Errorf("subscription = %+v, want %s of BTC/USDT", req, channel)
+		}
+	}
+	select {
+	case u := <-ch:
+		if u.Symbol != "BTCUSDT" || u.Price != 50000.5 || u.Volume != 0.25 || !u.IsTrade || !u.Timestamp.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("tick = %+v", u)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("no tick from the stand-in")
+	}
+
+	bars, err := client.FetchBars(ctx, "1h", time.Time{}, time.Time{}, 1)
+	if err != nil || len(bars) != 1 || bars[0].Close != 101.5 || bars[0].Volume != 4.25 || !bars[0].OpenTime.Equal(time.Unix(1709251200, 0)) {
+		t.Errorf("FetchBars(latest) = %+v, %v", bars, err)
+	}
+	bars, err = client.FetchBars(ctx, "1h", time.Unix(1709247600, 0), time.Unix(1709247600, 0), 10)
+	if err != nil || len(bars) != 1 || bars[0].Close != 100.5 {
+		t.Errorf("FetchBars(range) = %+v, %v", bars, err)
+	}
+	if _, err := client.FetchBars(ctx, "3m", time.Time{}, time.Time{}, 10); err == nil {
+		t.Error("FetchBars() should reject intervals Kraken does not serve")
+	}
+
+	symbols, err := client.Symbols(ctx)
+	if err != nil || strings.Join(symbols, ",") != "btcusd,ethusdt" {
+		t.Errorf("Symbols() = %v, %v", symbols, err)
+	}
+
+	client.Close()
+	if _, ok := <-ch; ok {
+		t.Error("Close() should close subscriber channels")
+	}
+}
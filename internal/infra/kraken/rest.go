@@ -0,0 +1,214 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/infra/exchange"
+)
+
+// maxBars is the most bars Kraken's OHLC endpoint returns.
+const maxBars = 720
+
+// intervals maps the app's intervals to Kraken OHLC intervals in minutes.
+var intervals = map[string]int{
+	"1m": 1, "5m": 5, "15m": 15, "30m": 30,
+	"1h": 60, "4h": 240, "1d": 1440, "1w": 10080,
+}
+
+// quotes are the quote currencies Pair recognises, longest first so that
+// USDT is not read as USD.
+var quotes = []string{"USDT", "USDC", "USD", "EUR", "GBP", "CAD", "JPY", "BTC", "ETH"}
+
+// Pair maps a symbol such as "btcusdt" to its Kraken WebSocket pair, "BTC/USDT".
+func Pair(symbol string) (string, error) {
+	symbol = strings.ToUpper(symbol)
+	for _, quote := range quotes {
+		if base, ok := strings.CutSuffix(symbol, quote); ok && base != "" {
+			return base + "/" + quote, nil
+		}
+	}
+	return "", fmt.Errorf("kraken: unknown quote currency in %q", symbol)
+}
+
+// restAssets are the legacy asset codes of Kraken's REST API that differ from
+// the WebSocket v2 ones.
+var restAssets = map[string]string{"BTC": "XBT", "DOGE": "XDG"}
+
+// restPair returns the REST name of a WebSocket pair, as in XBTUSDT.
+func restPair(pair string) string {
+	base, quote, _ := strings.Cut(pair, "/")
+	if alt, ok := restAssets[base]; ok {
+		base = alt
+	}
+	if alt, ok := restAssets[quote]; ok {
+		quote = alt
+	}
+	return base + quote
+}
+
+// symbolFromPair returns the app symbol of a pair, undoing the legacy asset
+// codes that REST pair names still use.
+func symbolFromPair(pair string) string {
+	base, quote, _ := strings.Cut(pair, "/")
+	for ws, rest := range restAssets {
+		if base == rest {
+			base = ws
+		}
+		if quote == rest {
+			quote = ws
+		}
+	}
+	return strings.ToLower(base + quote)
+}
+
+// restResponse is the envelope of every REST response.
+type restResponse struct {
+	Error  []string        `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// get fetches a public REST endpoint and decodes its result into v.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v any) error {
+	reqURL := c.restURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	var envelope restResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if len(envelope.Error) > 0 {
+		return fmt.Errorf("kraken API error: %s", strings.Join(envelope.Error, "; "))
+	}
+	return json.Unmarshal(envelope.Result, v)
+}
+
+// FetchBars fetches the pair's OHLC bars. Kraken only serves the latest 720
+// bars of an interval, so older ranges come back empty.
+func (c *Client) FetchBars(ctx context.Context, interval string, start, end time.Time, limit int) ([]exchange.Bar, error) {
+	if c.pair == "" {
+		return nil, fmt.Errorf("kraken: no pair for symbol %q", c.symbol)
+	}
+	minutes, ok := intervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("kraken: unsupported interval %q", interval)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, maxBars)
+
+	query := url.Values{"pair": {restPair(c.pair)}, "interval": {strconv.Itoa(minutes)}}
+	if !start.IsZero() {
+		// since is exclusive
+		query.Set("since", strconv.FormatInt(start.Unix()-1, 10))
+	}
+	var result map[string]json.RawMessage
+	if err := c.get(ctx, "/0/public/OHLC", query, &result); err != nil {
+		return nil, fmt.Errorf("fetch bars: %w", err)
+	}
+
+	var bars []exchange.Bar
+	for name, raw := range result {
+		if name == "last" {
+			continue
+		}
+		var rows [][]any
+		if err := json.Unmarshal(raw, &rows); err != nil {
+			return nil, fmt.Errorf("decode bars: %w", err)
+		}
+		width := time.Duration(minutes) * time.Minute
+		for _, row := range rows {
+			bar, ok := parseBar(row, width)
+			if !ok || bar.OpenTime.Before(start) || !end.IsZero() && bar.OpenTime.After(end) {
+				continue
+			}
+			bars = append(bars, bar)
+		}
+	}
+
+	if len(bars) > limit {
+		if start.IsZero() {
+			bars = bars[len(bars)-limit:]
+		} else {
+			bars = bars[:limit]
+		}
+	}
+	return bars, nil
+}
+
+// parseBar parses an OHLC row: time, open, high, low, close, vwap, volume, count.
+func parseBar(row []any, width time.Duration) (exchange.Bar, bool) {
+	if len(row) < 7 {
+		return exchange.Bar{}, false
+	}
+	openTime, ok := row[0].(float64)
+	if !ok {
+		return exchange.Bar{}, false
+	}
+	var values [5]float64 // open, high, low, close, volume
+	for i, col := range []int{1, 2, 3, 4, 6} {
+		s, _ := row[col].(string)
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return exchange.Bar{}, false
+		}
+		values[i] = v
+	}
+	open := time.Unix(int64(openTime), 0)
+	return exchange.Bar{
+		OpenTime:  open,
+		Open:      values[0],
+		High:      values[1],
+		Low:       values[2],
+		Close:     values[3],
+		Volume:    values[4],
+		CloseTime: open.Add(width - time.Millisecond),
+	}, true
+}
+
+// assetPair is the part of an AssetPairs entry Symbols uses.
+type assetPair struct {
+	WSName string `json:"wsname"`
+	Status string `json:"status"`
+}
+
+// Symbols lists the pairs Kraken currently trades, as app symbols.
+func (c *Client) Symbols(ctx context.Context) ([]string, error) {
+	var pairs map[string]assetPair
+	if err := c.get(ctx, "/0/public/AssetPairs", nil, &pairs); err != nil {
+		return nil, fmt.Errorf("fetch asset pairs: %w", err)
+	}
+	symbols := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		if p.WSName == "" || p.Status != "" && p.Status != "online" {
+			continue
+		}
+		symbols = append(symbols, symbolFromPair(p.WSName))
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
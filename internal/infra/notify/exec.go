@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+)
+
+// Command runs a local shell command for every alert. The alert is passed in
+// environment variables:
+//
+//	QUANTACODE_ALERT_RULE       rule id
+//	QUANTACODE_ALERT_SYMBOL     symbol, e.g. BTCUSDT
+//	QUANTACODE_ALERT_MESSAGE    alert message
+//	QUANTACODE_ALERT_CONDITION  rule condition
+//	QUANTACODE_ALERT_PRICE      price that triggered the alert
+//	QUANTACODE_ALERT_TIME       trigger time in RFC 3339
+//	QUANTACODE_ALERT_JSON       the webhook JSON payload
+type Command struct {
+	command string
+	shell   string
+}
+
+// NewCommand creates a sink running command with /bin/sh -c.
+func NewCommand(command string) (*Command, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("alert command is empty")
+	}
+	return &Command{command: command, shell: "/bin/sh"}, nil
+}
+
+// Name implements Sink.
+func (c *Command) Name() string {
+	return "command"
+}
+
+// Send implements Sink. The command is killed when ctx is done.
+func (c *Command) Send(ctx context.Context, a alerts.Alert) error {
+	payload, err := json.Marshal(NewPayload(a))
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, c.shell, "-c", c.command)
+	cmd.Env = append(os.Environ(),
+		"QUANTACODE_ALERT_RULE="+a.RuleID,
+		"QUANTACODE_ALERT_SYMBOL="+a.Symbol,
+		"QUANTACODE_ALERT_MESSAGE="+a.Message,
+		"QUANTACODE_ALERT_CONDITION="+a.Condition,
+		"QUANTACODE_ALERT_PRICE="+strconv.FormatFloat(a.Price, 'f', -1, 64),
+		"QUANTACODE_ALERT_TIME="+a.Time.UTC().Format(time.RFC3339),
+		"QUANTACODE_ALERT_JSON="+string(payload),
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("run %q: %w: %s", c.command, err, msg)
+		}
+		return fmt.Errorf("run %q: %w", c.command, err)
+	}
+	return nil
+}
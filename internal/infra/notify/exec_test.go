@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandReceivesAlertInEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "alert.txt")
+	cmd, err := NewCommand(`printf '%s|%s|%s|%s' "$QUANTACODE_ALERT_RULE" "$QUANTACODE_ALERT_SYMBOL" "$QUANTACODE_ALERT_PRICE" "$QUANTACODE_ALERT_TIME" > ` + out)
+	if err != nil {
+		t.Fatalf("NewCommand() error = %v", err)
+	}
+	if err := cmd.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "rsi-high|BTCUSDT|64250.5|2024-03-01T12:00:00Z"; string(got) != want {
+		t.Errorf("command saw %q, want %q", got, want)
+	}
+}
+
+func TestCommandFailureIncludesStderr(t *testing.T) {
+	cmd, _ := NewCommand("echo boom >&2; exit 3")
+	err := cmd.Send(context.Background(), testAlert)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Send() error = %v, want it to include stderr", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package notify delivers alerts to destinations outside the gRPC stream, such
+// as HTTP webhooks and local commands.
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+)
+
+// Sink delivers an alert to one destination.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	Send(ctx context.Context, a alerts.Alert) error
+}
+
+const (
+	// DefaultQueueSize is the number of alerts buffered per sink before new ones are dropped.
+	DefaultQueueSize = 64
+	// DefaultDeliveryTimeout bounds one delivery, retries included.
+	DefaultDeliveryTimeout = 30 * time.Second
+)
+
+// Payload is the JSON representation of an alert sent by webhooks and exposed to commands.
+type Payload struct {
+	RuleID    string  `json:"rule_id"`
+	Symbol    string  `json:"symbol"`
+	Message   string  `json:"message"`
+	Condition string  `json:"condition,omitempty"`
+	Price     float64 `json:"price"`
+	Timestamp int64   `json:"timestamp"` // Unix milliseconds
+}
+
+// NewPayload converts an alert into its JSON payload.
+func NewPayload(a alerts.Alert) Payload {
+	return Payload{
+		RuleID:    a.RuleID,
+		Symbol:    a.Symbol,
+		Message:   a.Message,
+		Condition: a.Condition,
+		Price:     a.Price,
+		Timestamp: a.Time.UnixMilli(),
+	}
+}
+
+// Dispatcher fans alerts out to its sinks. Each sink has its own queue and
+// worker, so a slow webhook does not hold back a local command.
+type Dispatcher struct {
+	queues []chan alerts.Alert
+	sinks  []Sink
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewDispatcher starts one delivery worker per sink.
+func NewDispatcher(sinks ...Sink) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{sinks: sinks, cancel: cancel}
+	for _, sink := range sinks {
+		queue := make(chan alerts.Alert, DefaultQueueSize)
+		d.queues = append(d.queues, queue)
+		d.wg.Add(1)
+		go d.deliver(ctx, sink, queue)
+	}
+	return d
+}
+
+// Notify queues an alert for every sink without blocking. Alerts are dropped
+// for sinks whose queue is full, and all of them once the dispatcher is closed.
+func (d *Dispatcher) Notify(a alerts.Alert) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		log.Printf("notify: dispatcher closed, dropping alert %s on %s", a.RuleID, a.Symbol)
+		return
+	}
+	for i, queue := range d.queues {
+		select {
+		case queue <- a:
+		default:
+			log.Printf("notify: %s queue full, dropping alert %s on %s", d.sinks[i].Name(), a.RuleID, a.Symbol)
+		}
+	}
+}
+
+// Close stops accepting alerts, waits for queued ones to be delivered and
+// aborts deliveries still running when ctx is done.
+func (d *Dispatcher) Close(ctx context.Context) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, queue := range d.queues {
+			close(queue)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, sink Sink, queue <-chan alerts.Alert) {
+	defer d.wg.Done()
+	for a := range queue {
+		sendCtx, cancel := context.WithTimeout(ctx, DefaultDeliveryTimeout)
+		if err := sink.Send(sendCtx, a); err != nil {
+			log.Printf("notify: %s failed to deliver alert %s on %s: %v", sink.Name(), a.RuleID, a.Symbol, err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+)
+
+type recordingSink struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(ctx context.Context, a alerts.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.got = append(s.got, a.RuleID)
+	return nil
+}
+
+func TestDispatcherDeliversToEverySink(t *testing.T) {
+	a, b := &recordingSink{}, &recordingSink{}
+	d := NewDispatcher(a, b)
+	d.Notify(alerts.Alert{RuleID: "one"})
+	d.Notify(alerts.Alert{RuleID: "two"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d.Close(ctx)
+
+	for _, s := range []*recordingSink{a, b} {
+		if len(s.got) != 2 || s.got[0] != "one" || s.got[1] != "two" {
+			t.Errorf("sink received %v, want [one two]", s.got)
+		}
+	}
+}
+
+func TestDispatcherDropsAlertsAfterClose(t *testing.T) {
+	s := &recordingSink{}
+	d := NewDispatcher(s)
+	d.Close(context.Background())
+
+	// An alert raised during shutdown must not panic on a closed queue
+	d.Notify(alerts.Alert{RuleID: "late"})
+	if len(s.got) != 0 {
+		t.Errorf("sink received %v after Close", s.got)
+	}
+}
@@ -0,0 +1,214 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+)
+
+// Format selects the body a webhook posts.
+type Format string
+
+const (
+	// FormatJSON posts the alert Payload.
+	FormatJSON Format = "json"
+	// FormatSlack posts a Slack incoming-webhook message.
+	FormatSlack Format = "slack"
+	// FormatDiscord posts a Discord webhook message.
+	FormatDiscord Format = "discord"
+)
+
+// ParseFormat validates a webhook format name; empty selects FormatJSON.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatSlack, FormatDiscord:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown webhook format %q (want json, slack or discord)", s)
+	}
+}
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of "<timestamp>.<body>", prefixed with "sha256=".
+	SignatureHeader = "X-QuantaCode-Signature"
+	// TimestampHeader carries the Unix time in seconds at which the request was signed.
+	TimestampHeader = "X-QuantaCode-Timestamp"
+
+	defaultAttempts = 4
+	defaultBackoff  = 500 * time.Millisecond
+	maxBackoff      = 10 * time.Second
+)
+
+// Webhook posts alerts to an HTTP endpoint, retrying failed deliveries with
+// exponential backoff.
+type Webhook struct {
+	url      string
+	secret   []byte
+	format   Format
+	attempts int
+	backoff  time.Duration
+	client   *http.Client
+	now      func() time.Time
+}
+
+// WebhookOption configures a Webhook.
+type WebhookOption func(*Webhook)
+
+// WithSecret signs every request with HMAC-SHA256 using secret.
+func WithSecret(secret string) WebhookOption {
+	return func(w *Webhook) { w.secret = []byte(secret) }
+}
+
+// WithFormat selects the request body format.
+func WithFormat(f Format) WebhookOption {
+	return func(w *Webhook) { w.format = f }
+}
+
+// WithRetry sets the number of delivery attempts and the delay before the
+// first retry; the delay doubles after every further failure.
+func WithRetry(attempts int, backoff time.Duration) WebhookOption {
+	return func(w *Webhook) {
+		w.attempts = attempts
+		w.backoff = backoff
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(c *http.Client) WebhookOption {
+	return func(w *Webhook) { w.client = c }
+}
+
+// NewWebhook creates a webhook sink posting to url.
+func NewWebhook(url string, opts ...WebhookOption) (*Webhook, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("webhook url %q must be http or https", url)
+	}
+	w := &Webhook{
+		url:      url,
+		format:   FormatJSON,
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if _, err := ParseFormat(string(w.format)); err != nil {
+		return nil, err
+	}
+	if w.attempts < 1 {
+		return nil, fmt.Errorf("webhook attempts must be at least 1, got %d", w.attempts)
+	}
+	return w, nil
+}
+
+// Name implements Sink.
+func (w *Webhook) Name() string {
+	return "webhook " + string(w.format)
+}
+
+// Send implements Sink. Network errors, 429 and 5xx responses are retried;
+// other responses fail immediately.
+func (w *Webhook) Send(ctx context.Context, a alerts.Alert) error {
+	body, err := w.body(a)
+	if err != nil {
+		return err
+	}
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == w.attempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("attempt %d: %w (gave up: %v)", attempt, err, ctx.Err())
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quantacode-alerts")
+	if len(w.secret) > 0 {
+		ts := strconv.FormatInt(w.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, ts, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status: %s", resp.Status)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret, as
+// sent in SignatureHeader. Receivers recompute it to authenticate requests.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *Webhook) body(a alerts.Alert) ([]byte, error) {
+	var v any
+	switch w.format {
+	case FormatSlack:
+		v = map[string]string{"text": summary(a, "*")}
+	case FormatDiscord:
+		v = map[string]string{"content": summary(a, "**")}
+	default:
+		v = NewPayload(a)
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode alert: %w", err)
+	}
+	return body, nil
+}
+
+// summary renders an alert as one chat line, emphasising the symbol with the
+// platform's bold marker.
+func summary(a alerts.Alert, bold string) string {
+	text := fmt.Sprintf(":bell: %s%s%s %s at %s", bold, a.Symbol, bold, a.Message, strconv.FormatFloat(a.Price, 'f', -1, 64))
+	if a.Condition != "" && a.Condition != a.Message {
+		text += fmt.Sprintf(" (`%s`)", a.Condition)
+	}
+	return text
+}
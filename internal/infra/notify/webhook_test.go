@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/alerts"
+)
+
+var testAlert = alerts.Alert{
+	RuleID:    "rsi-high",
+	Symbol:    "BTCUSDT",
+	Message:   "RSI overbought",
+	Condition: "rsi crosses_above 70",
+	Price:     64250.5,
+	Time:      time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func TestWebhookPostsSignedPayload(t *testing.T) {
+	var got Payload
+	var sigOK bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get(TimestampHeader)
+		sigOK = r.Header.Get(SignatureHeader) == "sha256="+Sign([]byte("s3cret"), ts, body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	hook, err := NewWebhook(srv.URL, WithSecret("s3cret"))
+	if err != nil {
+		t.Fatalf("NewWebhook() error = %v", err)
+	}
+	if err := hook.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !sigOK {
+		t.Error("signature header does not match the body")
+	}
+	want := NewPayload(testAlert)
+	if got != want {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestWebhookRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	hook, _ := NewWebhook(srv.URL, WithRetry(3, time.Millisecond))
+	if err := hook.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+}
+
+func TestWebhookGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	hook, _ := NewWebhook(srv.URL, WithRetry(2, time.Millisecond))
+	if err := hook.Send(context.Background(), testAlert); err == nil || !strings.Contains(err.Error(), "attempt 2") {
+		t.Errorf("Send() error = %v, want failure after 2 attempts", err)
+	}
+
+	// Client errors are not retried
+	calls.Store(0)
+	status = http.StatusBadRequest
+	if err := hook.Send(context.Background(), testAlert); err == nil {
+		t.Error("Send() should fail on 400")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls on 400 = %d, want 1", n)
+	}
+}
+
+func TestWebhookChatFormats(t *testing.T) {
+	tests := []struct {
+		format Format
+		key    string
+		want   string
+	}{
+		{FormatSlack, "text", "*BTCUSDT* RSI overbought at 64250.5"},
+		{FormatDiscord, "content", "**BTCUSDT** RSI overbought at 64250.5"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var got map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&got)
+			}))
+			defer srv.Close()
+
+			hook, _ := NewWebhook(srv.URL, WithFormat(tt.format))
+			if err := hook.Send(context.Background(), testAlert); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if text := got[tt.key]; !strings.Contains(text, tt.want) || !strings.Contains(text, "`rsi crosses_above 70`") {
+				t.Errorf("%s = %q, want it to contain %q and the condition", tt.key, text, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWebhookValidates(t *testing.T) {
+	if _, err := NewWebhook("ftp://example.com"); err == nil {
+		t.Error("non-HTTP url should be rejected")
+	}
+	if _, err := NewWebhook("https://example.com", WithFormat("teams")); err == nil {
+		t.Error("unknown format should be rejected")
+	}
+	if _, err := ParseFormat("Slack"); err != nil {
+		t.Errorf("ParseFormat(Slack) error = %v", err)
+	}
+}
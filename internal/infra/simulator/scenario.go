@@ -0,0 +1,269 @@
+package simulator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventKind is what a scenario event does to the market.
+type EventKind int
+
+const (
+	// Move changes the price by Change, spread evenly over Over or at once.
+	Move EventKind = iota
+	// Shock changes the price by Change at once and reverts it over Over,
+	// as in a flash crash; a zero Over never reverts.
+	Shock
+	// Volatility multiplies the volatility by Factor for Over.
+	Volatility
+	// SetRegime switches to Regime; the market may switch again later.
+	SetRegime
+)
+
+// Event is one scripted action of a Scenario. Its times are offsets from the
+// simulator's start.
+type Event struct {
+	Kind   EventKind
+	At     time.Duration
+	Over   time.Duration
+	Change float64 // fractional price change, -0.08 for a drop of 8%
+	Factor float64
+	Regime Regime
+}
+
+// Scenario scripts events on top of a simulated market's random walk.
+//
+// A scenario file holds one event per line, each ending with the offset it
+// starts at; blank lines and lines starting with # are ignored:
+//
+//	drop 8% over 5m at 10m
+//	rise 3% over 90 seconds at t=20m
+//	crash 15% recover over 2m at 30m
+//	spike 5% at 40m
+//	volatility 3x for 10m at 45m
+//	regime calm at 1h
+//
+// Durations are Go durations such as 90s or 1h30m, or a number followed by
+// seconds, minutes or hours. drop and rise without over move the price at
+// once; crash and spike are shocks that recover over the given time, if any.
+type Scenario struct {
+	Events []Event // in start order
+}
+
+// LoadScenario reads a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open scenario: %w", err)
+	}
+	defer f.Close()
+	sc, err := ParseScenario(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sc, nil
+}
+
+// ParseScenario parses a scenario; see Scenario for the format.
+func ParseScenario(r io.Reader) (*Scenario, error) {
+	sc := &Scenario{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		event, err := parseEvent(strings.Fields(strings.ToLower(line)))
+		if err != nil {
+			return nil, fmt.Errorf("scenario line %d: %w", n, err)
+		}
+		sc.Events = append(sc.Events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	sort.SliceStable(sc.Events, func(i, j int) bool { return sc.Events[i].At < sc.Events[j].At })
+	return sc, nil
+}
+
+// parseEvent parses the fields of one scenario line.
+func parseEvent(fields []string) (Event, error) {
+	// Every event ends with "at <offset>"
+	i := len(fields) - 1
+	for i >= 0 && fields[i] != "at" {
+		i--
+	}
+	if i < 1 {
+		return Event{}, fmt.Errorf("%q: missing \"at <offset>\"", strings.Join(fields, " "))
+	}
+	at, err := parseDuration(fields[i+1:], "t=")
+	if err != nil {
+		return Event{}, fmt.Errorf("offset: %w", err)
+	}
+	event := Event{At: at}
+	action, args := fields[0], fields[1:i]
+
+	switch action {
+	case "drop", "rise", "crash", "spike":
+		if len(args) == 0 {
+			return Event{}, fmt.Errorf("%s: missing percentage", action)
+		}
+		pct, err := parsePercent(args[0])
+		if err != nil {
+			return Event{}, fmt.Errorf("%s: %w", action, err)
+		}
+		if action == "drop" || action == "crash" {
+			if pct >= 100 {
+				return Event{}, fmt.Errorf("%s: cannot lose %g%%", action, pct)
+			}
+			pct = -pct
+		}
+		event.Change = pct / 100
+		event.Kind = Move
+		keyword := "over"
+		if action == "crash" || action == "spike" {
+			event.Kind = Shock
+			keyword = "recover"
+		}
+		if args = args[1:]; len(args) > 0 {
+			if args[0] != keyword {
+				return Event{}, fmt.Errorf("%s: unexpected %q, want %q", action, args[0], keyword)
+			}
+			args = args[1:]
+			if event.Kind == Shock && len(args) > 0 && args[0] == "over" {
+				args = args[1:]
+			}
+			if event.Over, err = parseDuration(args, ""); err != nil {
+				return Event{}, fmt.Errorf("%s: %w", action, err)
+			}
+		}
+	case "volatility":
+		if len(args) < 3 || args[1] != "for" {
+			return Event{}, fmt.Errorf("volatility: want \"volatility <factor>x for <duration>\"")
+		}
+		factor, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "x"), 64)
+		if err != nil || factor < 0 {
+			return Event{}, fmt.Errorf("volatility: invalid factor %q", args[0])
+		}
+		event.Kind, event.Factor = Volatility, factor
+		if event.Over, err = parseDuration(args[2:], ""); err != nil {
+			return Event{}, fmt.Errorf("volatility: %w", err)
+		}
+	case "regime":
+		if len(args) != 1 {
+			return Event{}, fmt.Errorf("regime: want \"regime calm|normal|turbulent\"")
+		}
+		event.Kind = SetRegime
+		event.Regime = -1
+		for r, name := range regimeNames {
+			if args[0] == name {
+				event.Regime = Regime(r)
+			}
+		}
+		if event.Regime < 0 {
+			return Event{}, fmt.Errorf("regime: unknown regime %q", args[0])
+		}
+	default:
+		return Event{}, fmt.Errorf("unknown action %q (want drop, rise, crash, spike, volatility or regime)", action)
+	}
+	return event, nil
+}
+
+// parsePercent parses a positive percentage such as "8%".
+func parsePercent(s string) (float64, error) {
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || !strings.HasSuffix(s, "%") || pct <= 0 {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	return pct, nil
+}
+
+// durationUnits are the unit words parseDuration accepts after a number.
+var durationUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+}
+
+// parseDuration parses a non-negative duration written as one Go duration,
+// "5m", or as a number and a unit, "5 minutes", after an optional prefix.
+func parseDuration(fields []string, prefix string) (time.Duration, error) {
+	switch len(fields) {
+	case 1:
+		s := strings.TrimPrefix(fields[0], prefix)
+		if s == "0" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid duration %q", fields[0])
+		}
+		return d, nil
+	case 2:
+		n, err := strconv.ParseFloat(strings.TrimPrefix(fields[0], prefix), 64)
+		unit, ok := durationUnits[fields[1]]
+		if err != nil || !ok || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", strings.Join(fields, " "))
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+	return 0, fmt.Errorf("invalid duration %q", strings.Join(fields, " "))
+}
+
+// effects returns what the scenario does over the step [from, from+step): the
+// log return it adds, the factor it scales volatility by, and the regime it
+// switches to, if any.
+func (sc *Scenario) effects(from, step time.Duration) (logReturn, factor float64, regime *Regime) {
+	factor = 1
+	to := from + step
+	for i := range sc.Events {
+		e := &sc.Events[i]
+		if e.At >= to {
+			break
+		}
+		starts := e.At >= from
+		switch e.Kind {
+		case Move:
+			logReturn += math.Log1p(e.Change) * spread(e.At, e.Over, from, to, starts)
+		case Shock:
+			if starts {
+				logReturn += math.Log1p(e.Change)
+			}
+			if e.Over > 0 {
+				logReturn -= math.Log1p(e.Change) * spread(e.At, e.Over, from, to, false)
+			}
+		case Volatility:
+			if from < e.At+e.Over {
+				factor *= e.Factor
+			}
+		case SetRegime:
+			if starts {
+				regime = &e.Regime
+			}
+		}
+	}
+	return logReturn, factor, regime
+}
+
+// spread returns the share of [at, at+over) that falls in [from, to); an
+// instant event, over zero, counts fully in the step it starts in.
+func spread(at, over, from, to time.Duration, starts bool) float64 {
+	if over <= 0 {
+		if starts {
+			return 1
+		}
+		return 0
+	}
+	overlap := min(to, at+over) - max(from, at)
+	if overlap <= 0 {
+		return 0
+	}
+	return float64(overlap) / float64(over)
+}
@@ -0,0 +1,94 @@
+package simulator
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScenario(t *testing.T) {
+	sc, err := ParseScenario(strings.NewReader(`
+# a bad afternoon
+volatility 3x for 10m at 45m
+drop 8% over 5 minutes at t=10m
+rise 3% at 1h30m
+crash 15% recover over 2m at 30m
+spike 5% at 40 minutes
+regime calm at 2h
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario() error = %v", err)
+	}
+	want := []Event{
+		{Kind: Move, At: 10 * time.Minute, Over: 5 * time.Minute, Change: -0.08},
+		{Kind: Shock, At: 30 * time.Minute, Over: 2 * time.Minute, Change: -0.15},
+		{Kind: Shock, At: 40 * time.Minute, Change: 0.05},
+		{Kind: Volatility, At: 45 * time.Minute, Over: 10 * time.Minute, Factor: 3},
+		{Kind: Move, At: 90 * time.Minute, Change: 0.03},
+		{Kind: SetRegime, At: 2 * time.Hour, Regime: Calm},
+	}
+	if len(sc.Events) != len(want) {
+		t.Fatalf("events = %+v, want %d", sc.Events, len(want))
+	}
+	for i, e := range sc.Events {
+		w := want[i]
+		if e.Kind != w.Kind || e.At != w.At || e.Over != w.Over || math.Abs(e.Change-w.Change) > 1e-12 || e.Factor != w.Factor || e.Regime != w.Regime {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+	}
+
+	for _, line := range []string{
+		"drop 8% over 5m",
+		"drop 8 over 5m at 1m",
+		"drop 100% at 1m",
+		"drop 8% during 5m at 1m",
+		"rise 3% over soon at 1m",
+		"crash 5% over 1m at 1m",
+		"volatility 3x at 1m",
+		"volatility fast for 1m at 1m",
+		"regime wild at 1m",
+		"halt for 1m at 1m",
+		"spike 5% at -1m",
+	} {
+		_, err := ParseScenario(strings.NewReader("# header\n" + line))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("ParseScenario(%q) error = %v, want an error on line 2", line, err)
+		}
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.txt")
+	if err := os.WriteFile(path, []byte("drop 8% over 5m at 10m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScenario(path)
+	if err != nil || len(sc.Events) != 1 {
+		t.Fatalf("LoadScenario() = %+v, %v", sc, err)
+	}
+	if _, err := LoadScenario(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadScenario() should fail for a missing file")
+	}
+}
+
+func TestScenarioVolatility(t *testing.T) {
+	sc, err := ParseScenario(strings.NewReader("volatility 3x for 1h at 1h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New("btcusdt", Config{Seed: 11, StartPrice: 100, Volatility: 0.6, Step: time.Minute, Scenario: sc})
+	last := s.Next().Price
+	var sums [3]float64 // squared returns per hour
+	for i := 0; i < 3*60; i++ {
+		p := s.Next().Price
+		r := math.Log(p / last)
+		sums[i/60] += r * r
+		last = p
+	}
+	if sums[1] < 4*sums[0] || sums[1] < 4*sums[2] {
+		t.Errorf("squared returns per hour = %v, want the second hour about 9 times the others", sums)
+	}
+}
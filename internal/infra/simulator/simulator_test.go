@@ -0,0 +1,171 @@
+package simulator
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func prices(s *Simulator, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = s.Next().Price
+	}
+	return out
+}
+
+func TestSimulatorIsDeterministic(t *testing.T) {
+	cfg := DefaultConfig("btcusdt")
+	cfg.Start = start
+	a, b := prices(New("btcusdt", cfg), 500), prices(New("btcusdt", cfg), 500)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("tick %d: %v != %v with the same seed", i, a[i], b[i])
+		}
+	}
+
+	cfg.Seed++
+	c := prices(New("btcusdt", cfg), 500)
+	if a[499] == c[499] {
+		t.Error("different seeds should take different paths")
+	}
+	if DefaultConfig("btcusdt").Seed == DefaultConfig("ethusdt").Seed {
+		t.Error("symbols should have their own seeds")
+	}
+}
+
+func TestSimulatorTicks(t *testing.T) {
+	cfg := DefaultConfig("ethusdt")
+	cfg.Start, cfg.Step = start, 250*time.Millisecond
+	s := New("ethusdt", cfg)
+
+	first := s.Next()
+	if first.Symbol != "ETHUSDT" || first.Price != 2500 || !first.Timestamp.Equal(start) || !first.IsTrade {
+		t.Errorf("first tick = %+v, want ETHUSDT at 2500 at the start", first)
+	}
+	var sizes []float64
+	for i := 1; i <= 4000; i++ {
+		tick := s.Next()
+		if want := start.Add(time.Duration(i) * 250 * time.Millisecond); !tick.Timestamp.Equal(want) {
+			t.Fatalf("tick %d at %v, want %v", i, tick.Timestamp, want)
+		}
+		if tick.Price <= 0 || tick.Volume <= 0 {
+			t.Fatalf("tick %d = %+v", i, tick)
+		}
+		sizes = append(sizes, tick.Price*tick.Volume)
+	}
+	sort.Float64s(sizes)
+	if median := sizes[len(sizes)/2]; median < 4000 || median > 6000 {
+		t.Errorf("median trade notional = %.0f, want about 5000", median)
+	}
+	if sizes[len(sizes)-1] < 10*sizes[len(sizes)/2] {
+		t.Error("trade sizes should have a heavy right tail")
+	}
+}
+
+func TestSimulatorVolatility(t *testing.T) {
+	// Realised volatility of one day of minute steps
+	realised := func(cfg Config) float64 {
+		cfg.Step = time.Minute
+		s := New("btcusdt", cfg)
+		last := s.Next().Price
+		var sum float64
+		n := 24 * 60
+		for i := 0; i < n; i++ {
+			p := s.Next().Price
+			r := math.Log(p / last)
+			sum += r * r
+			last = p
+		}
+		return math.Sqrt(sum / float64(n) * float64(year/time.Minute))
+	}
+
+	if got := realised(Config{StartPrice: 100}); got != 0 {
+		t.Errorf("volatility without noise = %v, want 0", got)
+	}
+	got := realised(Config{Seed: 7, StartPrice: 100, Volatility: 0.6})
+	if got < 0.5 || got > 0.7 {
+		t.Errorf("realised volatility = %.2f, want about 0.6", got)
+	}
+	jumpy := realised(Config{Seed: 7, StartPrice: 100, Volatility: 0.6, JumpsPerDay: 50, JumpSize: 0.01})
+	if jumpy <= got {
+		t.Errorf("jumps should add volatility: %.2f <= %.2f", jumpy, got)
+	}
+}
+
+func TestSimulatorRegimes(t *testing.T) {
+	s := New("btcusdt", Config{Seed: 3, StartPrice: 100, Volatility: 0.6, RegimeDuration: time.Hour, Step: time.Minute})
+	seen := map[Regime]int{}
+	for i := 0; i < 7*24*60; i++ {
+		s.Next()
+		seen[s.Regime()]++
+	}
+	for _, r := range []Regime{Calm, Normal, Turbulent} {
+		if seen[r] == 0 {
+			t.Errorf("a week of hourly regimes never was %v: %v", r, seen)
+		}
+	}
+
+	s = New("btcusdt", Config{StartPrice: 100, Volatility: 0.6, Step: time.Minute})
+	for i := 0; i < 24*60; i++ {
+		s.Next()
+	}
+	if s.Regime() != Normal {
+		t.Errorf("regime = %v without switching, want normal", s.Regime())
+	}
+}
+
+func TestSimulatorScenario(t *testing.T) {
+	sc, err := ParseScenario(strings.NewReader(`
+		drop 8% over 5 minutes at t=10m
+		crash 10% recover over 2m at 20m
+		regime turbulent at 30m
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New("btcusdt", Config{StartPrice: 1000, Start: start, Scenario: sc})
+
+	at := func(offset time.Duration) float64 {
+		for {
+			tick := s.Next()
+			if !tick.Timestamp.Before(start.Add(offset)) {
+				return tick.Price
+			}
+		}
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-6 }
+
+	if got := at(10 * time.Minute); got != 1000 {
+		t.Errorf("price before the drop = %v, want 1000", got)
+	}
+	if got, want := at(12*time.Minute+30*time.Second), 1000*math.Sqrt(0.92); !near(got, want) {
+		t.Errorf("price halfway through the drop = %v, want %v", got, want)
+	}
+	if got := at(15 * time.Minute); !near(got, 920) {
+		t.Errorf("price after the drop = %v, want 920", got)
+	}
+	// The crash's first second also starts its recovery
+	if got, want := at(20*time.Minute+time.Second), 828*math.Pow(1/0.9, 1.0/120); !near(got, want) {
+		t.Errorf("price after the crash = %v, want %v", got, want)
+	}
+	if got := at(22 * time.Minute); !near(got, 920) {
+		t.Errorf("price after the recovery = %v, want 920", got)
+	}
+	at(30*time.Minute + time.Second)
+	if s.Regime() != Turbulent {
+		t.Errorf("regime = %v, want turbulent", s.Regime())
+	}
+}
+
+func TestStartPrice(t *testing.T) {
+	for symbol, want := range map[string]float64{"btcusdt": 48000, "ETHUSD": 2500, "solusdc": 100, "xyzusdt": 100, "ethbtc": 100} {
+		if got := StartPrice(symbol); got != want {
+			t.Errorf("StartPrice(%q) = %v, want %v", symbol, got, want)
+		}
+	}
+}
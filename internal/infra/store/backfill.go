@@ -0,0 +1,51 @@
+package store
+
+import (
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+)
+
+// Backfill returns the last n closed candles of symbol on an interval of the
+// given length followed by the bar open at now, rebuilt from recorded ticks.
+// ok is false unless the store holds n gap-free candles ending right before
+// the current bar, in which case callers should fetch history elsewhere.
+// The live bar only reflects ticks recorded while the server was running.
+func (s *Store) Backfill(symbol, interval string, length time.Duration, n int, now time.Time) (history []candles.Candle, ok bool, err error) {
+	if s == nil || n <= 0 || length <= 0 {
+		return nil, false, nil
+	}
+	current := candles.OpenTime(now, length)
+	closed, err := s.LastCandles(symbol, interval, n, current)
+	if err != nil || len(closed) < n {
+		return nil, false, err
+	}
+	if !closed[len(closed)-1].OpenTime.Equal(current.Add(-length)) {
+		return nil, false, nil
+	}
+	for i := 1; i < len(closed); i++ {
+		if !closed[i].OpenTime.Equal(closed[i-1].OpenTime.Add(length)) {
+			return nil, false, nil
+		}
+	}
+
+	ticks, err := s.Ticks(symbol, current, now.Add(time.Millisecond))
+	if err != nil {
+		return nil, false, err
+	}
+	builder, err := candles.NewBuilder(length)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, t := range ticks {
+		var volume float64
+		if t.Trade {
+			volume = t.Volume
+		}
+		builder.Add(t.Price, volume, t.Time)
+	}
+	if live, ok := builder.Live(); ok {
+		closed = append(closed, live)
+	}
+	return closed, true, nil
+}
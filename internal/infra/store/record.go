@@ -0,0 +1,63 @@
+package store
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+)
+
+// Record layouts, little-endian, times in Unix milliseconds:
+//
+//	tick:   time int64 | price float64 | volume float64 | flags uint8
+//	candle: open time int64 | close time int64 | open, high, low, close, volume float64
+const (
+	tickSize   = 8 + 8 + 8 + 1
+	candleSize = 8 + 8 + 5*8
+
+	flagTrade = 1 << 0
+)
+
+func encodeTick(t Tick) []byte {
+	rec := make([]byte, tickSize)
+	binary.LittleEndian.PutUint64(rec[0:], uint64(t.Time.UnixMilli()))
+	binary.LittleEndian.PutUint64(rec[8:], math.Float64bits(t.Price))
+	binary.LittleEndian.PutUint64(rec[16:], math.Float64bits(t.Volume))
+	if t.Trade {
+		rec[24] = flagTrade
+	}
+	return rec
+}
+
+func decodeTick(rec []byte) Tick {
+	return Tick{
+		Time:   time.UnixMilli(int64(binary.LittleEndian.Uint64(rec[0:]))),
+		Price:  math.Float64frombits(binary.LittleEndian.Uint64(rec[8:])),
+		Volume: math.Float64frombits(binary.LittleEndian.Uint64(rec[16:])),
+		Trade:  rec[24]&flagTrade != 0,
+	}
+}
+
+func encodeCandle(c candles.Candle) []byte {
+	rec := make([]byte, candleSize)
+	binary.LittleEndian.PutUint64(rec[0:], uint64(c.OpenTime.UnixMilli()))
+	binary.LittleEndian.PutUint64(rec[8:], uint64(c.CloseTime.UnixMilli()))
+	for i, v := range []float64{c.Open, c.High, c.Low, c.Close, c.Volume} {
+		binary.LittleEndian.PutUint64(rec[16+8*i:], math.Float64bits(v))
+	}
+	return rec
+}
+
+func decodeCandle(rec []byte) candles.Candle {
+	f := func(i int) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(rec[16+8*i:])) }
+	return candles.Candle{
+		OpenTime:  time.UnixMilli(int64(binary.LittleEndian.Uint64(rec[0:]))),
+		CloseTime: time.UnixMilli(int64(binary.LittleEndian.Uint64(rec[8:]))),
+		Open:      f(0),
+		High:      f(1),
+		Low:       f(2),
+		Close:     f(3),
+		Volume:    f(4),
+	}
+}
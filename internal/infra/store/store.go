@@ -0,0 +1,419 @@
+// Package store persists price ticks and closed candles per symbol in
+// append-only segment files, one file per UTC day:
+//
+//	<dir>/<symbol>/ticks/20240301.seg
+//	<dir>/<symbol>/candles/<interval>/20240301.seg
+//
+// Records have a fixed size, so a record torn by a crash is detected and
+// dropped. Retention removes whole segments once their day has expired.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+)
+
+const (
+	// DefaultTickRetention is how long raw ticks are kept when no retention is set.
+	DefaultTickRetention = 7 * 24 * time.Hour
+	// DefaultCandleRetention is how long closed candles are kept when no retention is set.
+	DefaultCandleRetention = 365 * 24 * time.Hour
+
+	segmentExt = ".seg"
+	dayLayout  = "20060102"
+	day        = 24 * time.Hour
+)
+
+// Options configures retention. A zero retention uses the default; a negative
+// one keeps data forever.
+type Options struct {
+	TickRetention   time.Duration
+	CandleRetention time.Duration
+}
+
+// Tick is a price update as recorded by the store.
+type Tick struct {
+	Time   time.Time
+	Price  float64
+	Volume float64
+	Trade  bool // Volume is a traded quantity rather than a 24h total
+}
+
+// Store reads and appends segment files under one directory. It is safe for
+// concurrent use; a nil *Store stores nothing and holds no history.
+type Store struct {
+	dir  string
+	opts Options
+
+	mu       sync.Mutex
+	segments map[string]*segment  // open append segment per series directory
+	lastOpen map[string]time.Time // newest stored candle per candle series
+}
+
+// segment is the file currently appended to for one series.
+type segment struct {
+	day  string
+	file *os.File
+}
+
+// Open opens or creates a store in dir.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.TickRetention == 0 {
+		opts.TickRetention = DefaultTickRetention
+	}
+	if opts.CandleRetention == 0 {
+		opts.CandleRetention = DefaultCandleRetention
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &Store{
+		dir:      dir,
+		opts:     opts,
+		segments: make(map[string]*segment),
+		lastOpen: make(map[string]time.Time),
+	}, nil
+}
+
+// Dir returns the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Close closes every open segment.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for key, seg := range s.segments {
+		errs = append(errs, seg.file.Close())
+		delete(s.segments, key)
+	}
+	return errors.Join(errs...)
+}
+
+// AppendTick records a tick for symbol.
+func (s *Store) AppendTick(symbol string, t Tick) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(s.tickDir(symbol), t.Time, encodeTick(t))
+}
+
+// AppendCandles records closed candles of symbol on interval, oldest first.
+// Candles not newer than the last stored one are skipped, so several streams
+// may record the same series.
+func (s *Store) AppendCandles(symbol, interval string, cs ...candles.Candle) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.candleDir(symbol, interval)
+	last, ok := s.lastOpen[dir]
+	if !ok {
+		if c, found, err := s.lastCandle(dir); err != nil {
+			return err
+		} else if found {
+			last = c.OpenTime
+		}
+	}
+	for _, c := range cs {
+		if !c.OpenTime.After(last) {
+			continue
+		}
+		if err := s.append(dir, c.OpenTime, encodeCandle(c)); err != nil {
+			return err
+		}
+		last = c.OpenTime
+	}
+	s.lastOpen[dir] = last
+	return nil
+}
+
+// Ticks returns the ticks of symbol with from <= Time < to, oldest first.
+func (s *Store) Ticks(symbol string, from, to time.Time) ([]Tick, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var out []Tick
+	err := s.scan(s.tickDir(symbol), from, to, tickSize, func(rec []byte) bool {
+		if t := decodeTick(rec); !t.Time.Before(from) && t.Time.Before(to) {
+			out = append(out, t)
+		}
+		return true
+	})
+	return out, err
+}
+
+// Candles returns the candles of symbol on interval with from <= OpenTime < to, oldest first.
+func (s *Store) Candles(symbol, interval string, from, to time.Time) ([]candles.Candle, error) {
+	if s == nil {
+		return nil, nil
+	}
+	var out []candles.Candle
+	err := s.scan(s.candleDir(symbol, interval), from, to, candleSize, func(rec []byte) bool {
+		if c := decodeCandle(rec); !c.OpenTime.Before(from) && c.OpenTime.Before(to) {
+			out = append(out, c)
+		}
+		return true
+	})
+	return out, err
+}
+
+// LastCandles returns up to n of the newest candles of symbol on interval
+// that opened before before, oldest first.
+func (s *Store) LastCandles(symbol, interval string, n int, before time.Time) ([]candles.Candle, error) {
+	if s == nil || n <= 0 {
+		return nil, nil
+	}
+	days, err := s.segmentDays(s.candleDir(symbol, interval))
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk segments newest first until n candles are collected
+	var out []candles.Candle
+	for i := len(days) - 1; i >= 0 && len(out) < n; i-- {
+		if !days[i].start.Before(before) {
+			continue
+		}
+		data, err := readSegment(days[i].path, candleSize)
+		if err != nil {
+			return nil, err
+		}
+		var seg []candles.Candle
+		for off := 0; off < len(data); off += candleSize {
+			if c := decodeCandle(data[off : off+candleSize]); c.OpenTime.Before(before) {
+				seg = append(seg, c)
+			}
+		}
+		out = append(seg, out...)
+	}
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out, nil
+}
+
+// Symbols returns the symbols with stored data.
+func (s *Store) Symbols() ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		if e.IsDir() {
+			out = append(out, e.Name())
+		}
+	}
+	return out, nil
+}
+
+// Prune deletes segments whose whole day lies outside the retention period at now.
+func (s *Store) Prune(now time.Time) (removed int, err error) {
+	if s == nil {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	symbols, err := s.Symbols()
+	if err != nil {
+		return 0, err
+	}
+	for _, symbol := range symbols {
+		dirs := []string{s.tickDir(symbol)}
+		retentions := []time.Duration{s.opts.TickRetention}
+		intervals, _ := os.ReadDir(filepath.Join(s.dir, symbol, "candles"))
+		for _, e := range intervals {
+			if e.IsDir() {
+				dirs = append(dirs, s.candleDir(symbol, e.Name()))
+				retentions = append(retentions, s.opts.CandleRetention)
+			}
+		}
+
+		for i, dir := range dirs {
+			if retentions[i] < 0 {
+				continue
+			}
+			cutoff := now.Add(-retentions[i])
+			days, err := s.segmentDays(dir)
+			if err != nil {
+				return removed, err
+			}
+			for _, d := range days {
+				if d.start.Add(day).After(cutoff) {
+					break
+				}
+				if seg, ok := s.segments[dir]; ok && seg.day == d.name {
+					seg.file.Close()
+					delete(s.segments, dir)
+				}
+				if err := os.Remove(d.path); err != nil {
+					return removed, fmt.Errorf("remove segment: %w", err)
+				}
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+func (s *Store) tickDir(symbol string) string {
+	return filepath.Join(s.dir, strings.ToLower(symbol), "ticks")
+}
+
+func (s *Store) candleDir(symbol, interval string) string {
+	return filepath.Join(s.dir, strings.ToLower(symbol), "candles", interval)
+}
+
+// append writes one record to the day segment of ts in dir. Must be called with s.mu held.
+func (s *Store) append(dir string, ts time.Time, rec []byte) error {
+	name := ts.UTC().Format(dayLayout)
+	seg, ok := s.segments[dir]
+	if !ok || seg.day != name {
+		if ok {
+			seg.file.Close()
+			delete(s.segments, dir)
+		}
+		file, err := openSegment(filepath.Join(dir, name+segmentExt), len(rec))
+		if err != nil {
+			return err
+		}
+		seg = &segment{day: name, file: file}
+		s.segments[dir] = seg
+	}
+	if _, err := seg.file.Write(rec); err != nil {
+		return fmt.Errorf("append to segment: %w", err)
+	}
+	return nil
+}
+
+// lastCandle returns the newest candle stored in dir.
+func (s *Store) lastCandle(dir string) (candles.Candle, bool, error) {
+	days, err := s.segmentDays(dir)
+	if err != nil {
+		return candles.Candle{}, false, err
+	}
+	for i := len(days) - 1; i >= 0; i-- {
+		data, err := readSegment(days[i].path, candleSize)
+		if err != nil {
+			return candles.Candle{}, false, err
+		}
+		if len(data) > 0 {
+			return decodeCandle(data[len(data)-candleSize:]), true, nil
+		}
+	}
+	return candles.Candle{}, false, nil
+}
+
+// scan calls fn with every record of the segments in dir that may hold times in [from, to).
+func (s *Store) scan(dir string, from, to time.Time, size int, fn func(rec []byte) bool) error {
+	days, err := s.segmentDays(dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range days {
+		if !d.start.Before(to) || !d.start.Add(day).After(from) {
+			continue
+		}
+		data, err := readSegment(d.path, size)
+		if err != nil {
+			return err
+		}
+		for off := 0; off < len(data); off += size {
+			if !fn(data[off : off+size]) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+type segmentDay struct {
+	name  string
+	start time.Time
+	path  string
+}
+
+// segmentDays lists the segments in dir, oldest first.
+func (s *Store) segmentDays(dir string) ([]segmentDay, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var days []segmentDay
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		start, err := time.Parse(dayLayout, name)
+		if err != nil {
+			continue
+		}
+		days = append(days, segmentDay{name: name, start: start, path: filepath.Join(dir, e.Name())})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].start.Before(days[j].start) })
+	return days, nil
+}
+
+// openSegment opens a segment for appending, first cutting off a record left
+// incomplete by a crash so new records stay aligned.
+func openSegment(path string, size int) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create segment dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open segment: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("stat segment: %w", err)
+	}
+	if torn := info.Size() % int64(size); torn != 0 {
+		if err := file.Truncate(info.Size() - torn); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("truncate torn record: %w", err)
+		}
+	}
+	return file, nil
+}
+
+// readSegment reads a segment, dropping a trailing incomplete record.
+func readSegment(path string, size int) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read segment: %w", err)
+	}
+	return data[:len(data)-len(data)%size], nil
+}
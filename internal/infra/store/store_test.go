@@ -0,0 +1,178 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rp4ri/quantacode/internal/domain/candles"
+)
+
+var t0 = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func openTest(t *testing.T, opts Options) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func hourly(start time.Time, n int) []candles.Candle {
+	cs := make([]candles.Candle, n)
+	for i := range cs {
+		open := start.Add(time.Duration(i) * time.Hour)
+		p := 100 + float64(i)
+		cs[i] = candles.Candle{OpenTime: open, Open: p, High: p + 1, Low: p - 1, Close: p + 0.5, Volume: 10, CloseTime: open.Add(time.Hour - time.Millisecond)}
+	}
+	return cs
+}
+
+func TestTicksRoundTripAcrossDays(t *testing.T) {
+	s := openTest(t, Options{})
+	ticks := []Tick{
+		{Time: t0.Add(23 * time.Hour), Price: 100, Volume: 0.5, Trade: true},
+		{Time: t0.Add(24*time.Hour + time.Second), Price: 101, Volume: 1200},
+		{Time: t0.Add(25 * time.Hour), Price: 102, Volume: 0.1, Trade: true},
+	}
+	for _, tk := range ticks {
+		if err := s.AppendTick("BTCUSDT", tk); err != nil {
+			t.Fatalf("AppendTick() error = %v", err)
+		}
+	}
+
+	got, err := s.Ticks("btcusdt", t0.Add(23*time.Hour), t0.Add(25*time.Hour))
+	if err != nil {
+		t.Fatalf("Ticks() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Ticks() = %d ticks, want 2", len(got))
+	}
+	for i, tk := range got {
+		if !tk.Time.Equal(ticks[i].Time) || tk.Price != ticks[i].Price || tk.Volume != ticks[i].Volume || tk.Trade != ticks[i].Trade {
+			t.Errorf("tick %d = %+v, want %+v", i, tk, ticks[i])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir(), "btcusdt", "ticks", "20240302.seg")); err != nil {
+		t.Errorf("expected a second day segment: %v", err)
+	}
+}
+
+func TestAppendCandlesSkipsStored(t *testing.T) {
+	s := openTest(t, Options{})
+	cs := hourly(t0, 30)
+	if err := s.AppendCandles("ethusdt", "1h", cs[:20]...); err != nil {
+		t.Fatal(err)
+	}
+	// A second stream re-recording overlapping bars, and a reopened store
+	if err := s.AppendCandles("ethusdt", "1h", cs[10:25]...); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	reopened, _ := Open(s.Dir(), Options{})
+	defer reopened.Close()
+	if err := reopened.AppendCandles("ethusdt", "1h", cs...); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := reopened.Candles("ethusdt", "1h", t0, t0.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 30 {
+		t.Fatalf("Candles() = %d, want 30", len(got))
+	}
+	for i, c := range got {
+		if !c.OpenTime.Equal(cs[i].OpenTime) || !c.CloseTime.Equal(cs[i].CloseTime) || c.High != cs[i].High || c.Close != cs[i].Close {
+			t.Errorf("candle %d = %+v, want %+v", i, c, cs[i])
+		}
+	}
+
+	last, _ := reopened.LastCandles("ethusdt", "1h", 5, t0.Add(26*time.Hour))
+	if len(last) != 5 || !last[4].OpenTime.Equal(t0.Add(25*time.Hour)) {
+		t.Errorf("LastCandles() = %d candles ending %v", len(last), last[len(last)-1].OpenTime)
+	}
+}
+
+func TestTornRecordIsDropped(t *testing.T) {
+	s := openTest(t, Options{})
+	s.AppendTick("solusdt", Tick{Time: t0, Price: 1})
+	s.Close()
+
+	path := filepath.Join(s.Dir(), "solusdt", "ticks", "20240301.seg")
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	f.Write([]byte{1, 2, 3})
+	f.Close()
+
+	s2, _ := Open(s.Dir(), Options{})
+	defer s2.Close()
+	s2.AppendTick("solusdt", Tick{Time: t0.Add(time.Second), Price: 2})
+	got, _ := s2.Ticks("solusdt", t0, t0.Add(time.Hour))
+	if len(got) != 2 || got[0].Price != 1 || got[1].Price != 2 {
+		t.Errorf("Ticks() = %+v, want prices 1 and 2", got)
+	}
+}
+
+func TestPruneRemovesExpiredDays(t *testing.T) {
+	s := openTest(t, Options{TickRetention: 24 * time.Hour, CandleRetention: -1})
+	for d := 0; d < 3; d++ {
+		s.AppendTick("btcusdt", Tick{Time: t0.Add(time.Duration(d) * day), Price: 1})
+	}
+	s.AppendCandles("btcusdt", "1h", hourly(t0, 72)...)
+
+	removed, err := s.Prune(t0.Add(3 * day))
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune() removed %d segments, want 2", removed)
+	}
+	ticks, _ := s.Ticks("btcusdt", t0, t0.Add(4*day))
+	if len(ticks) != 1 {
+		t.Errorf("ticks left = %d, want 1", len(ticks))
+	}
+	if cs, _ := s.Candles("btcusdt", "1h", t0, t0.Add(4*day)); len(cs) != 72 {
+		t.Errorf("candles left = %d, want 72 with unlimited retention", len(cs))
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	s := openTest(t, Options{})
+	s.AppendCandles("btcusdt", "1h", hourly(t0, 10)...)
+	now := t0.Add(10*time.Hour + 20*time.Minute)
+	s.AppendTick("btcusdt", Tick{Time: t0.Add(10*time.Hour + time.Minute), Price: 120, Volume: 2, Trade: true})
+	s.AppendTick("btcusdt", Tick{Time: t0.Add(10*time.Hour + 5*time.Minute), Price: 118, Volume: 999})
+
+	history, ok, err := s.Backfill("btcusdt", "1h", time.Hour, 8, now)
+	if err != nil || !ok {
+		t.Fatalf("Backfill() ok = %v, err = %v", ok, err)
+	}
+	if len(history) != 9 {
+		t.Fatalf("Backfill() = %d candles, want 8 closed + live", len(history))
+	}
+	live := history[8]
+	if live.Open != 120 || live.Close != 118 || live.Low != 118 || live.Volume != 2 {
+		t.Errorf("live bar = %+v", live)
+	}
+
+	// Not enough stored bars, or a bar missing before now
+	if _, ok, _ := s.Backfill("btcusdt", "1h", time.Hour, 11, now); ok {
+		t.Error("Backfill() should fail with fewer stored bars than requested")
+	}
+	if _, ok, _ := s.Backfill("btcusdt", "1h", time.Hour, 8, now.Add(time.Hour)); ok {
+		t.Error("Backfill() should fail when the latest closed bar is missing")
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	if err := s.AppendTick("btcusdt", Tick{}); err != nil {
+		t.Error(err)
+	}
+	if _, ok, _ := s.Backfill("btcusdt", "1h", time.Hour, 10, t0); ok {
+		t.Error("nil store should not backfill")
+	}
+}
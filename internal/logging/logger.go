@@ -186,6 +186,16 @@ func (l *Logger) LogPairSwitch(oldPair, newPair string) {
 	}, nil, nil, 0)
 }
 
+// LogAlert logs alerts delivered by the server
+func (l *Logger) LogAlert(ruleID, symbol, message string, price float64) {
+	l.log(INFO, "Alert", map[string]interface{}{
+		"rule":    ruleID,
+		"symbol":  symbol,
+		"message": message,
+		"price":   price,
+	}, nil, nil, 0)
+}
+
 func Close() {
 	if globalLogger != nil && globalLogger.file != nil {
 		globalLogger.file.Close()
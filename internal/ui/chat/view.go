@@ -4,6 +4,7 @@ import (
     "context"
     "fmt"
     "math"
+    "os"
     "strings"
     "time"
 
@@ -14,6 +15,7 @@ import (
 
     "github.com/rp4ri/quantacode/internal/ai/openrouter"
     domainindicators "github.com/rp4ri/quantacode/internal/domain/indicators"
+    "github.com/rp4ri/quantacode/internal/domain/orderbook"
     grpcclient "github.com/rp4ri/quantacode/internal/grpc/client"
     "github.com/rp4ri/quantacode/internal/logging"
     indicatorpanel "github.com/rp4ri/quantacode/internal/ui/indicators"
@@ -33,6 +35,8 @@ var availablePairs = []string{
     "avaxusdt", "linkusdt", "atomusdt", "uniusdt", "xlmusdt",
 }
 
+var availableTimeframes = []string{"1m", "5m", "15m", "30m", "1h", "4h", "1d"}
+
 type slashCommand struct {
     name        string
     description string
@@ -41,12 +45,19 @@ type slashCommand struct {
 var slashCommands = []slashCommand{
     {name: "/clear", description: "Limpiar historial del chat"},
     {name: "/pairs", description: "Cambiar par de trading"},
+    {name: "/timeframe", description: "Cambiar temporalidad (ej. /timeframe 5m)"},
 }
 
 // Config contains runtime configuration for the chat UI.
 type Config struct {
     ServerAddr    string
     Symbol        string
+    Interval      string
+    Indicators    []string // extra registry indicators, e.g. "ema:50"
+    Rules         []string // signal rules evaluated by the server, e.g. "oversold: rsi(14) < 30"
+    Alerts        []grpcclient.AlertRule
+    DepthLevels   int    // order book levels per side shown in the panel, 0 to hide it
+    Exchange      string // exchange to stream from, empty for the server's default
     OpenRouterKey string
 }
 
@@ -58,7 +69,10 @@ func Run(ctx context.Context, cfg Config) error {
     logger := logging.GetLogger("chat-ui")
     logger.Info("Starting chat UI")
 
-    panel := indicatorpanel.NewPanel()
+    if cfg.Interval == "" {
+        cfg.Interval = grpcclient.DefaultStreamConfig().Interval
+    }
+    panel := indicatorpanel.NewPanel().WithInterval(cfg.Interval)
     m := newModel(cfg, panel)
     m.programCtx = ctx // Store program context for cancellation propagation
 
@@ -84,12 +98,18 @@ type model struct {
     prevPrice    float64
 
     indicatorValues domainindicators.AggregatedValues
+    customValues    map[string]float64
     panel           indicatorpanel.Panel
 
     grpcClient  *grpcclient.Client
+    session     *grpcclient.Session
     connected   bool
     priceCh     chan grpcclient.PriceUpdate
     indicatorCh chan grpcclient.IndicatorUpdate
+    alertCh     chan grpcclient.Alert
+    bookCh      chan grpcclient.OrderBookUpdate
+    statusCh    chan grpcclient.StatusUpdate
+    feedStatus  *grpcclient.StatusUpdate
 
     aiClient        *openrouter.Client
     streamingMsg    string
@@ -115,6 +135,7 @@ type chatMessage struct {
     author    string
     content   string
     timestamp time.Time
+    alert     bool // highlighted alert notification
 }
 
 func newModel(cfg Config, panel indicatorpanel.Panel) model {
@@ -161,12 +182,46 @@ type priceUpdateMsg struct {
     symbol string
 }
 type indicatorUpdateMsg struct {
-    rsi        float64
-    sma        float64
-    ema        float64
-    rsiHistory []float64
-    smaHistory []float64
-    emaHistory []float64
+    symbol               string
+    interval             string
+    rsi                  float64
+    sma                  float64
+    ema                  float64
+    macd                 float64
+    macdSignal           float64
+    macdHistogram        float64
+    bbUpper              float64
+    bbMiddle             float64
+    bbLower              float64
+    bbPercentB           float64
+    atr                  float64
+    stochK               float64
+    stochD               float64
+    williamsR            float64
+    vwap                 float64
+    obv                  float64
+    volumeSMA            float64
+    values               map[string]grpcclient.IndicatorValue
+    rules                []grpcclient.RuleResult
+    rsiHistory           []float64
+    smaHistory           []float64
+    emaHistory           []float64
+    macdHistory          []float64
+    macdSignalHistory    []float64
+    macdHistogramHistory []float64
+    bbUpperHistory       []float64
+    bbLowerHistory       []float64
+    bbPercentBHistory    []float64
+    live                 *grpcclient.LiveIndicators
+}
+type alertMsg struct {
+    alert grpcclient.Alert
+}
+type orderBookMsg struct {
+    book grpcclient.OrderBookUpdate
+}
+type statusMsg struct {
+    status grpcclient.StatusUpdate
 }
 type typingTickMsg struct{}
 type aiResponseMsg struct {
@@ -202,14 +257,14 @@ type aiStreamChunkMsg struct {
     streamCh <-chan openrouter.StreamChunk
 }
 
-func startAIStreamCmd(client *openrouter.Client, ctx context.Context, prompt, symbol string, price, rsi, sma, ema float64, history *openrouter.IndicatorHistory) tea.Cmd {
+func startAIStreamCmd(client *openrouter.Client, ctx context.Context, prompt, symbol string, price, rsi, sma, ema float64, macd *openrouter.MACDValues, bands *openrouter.BollingerValues, custom map[string]float64, history *openrouter.IndicatorHistory) tea.Cmd {
     return func() tea.Msg {
         if client == nil {
             return aiStreamChunkMsg{content: "Error: API key no configurada", done: true}
         }
 
         // Use provided context for proper cancellation on program exit
-        chunkCh, err := client.StreamAnalysis(ctx, prompt, symbol, price, rsi, sma, ema, history)
+        chunkCh, err := client.StreamAnalysis(ctx, prompt, symbol, price, rsi, sma, ema, macd, bands, custom, history)
         if err != nil {
             return aiStreamChunkMsg{err: err, done: true}
         }
@@ -233,28 +288,148 @@ func continueAIStreamCmd(chunkCh <-chan openrouter.StreamChunk) tea.Cmd {
 }
 
 type startStreamMsg struct {
+    session     *grpcclient.Session
     priceCh     chan grpcclient.PriceUpdate
     indicatorCh chan grpcclient.IndicatorUpdate
+    alertCh     chan grpcclient.Alert
+    bookCh      chan grpcclient.OrderBookUpdate
+    statusCh    chan grpcclient.StatusUpdate
+}
+
+type pairSwitchedMsg struct {
+    symbol   string
+    previous string // set when the new pair could not be subscribed
+    err      error
+}
+
+type timeframeChangedMsg struct {
+    interval string
+    previous string
+    err      error
+}
+
+// streamConfig returns the stream configuration for the selected timeframe.
+func streamConfig(c Config) grpcclient.StreamConfig {
+    cfg := grpcclient.DefaultStreamConfig()
+    if c.Interval != "" {
+        cfg.Interval = c.Interval
+    }
+    cfg.Indicators = c.Indicators
+    cfg.Rules = c.Rules
+    cfg.DepthLevels = c.DepthLevels
+    cfg.Exchange = c.Exchange
+    return cfg
 }
 
-func startStreamCmd(client *grpcclient.Client, symbol string, ctx context.Context) tea.Cmd {
+// startStreamCmd opens a control session and subscribes to the initial symbol.
+// The session stays open for the lifetime of the stream context; pair switches
+// are sent as commands on it instead of reopening the stream.
+func startStreamCmd(client *grpcclient.Client, symbol string, cfg grpcclient.StreamConfig, alerts []grpcclient.AlertRule, ctx context.Context) tea.Cmd {
     return func() tea.Msg {
         priceCh := make(chan grpcclient.PriceUpdate, channelBufferSize)
         indicatorCh := make(chan grpcclient.IndicatorUpdate, channelBufferSize)
+        alertCh := make(chan grpcclient.Alert, channelBufferSize)
+        bookCh := make(chan grpcclient.OrderBookUpdate, channelBufferSize)
+        statusCh := make(chan grpcclient.StatusUpdate, channelBufferSize)
+
+        session, err := client.OpenSession(ctx, grpcclient.SymbolChannels{Prices: priceCh, Indicators: indicatorCh, Alerts: alertCh, OrderBooks: bookCh, Statuses: statusCh})
+        if err != nil {
+            return errMsg{err: err}
+        }
 
         go func() {
-            _ = client.StreamPrices(ctx, symbol, priceCh, indicatorCh)
+            <-session.Done()
             close(priceCh)
             close(indicatorCh)
+            close(alertCh)
+            close(bookCh)
+            close(statusCh)
         }()
 
-        return startStreamMsg{priceCh: priceCh, indicatorCh: indicatorCh}
+        if err := session.Subscribe(ctx, cfg, symbol); err != nil {
+            return errMsg{err: fmt.Errorf("subscribe %s: %w", strings.ToUpper(symbol), err)}
+        }
+        // Alerts without a symbol follow the pair across switches
+        if len(alerts) > 0 {
+            if err := session.AddAlerts(ctx, alerts...); err != nil {
+                return errMsg{err: fmt.Errorf("register alerts: %w", err)}
+            }
+        }
+
+        return startStreamMsg{session: session, priceCh: priceCh, indicatorCh: indicatorCh, alertCh: alertCh, bookCh: bookCh, statusCh: statusCh}
+    }
+}
+
+// switchPairCmd moves the control session from one symbol to another without reconnecting.
+func switchPairCmd(session *grpcclient.Session, ctx context.Context, oldPair, newPair string, cfg grpcclient.StreamConfig) tea.Cmd {
+    return func() tea.Msg {
+        if err := session.Subscribe(ctx, cfg, newPair); err != nil {
+            return pairSwitchedMsg{symbol: newPair, previous: oldPair, err: err}
+        }
+        if err := session.Unsubscribe(ctx, oldPair); err != nil {
+            return pairSwitchedMsg{symbol: newPair, err: err}
+        }
+        return pairSwitchedMsg{symbol: newPair}
+    }
+}
+
+// changeTimeframeCmd reconfigures the current symbol to a new candle interval in place.
+func changeTimeframeCmd(session *grpcclient.Session, ctx context.Context, symbol, interval, previous string) tea.Cmd {
+    return func() tea.Msg {
+        err := session.Reconfigure(ctx, symbol, grpcclient.StreamConfig{Interval: interval})
+        return timeframeChangedMsg{interval: interval, previous: previous, err: err}
+    }
+}
+
+// bellCmd rings the terminal bell so alerts are noticed while the chat is in the background.
+func bellCmd() tea.Cmd {
+    return func() tea.Msg {
+        fmt.Fprint(os.Stdout, "\a")
+        return nil
     }
 }
 
-func waitForUpdateCmd(priceCh <-chan grpcclient.PriceUpdate, indicatorCh <-chan grpcclient.IndicatorUpdate) tea.Cmd {
+// panelOrderBook converts a streamed order book for the depth ladder.
+func panelOrderBook(b grpcclient.OrderBookUpdate) *indicatorpanel.OrderBook {
+    levels := func(in []grpcclient.BookLevel) []orderbook.Level {
+        out := make([]orderbook.Level, len(in))
+        for i, l := range in {
+            out[i] = orderbook.Level{Price: l.Price, Quantity: l.Quantity}
+        }
+        return out
+    }
+    return &indicatorpanel.OrderBook{
+        Bids: levels(b.Bids),
+        Asks: levels(b.Asks),
+        Metrics: orderbook.Metrics{
+            BestBid:   b.BestBid,
+            BestAsk:   b.BestAsk,
+            Mid:       b.Mid,
+            Spread:    b.Spread,
+            SpreadBps: b.SpreadBps,
+            Imbalance: b.Imbalance,
+        },
+    }
+}
+
+func waitForUpdateCmd(priceCh <-chan grpcclient.PriceUpdate, indicatorCh <-chan grpcclient.IndicatorUpdate, alertCh <-chan grpcclient.Alert, bookCh <-chan grpcclient.OrderBookUpdate, statusCh <-chan grpcclient.StatusUpdate) tea.Cmd {
     return func() tea.Msg {
         select {
+        case a, ok := <-alertCh:
+            if !ok {
+                return errMsg{err: fmt.Errorf("alert channel closed")}
+            }
+            return alertMsg{alert: a}
+        case b, ok := <-bookCh:
+            if !ok {
+                return errMsg{err: fmt.Errorf("order book channel closed")}
+            }
+            return orderBookMsg{book: b}
+        case st, ok := <-statusCh:
+            if !ok {
+                return errMsg{err: fmt.Errorf("status channel closed")}
+            }
+            return statusMsg{status: st}
         case p, ok := <-priceCh:
             if !ok {
                 return errMsg{err: fmt.Errorf("price channel closed")}
@@ -265,12 +440,37 @@ func waitForUpdateCmd(priceCh <-chan grpcclient.PriceUpdate, indicatorCh <-chan
                 return errMsg{err: fmt.Errorf("indicator channel closed")}
             }
             return indicatorUpdateMsg{
-                rsi:        i.RSI,
-                sma:        i.SMA,
-                ema:        i.EMA,
-                rsiHistory: i.RSIHistory,
-                smaHistory: i.SMAHistory,
-                emaHistory: i.EMAHistory,
+                symbol:               i.Symbol,
+                interval:             i.Interval,
+                rsi:                  i.RSI,
+                sma:                  i.SMA,
+                ema:                  i.EMA,
+                macd:                 i.MACD,
+                macdSignal:           i.MACDSignal,
+                macdHistogram:        i.MACDHistogram,
+                rsiHistory:           i.RSIHistory,
+                smaHistory:           i.SMAHistory,
+                emaHistory:           i.EMAHistory,
+                macdHistory:          i.MACDHistory,
+                macdSignalHistory:    i.MACDSignalHistory,
+                macdHistogramHistory: i.MACDHistogramHistory,
+                bbUpper:              i.BBUpper,
+                bbMiddle:             i.BBMiddle,
+                bbLower:              i.BBLower,
+                bbPercentB:           i.BBPercentB,
+                bbUpperHistory:       i.BBUpperHistory,
+                bbLowerHistory:       i.BBLowerHistory,
+                bbPercentBHistory:    i.BBPercentBHistory,
+                atr:                  i.ATR,
+                stochK:               i.StochK,
+                stochD:               i.StochD,
+                williamsR:            i.WilliamsR,
+                vwap:                 i.VWAP,
+                obv:                  i.OBV,
+                volumeSMA:            i.VolumeSMA,
+                values:               i.Values,
+                rules:                i.Rules,
+                live:                 i.Live,
             }
         }
     }
@@ -316,29 +516,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                 selectedPair := availablePairs[m.pairSelectIndex]
                 oldPair := m.cfg.Symbol
                 m.showPairSelect = false
-                m.cfg.Symbol = selectedPair
-                
-                // Cancel previous stream to prevent goroutine/channel leak
-                if m.streamCancel != nil {
-                    m.streamCancel()
+                if strings.EqualFold(selectedPair, oldPair) {
+                    break
                 }
+                m.cfg.Symbol = selectedPair
                 
                 // Reset price and indicators for new pair
                 m.currentPrice = 0
                 m.prevPrice = 0
                 m.priceChange = 0
+                m.feedStatus = nil
                 m.indicatorValues = domainindicators.AggregatedValues{}
+                m.customValues = nil
                 m.indicatorHistory = nil
-                m.priceCh = nil
-                m.indicatorCh = nil
+                m.panel = m.panel.WithHistory(domainindicators.IndicatorHistory{}).WithCustom(nil).WithRules(nil).WithOrderBook(nil)
                 m.logger.LogPairSwitch(oldPair, selectedPair)
                 m.addMessage(chatMessage{author: "Sistema", content: fmt.Sprintf("Cambiando a par: %s", strings.ToUpper(selectedPair)), timestamp: time.Now()})
                 m.chatDirty = true
                 
-                // Create new context for new stream
-                if m.grpcClient != nil {
-                    m.streamCtx, m.streamCancel = context.WithCancel(m.programCtx)
-                    cmds = append(cmds, startStreamCmd(m.grpcClient, selectedPair, m.streamCtx))
+                // Switch symbols on the open control session (no reconnect)
+                if m.session != nil {
+                    cmds = append(cmds, switchPairCmd(m.session, m.streamCtx, oldPair, selectedPair, streamConfig(m.cfg)))
                 }
             }
             break
@@ -422,8 +620,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
                     }
                     m.textarea.Reset()
                     break
+                case cmd == "/timeframe" || strings.HasPrefix(cmd, "/timeframe "):
+                    cmds = append(cmds, m.handleTimeframeCommand(strings.TrimSpace(strings.TrimPrefix(cmd, "/timeframe"))))
+                    m.chatDirty = true
+                    m.textarea.Reset()
                 default:
-                    m.addMessage(chatMessage{author: "Sistema", content: "Comandos disponibles: /clear, /pairs", timestamp: time.Now()})
+                    m.addMessage(chatMessage{author: "Sistema", content: "Comandos disponibles: /clear, /pairs, /timeframe", timestamp: time.Now()})
                     m.chatDirty = true
                     m.textarea.Reset()
                 }
@@ -437,7 +639,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
             m.textarea.Reset()
             m.typing = true
             m.streamingMsg = ""
-            cmds = append(cmds, startAIStreamCmd(m.aiClient, m.programCtx, input, m.cfg.Symbol, m.currentPrice, m.indicatorValues.RSI, m.indicatorValues.SMA, m.indicatorValues.EMA, m.indicatorHistory))
+            cmds = append(cmds, startAIStreamCmd(m.aiClient, m.programCtx, input, m.cfg.Symbol, m.currentPrice, m.indicatorValues.RSI, m.indicatorValues.SMA, m.indicatorValues.EMA, &openrouter.MACDValues{
+                MACD:      m.indicatorValues.MACD,
+                Signal:    m.indicatorValues.MACDSignal,
+                Histogram: m.indicatorValues.MACDHistogram,
+            }, &openrouter.BollingerValues{
+                Upper:    m.indicatorValues.BBUpper,
+                Middle:   m.indicatorValues.BBMiddle,
+                Lower:    m.indicatorValues.BBLower,
+                PercentB: m.indicatorValues.BBPercentB,
+            }, m.customValues, m.indicatorHistory))
         default:
             var cmd tea.Cmd
             m.textarea, cmd = m.textarea.Update(msg)
@@ -473,42 +684,177 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
         m.chatDirty = true
         // Create initial stream context
         m.streamCtx, m.streamCancel = context.WithCancel(m.programCtx)
-        cmds = append(cmds, startStreamCmd(m.grpcClient, m.cfg.Symbol, m.streamCtx))
+        cmds = append(cmds, startStreamCmd(m.grpcClient, m.cfg.Symbol, streamConfig(m.cfg), m.cfg.Alerts, m.streamCtx))
 
     case startStreamMsg:
+        m.session = msg.session
         m.priceCh = msg.priceCh
         m.indicatorCh = msg.indicatorCh
-        cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh))
+        m.alertCh = msg.alertCh
+        m.bookCh = msg.bookCh
+        m.statusCh = msg.statusCh
+        cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+
+    case timeframeChangedMsg:
+        if msg.err != nil {
+            m.cfg.Interval = msg.previous
+            m.panel = m.panel.WithInterval(msg.previous)
+            m.addMessage(chatMessage{author: "Error", content: fmt.Sprintf("No se pudo cambiar a temporalidad %s: %v", msg.interval, msg.err), timestamp: time.Now()})
+            m.chatDirty = true
+        }
+
+    case pairSwitchedMsg:
+        if msg.err != nil {
+            content := fmt.Sprintf("No se pudo cambiar a %s: %v", strings.ToUpper(msg.symbol), msg.err)
+            // The previous pair is still subscribed; show its updates again
+            if msg.previous != "" && strings.EqualFold(m.cfg.Symbol, msg.symbol) {
+                m.cfg.Symbol = msg.previous
+                content += fmt.Sprintf("\nSe mantiene %s", strings.ToUpper(msg.previous))
+            }
+            m.addMessage(chatMessage{author: "Error", content: content, timestamp: time.Now()})
+            m.chatDirty = true
+        }
 
     case priceUpdateMsg:
+        // Drop updates still in flight for a previously selected pair
+        if !strings.EqualFold(msg.symbol, m.cfg.Symbol) {
+            if m.priceCh != nil {
+                cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+            }
+            break
+        }
         m.prevPrice = m.currentPrice
         m.currentPrice = msg.price
         m.priceChange = m.currentPrice - m.prevPrice
         m.logger.LogPriceUpdate(msg.symbol, msg.price, 0)
         if m.priceCh != nil {
-            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh))
+            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
         }
 
     case indicatorUpdateMsg:
+        stale := msg.symbol != "" && !strings.EqualFold(msg.symbol, m.cfg.Symbol)
+        stale = stale || (msg.interval != "" && msg.interval != m.cfg.Interval)
+        if stale {
+            if m.priceCh != nil {
+                cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+            }
+            break
+        }
+        // Show values including the in-progress bar; history stays on closed bars
         m.indicatorValues = domainindicators.AggregatedValues{
-            RSI: msg.rsi,
-            SMA: msg.sma,
-            EMA: msg.ema,
+            RSI:           msg.rsi,
+            SMA:           msg.sma,
+            EMA:           msg.ema,
+            MACD:          msg.macd,
+            MACDSignal:    msg.macdSignal,
+            MACDHistogram: msg.macdHistogram,
+            BBUpper:       msg.bbUpper,
+            BBMiddle:      msg.bbMiddle,
+            BBLower:       msg.bbLower,
+            BBPercentB:    msg.bbPercentB,
+            ATR:           msg.atr,
+            StochK:        msg.stochK,
+            StochD:        msg.stochD,
+            WilliamsR:     msg.williamsR,
+            VWAP:          msg.vwap,
+            OBV:           msg.obv,
+            VolumeSMA:     msg.volumeSMA,
         }
-        m.logger.LogIndicatorUpdate(msg.rsi, msg.sma, msg.ema)
+        if msg.live != nil {
+            m.indicatorValues = domainindicators.AggregatedValues{
+                RSI:           msg.live.RSI,
+                SMA:           msg.live.SMA,
+                EMA:           msg.live.EMA,
+                MACD:          msg.live.MACD,
+                MACDSignal:    msg.live.MACDSignal,
+                MACDHistogram: msg.live.MACDHistogram,
+                BBUpper:       msg.live.BBUpper,
+                BBMiddle:      msg.live.BBMiddle,
+                BBLower:       msg.live.BBLower,
+                BBPercentB:    msg.live.BBPercentB,
+                ATR:           msg.live.ATR,
+                StochK:        msg.live.StochK,
+                StochD:        msg.live.StochD,
+                WilliamsR:     msg.live.WilliamsR,
+                VWAP:          msg.live.VWAP,
+                OBV:           msg.live.OBV,
+                VolumeSMA:     msg.live.VolumeSMA,
+            }
+        }
+        custom := msg.values
+        if msg.live != nil && msg.live.Values != nil {
+            custom = msg.live.Values
+        }
+        m.customValues = make(map[string]float64, len(custom))
+        for key, v := range custom {
+            m.customValues[key] = v.Value
+        }
+        m.panel = m.panel.WithCustom(m.customValues)
+        ruleResults := msg.rules
+        if msg.live != nil && msg.live.Rules != nil {
+            ruleResults = msg.live.Rules
+        }
+        ruleStates := make([]indicatorpanel.RuleState, len(ruleResults))
+        for i, r := range ruleResults {
+            ruleStates[i] = indicatorpanel.RuleState{Name: r.Name, Active: r.Value}
+        }
+        m.panel = m.panel.WithRules(ruleStates)
+        m.logger.LogIndicatorUpdate(m.indicatorValues.RSI, m.indicatorValues.SMA, m.indicatorValues.EMA)
         history := domainindicators.IndicatorHistory{
-            RSI: msg.rsiHistory,
-            SMA: msg.smaHistory,
-            EMA: msg.emaHistory,
+            RSI:           msg.rsiHistory,
+            SMA:           msg.smaHistory,
+            EMA:           msg.emaHistory,
+            MACD:          msg.macdHistory,
+            MACDSignal:    msg.macdSignalHistory,
+            MACDHistogram: msg.macdHistogramHistory,
+            BBUpper:       msg.bbUpperHistory,
+            BBLower:       msg.bbLowerHistory,
+            BBPercentB:    msg.bbPercentBHistory,
         }
         m.panel = m.panel.WithHistory(history)
         m.indicatorHistory = &openrouter.IndicatorHistory{
-            RSI: msg.rsiHistory,
-            SMA: msg.smaHistory,
-            EMA: msg.emaHistory,
+            Interval:      m.cfg.Interval,
+            RSI:           msg.rsiHistory,
+            SMA:           msg.smaHistory,
+            EMA:           msg.emaHistory,
+            MACD:          msg.macdHistory,
+            MACDSignal:    msg.macdSignalHistory,
+            MACDHistogram: msg.macdHistogramHistory,
+            BBPercentB:    msg.bbPercentBHistory,
+        }
+        if m.priceCh != nil {
+            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+        }
+
+    case orderBookMsg:
+        if strings.EqualFold(msg.book.Symbol, m.cfg.Symbol) {
+            m.panel = m.panel.WithOrderBook(panelOrderBook(msg.book))
+        }
+        if m.priceCh != nil {
+            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+        }
+
+    case statusMsg:
+        if strings.EqualFold(msg.status.Symbol, m.cfg.Symbol) {
+            status := msg.status
+            m.feedStatus = &status
         }
         if m.priceCh != nil {
-            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh))
+            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
+        }
+
+    case alertMsg:
+        a := msg.alert
+        content := fmt.Sprintf("🔔 Alerta %s: %s (precio %.2f)", a.Symbol, a.Message, a.Price)
+        if a.Condition != "" && a.Condition != a.Message {
+            content += "\nCondición: " + a.Condition
+        }
+        m.addMessage(chatMessage{author: "Sistema", content: content, timestamp: a.Timestamp, alert: true})
+        m.logger.LogAlert(a.RuleID, a.Symbol, a.Message, a.Price)
+        m.chatDirty = true
+        cmds = append(cmds, bellCmd())
+        if m.priceCh != nil {
+            cmds = append(cmds, waitForUpdateCmd(m.priceCh, m.indicatorCh, m.alertCh, m.bookCh, m.statusCh))
         }
 
     case typingTickMsg:
@@ -572,15 +918,56 @@ var (
     aiColor   = lipgloss.Color("#D4A5FF")
     errColor  = lipgloss.Color("#FF6B6B")
     sysColor  = lipgloss.Color("#73F59F")
+    alertColor = lipgloss.Color("#FFD866")
     
     // Pre-compiled styles for performance (avoid creating new styles on each render)
     authorStyleAI     = lipgloss.NewStyle().Bold(true).Foreground(aiColor)
     authorStyleUser   = lipgloss.NewStyle().Bold(true).Foreground(userColor)
     authorStyleError  = lipgloss.NewStyle().Bold(true).Foreground(errColor)
     authorStyleSystem = lipgloss.NewStyle().Bold(true).Foreground(sysColor)
+    alertStyle        = lipgloss.NewStyle().Bold(true).Foreground(alertColor).
+                            Border(lipgloss.ThickBorder(), false, false, false, true).
+                            BorderForeground(alertColor).
+                            PaddingLeft(1)
     timestampStyle    = lipgloss.NewStyle().Foreground(dimText)
 )
 
+// handleTimeframeCommand switches the candle interval of the active stream.
+func (m *model) handleTimeframeCommand(arg string) tea.Cmd {
+    if arg == "" {
+        m.addMessage(chatMessage{author: "Sistema", content: fmt.Sprintf("Temporalidad actual: %s. Disponibles: %s", m.cfg.Interval, strings.Join(availableTimeframes, ", ")), timestamp: time.Now()})
+        return nil
+    }
+
+    valid := false
+    for _, tf := range availableTimeframes {
+        if tf == arg {
+            valid = true
+            break
+        }
+    }
+    if !valid {
+        m.addMessage(chatMessage{author: "Error", content: fmt.Sprintf("Temporalidad no válida: %s. Disponibles: %s", arg, strings.Join(availableTimeframes, ", ")), timestamp: time.Now()})
+        return nil
+    }
+    if arg == m.cfg.Interval {
+        return nil
+    }
+
+    previous := m.cfg.Interval
+    m.cfg.Interval = arg
+    m.indicatorValues = domainindicators.AggregatedValues{}
+    m.customValues = nil
+    m.indicatorHistory = nil
+    m.panel = m.panel.WithHistory(domainindicators.IndicatorHistory{}).WithCustom(nil).WithRules(nil).WithInterval(arg)
+    m.addMessage(chatMessage{author: "Sistema", content: fmt.Sprintf("Cambiando temporalidad a: %s", arg), timestamp: time.Now()})
+
+    if m.session == nil {
+        return nil
+    }
+    return changeTimeframeCmd(m.session, m.streamCtx, m.cfg.Symbol, arg, previous)
+}
+
 // addMessage adds a message with ring buffer limit to prevent unbounded growth
 func (m *model) addMessage(msg chatMessage) {
     m.messages = append(m.messages, msg)
@@ -680,6 +1067,9 @@ func (m model) formatMessage(msg chatMessage, width int) string {
     content := lipgloss.NewStyle().
         Width(width).
         Render(msg.content)
+    if msg.alert {
+        content = alertStyle.Width(width).Render(msg.content)
+    }
 
     return "\n" + header + timestamp + "\n" + content
 }
@@ -777,7 +1167,7 @@ func (m model) renderHeader(width int) string {
     symbol := lipgloss.NewStyle().
         Bold(true).
         Foreground(lipgloss.Color("#FFFFFF")).
-        Render(m.cfg.Symbol)
+        Render(m.cfg.Symbol) + lipgloss.NewStyle().Foreground(dimText).Render(" "+m.cfg.Interval)
     
     priceStyle := lipgloss.NewStyle().Bold(true)
     changeStr := ""
@@ -791,6 +1181,9 @@ func (m model) renderHeader(width int) string {
     price := priceStyle.Render(fmt.Sprintf("$%.2f%s", m.currentPrice, changeStr))
     
     left := logo + "  " + statusIcon + statusText
+    if badge := m.renderFeedBadge(); badge != "" {
+        left += "  " + badge
+    }
     right := symbol + " " + price
     
     gap := width - lipgloss.Width(left) - lipgloss.Width(right) - 2
@@ -807,6 +1200,44 @@ func (m model) renderHeader(width int) string {
     return header + "\n" + border
 }
 
+// renderFeedBadge shows the state of the server's upstream feed for the
+// selected pair, or nothing until the server reports it.
+func (m model) renderFeedBadge() string {
+    st := m.feedStatus
+    if st == nil {
+        return ""
+    }
+    badge := lipgloss.NewStyle().Bold(true).Padding(0, 1).Foreground(lipgloss.Color("#000000"))
+    var label string
+    switch st.State {
+    case "live":
+        label = "EN VIVO"
+        badge = badge.Background(sysColor)
+    case "degraded":
+        label = "DEGRADADO"
+        if st.LastData >= 0 {
+            label += fmt.Sprintf(" %ds", int(st.LastData.Seconds()))
+        }
+        badge = badge.Background(alertColor)
+    case "reconnecting":
+        label = "RECONECTANDO"
+        badge = badge.Background(alertColor)
+    case "connecting":
+        label = "CONECTANDO"
+        badge = badge.Background(dimText)
+    case "failed":
+        label = "CAÍDO"
+        badge = badge.Background(errColor)
+    default:
+        label = strings.ToUpper(st.State)
+        badge = badge.Background(dimText)
+    }
+    if st.Simulated {
+        label = "SIM · " + label
+    }
+    return badge.Render(label)
+}
+
 func (m model) renderInput(width int) string {
     border := lipgloss.NewStyle().
         Foreground(subtle).
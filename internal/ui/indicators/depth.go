@@ -0,0 +1,77 @@
+package indicators
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "github.com/rp4ri/quantacode/internal/domain/orderbook"
+)
+
+// maxLadderLevels bounds the rows per side of the depth ladder.
+const maxLadderLevels = 5
+
+// OrderBook is the top of the order book shown as a depth ladder.
+type OrderBook struct {
+    Bids    []orderbook.Level // highest first
+    Asks    []orderbook.Level // lowest first
+    Metrics orderbook.Metrics
+}
+
+// WithOrderBook sets the order book shown below the indicators; nil hides it.
+func (p Panel) WithOrderBook(book *OrderBook) Panel {
+    p.book = book
+    return p
+}
+
+// renderOrderBook draws asks above bids, best prices next to the spread line,
+// with bars scaled to the largest quantity shown.
+func (p Panel) renderOrderBook() string {
+    labelStyle := lipgloss.NewStyle().Foreground(dimText)
+    bidStyle := lipgloss.NewStyle().Foreground(greenColor)
+    askStyle := lipgloss.NewStyle().Foreground(redColor)
+
+    bids := p.book.Bids[:min(len(p.book.Bids), maxLadderLevels)]
+    asks := p.book.Asks[:min(len(p.book.Asks), maxLadderLevels)]
+    if len(bids) == 0 && len(asks) == 0 {
+        return labelStyle.Render("Libro:") + " " + labelStyle.Italic(true).Render("esperando datos...")
+    }
+
+    var maxQty float64
+    for _, l := range append(append([]orderbook.Level{}, bids...), asks...) {
+        maxQty = max(maxQty, l.Quantity)
+    }
+    barWidth := max(p.width-24, 3)
+    row := func(l orderbook.Level, style lipgloss.Style) string {
+        n := 0
+        if maxQty > 0 {
+            n = max(int(l.Quantity/maxQty*float64(barWidth)+0.5), 1)
+        }
+        return fmt.Sprintf("%s %s %s",
+            style.Render(fmt.Sprintf("%10.2f", l.Price)),
+            labelStyle.Render(fmt.Sprintf("%8.3f", l.Quantity)),
+            style.Render(strings.Repeat("▇", n)))
+    }
+
+    lines := []string{labelStyle.Render("Libro de órdenes:")}
+    for i := len(asks) - 1; i >= 0; i-- {
+        lines = append(lines, row(asks[i], askStyle))
+    }
+
+    m := p.book.Metrics
+    imbalanceStyle := lipgloss.NewStyle().Bold(true).Foreground(greenColor)
+    if m.Imbalance < 0 {
+        imbalanceStyle = imbalanceStyle.Foreground(redColor)
+    }
+    lines = append(lines, fmt.Sprintf("%s %s",
+        labelStyle.Render("Spread:"),
+        lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Render(fmt.Sprintf("%.2f (%.1f bps)", m.Spread, m.SpreadBps))))
+
+    for _, l := range bids {
+        lines = append(lines, row(l, bidStyle))
+    }
+    lines = append(lines, fmt.Sprintf("%s %s",
+        labelStyle.Render("Desbalance:"),
+        imbalanceStyle.Render(fmt.Sprintf("%+.0f%%", m.Imbalance*100))))
+    return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
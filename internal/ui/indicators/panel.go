@@ -2,6 +2,7 @@ package indicators
 
 import (
     "fmt"
+    "sort"
     "strings"
 
     "github.com/charmbracelet/lipgloss"
@@ -20,9 +21,19 @@ var (
 
 // Panel renders indicator values in a right sidebar.
 type Panel struct {
-    width   int
-    height  int
-    history domainindicators.IndicatorHistory
+    width    int
+    height   int
+    interval string
+    history  domainindicators.IndicatorHistory
+    custom   map[string]float64
+    rules    []RuleState
+    book     *OrderBook
+}
+
+// RuleState is the current result of a signal rule evaluated by the server.
+type RuleState struct {
+    Name   string
+    Active bool
 }
 
 // NewPanel creates a Panel with a default width.
@@ -45,6 +56,24 @@ func (p Panel) WithHeight(height int) Panel {
     return p
 }
 
+// WithInterval sets the candle interval shown in the title and history header.
+func (p Panel) WithInterval(interval string) Panel {
+    p.interval = interval
+    return p
+}
+
+// WithCustom sets the values of extra registry indicators, keyed by spec (e.g. "ema:50").
+func (p Panel) WithCustom(values map[string]float64) Panel {
+    p.custom = values
+    return p
+}
+
+// WithRules sets the signal rule states shown below the indicators.
+func (p Panel) WithRules(rules []RuleState) Panel {
+    p.rules = rules
+    return p
+}
+
 // WithHistory updates the indicator history.
 func (p Panel) WithHistory(history domainindicators.IndicatorHistory) Panel {
     p.history = history
@@ -53,10 +82,14 @@ func (p Panel) WithHistory(history domainindicators.IndicatorHistory) Panel {
 
 // View renders the indicator state.
 func (p Panel) View(vals domainindicators.AggregatedValues) string {
+    titleText := "◆ Indicadores"
+    if p.interval != "" {
+        titleText += " · " + p.interval
+    }
     title := lipgloss.NewStyle().
         Bold(true).
         Foreground(highlight).
-        Render("◆ Indicadores")
+        Render(titleText)
     
     border := lipgloss.NewStyle().
         Foreground(subtle).
@@ -64,11 +97,17 @@ func (p Panel) View(vals domainindicators.AggregatedValues) string {
 
     currentSection := p.renderCurrentValues(vals)
 
-    content := lipgloss.JoinVertical(lipgloss.Left,
-        title,
-        border,
-        currentSection,
-    )
+    sections := []string{title, border, currentSection}
+    if len(p.custom) > 0 {
+        sections = append(sections, border, p.renderCustom())
+    }
+    if len(p.rules) > 0 {
+        sections = append(sections, border, p.renderRules())
+    }
+    if p.book != nil {
+        sections = append(sections, border, p.renderOrderBook())
+    }
+    content := lipgloss.JoinVertical(lipgloss.Left, sections...)
 
     return lipgloss.NewStyle().
         Width(p.width).
@@ -83,7 +122,8 @@ func (p Panel) renderCurrentValues(vals domainindicators.AggregatedValues) strin
     // Check if indicators are still warming up (RSI=0 and SMA=0 means not enough data)
     isWarmingUp := vals.RSI == 0 && vals.SMA == 0
     
-    var rsiLine, smaLine, emaLine string
+    var rsiLine, smaLine, emaLine, macdLine, bandsLine, oscillatorLines string
+    volumeLines := p.renderVolume(vals, labelStyle, warmingStyle)
     
     if isWarmingUp {
         rsiLine = fmt.Sprintf("%s %s", 
@@ -95,6 +135,15 @@ func (p Panel) renderCurrentValues(vals domainindicators.AggregatedValues) strin
         emaLine = fmt.Sprintf("%s %s",
             labelStyle.Render("EMA:"),
             warmingStyle.Render("calentando..."))
+        macdLine = fmt.Sprintf("%s %s",
+            labelStyle.Render("MACD:"),
+            warmingStyle.Render("calentando..."))
+        bandsLine = fmt.Sprintf("%s %s",
+            labelStyle.Render("BB:"),
+            warmingStyle.Render("calentando..."))
+        oscillatorLines = fmt.Sprintf("%s %s",
+            labelStyle.Render("ATR/Stoch/%R:"),
+            warmingStyle.Render("calentando..."))
     } else {
         rsiStyle := lipgloss.NewStyle().Bold(true)
         rsiLabel := "RSI"
@@ -120,9 +169,198 @@ func (p Panel) renderCurrentValues(vals domainindicators.AggregatedValues) strin
         emaLine = fmt.Sprintf("%s %s",
             labelStyle.Render("EMA:"),
             lipgloss.NewStyle().Foreground(purpleColor).Render(fmt.Sprintf("%.2f", vals.EMA)))
+
+        macdLine = p.renderMACD(vals, labelStyle, warmingStyle)
+        bandsLine = p.renderBands(vals, labelStyle, warmingStyle)
+        oscillatorLines = p.renderOscillators(vals, labelStyle)
+    }
+
+    return lipgloss.JoinVertical(lipgloss.Left, rsiLine, smaLine, emaLine, macdLine, bandsLine, oscillatorLines, volumeLines)
+}
+
+// renderVolume shows the session VWAP, On-Balance Volume and the volume average.
+// VWAP is trade-based, so it can be ready before the bar indicators warm up.
+func (p Panel) renderVolume(vals domainindicators.AggregatedValues, labelStyle, warmingStyle lipgloss.Style) string {
+    valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+
+    vwapLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("VWAP:"),
+        warmingStyle.Render("calentando..."))
+    if vals.VWAP != 0 {
+        vwapLine = fmt.Sprintf("%s %s",
+            labelStyle.Render("VWAP:"),
+            lipgloss.NewStyle().Foreground(blueColor).Render(fmt.Sprintf("%.2f", vals.VWAP)))
+    }
+
+    obvStyle := valueStyle
+    switch {
+    case vals.OBV > 0:
+        obvStyle = obvStyle.Foreground(greenColor)
+    case vals.OBV < 0:
+        obvStyle = obvStyle.Foreground(redColor)
+    }
+    obvLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("OBV:"),
+        obvStyle.Render(formatVolume(vals.OBV)))
+
+    volLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("Vol SMA:"),
+        warmingStyle.Render("calentando..."))
+    if vals.VolumeSMA != 0 {
+        volLine = fmt.Sprintf("%s %s",
+            labelStyle.Render("Vol SMA:"),
+            valueStyle.Render(formatVolume(vals.VolumeSMA)))
+    }
+
+    return lipgloss.JoinVertical(lipgloss.Left, vwapLine, obvLine, volLine)
+}
+
+// formatVolume abbreviates large volumes (e.g. 12.3K, 4.56M).
+func formatVolume(v float64) string {
+    abs := v
+    if abs < 0 {
+        abs = -abs
+    }
+    switch {
+    case abs >= 1e9:
+        return fmt.Sprintf("%.2fB", v/1e9)
+    case abs >= 1e6:
+        return fmt.Sprintf("%.2fM", v/1e6)
+    case abs >= 1e3:
+        return fmt.Sprintf("%.1fK", v/1e3)
+    default:
+        return fmt.Sprintf("%.2f", v)
+    }
+}
+
+// renderCustom lists the extra registry indicators sorted by spec.
+func (p Panel) renderCustom() string {
+    labelStyle := lipgloss.NewStyle().Foreground(dimText)
+    valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+
+    keys := make([]string, 0, len(p.custom))
+    for key := range p.custom {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    lines := make([]string, 0, len(keys))
+    for _, key := range keys {
+        value := valueStyle.Render(fmt.Sprintf("%.2f", p.custom[key]))
+        if p.custom[key] == 0 {
+            value = lipgloss.NewStyle().Foreground(dimText).Italic(true).Render("calentando...")
+        }
+        lines = append(lines, fmt.Sprintf("%s %s", labelStyle.Render(strings.ToUpper(key)+":"), value))
+    }
+    return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// renderRules lists the signal rules in request order, highlighting those that hold.
+func (p Panel) renderRules() string {
+    labelStyle := lipgloss.NewStyle().Foreground(dimText)
+    activeStyle := lipgloss.NewStyle().Bold(true).Foreground(greenColor)
+
+    lines := []string{labelStyle.Render("Reglas:")}
+    maxName := p.width - 6
+    for _, rule := range p.rules {
+        name := rule.Name
+        if maxName > 3 && len(name) > maxName {
+            name = name[:maxName-3] + "..."
+        }
+        if rule.Active {
+            lines = append(lines, activeStyle.Render("● "+name))
+        } else {
+            lines = append(lines, labelStyle.Render("○ "+name))
+        }
+    }
+    return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// renderBands shows the Bollinger Bands and where the price sits relative to them.
+func (p Panel) renderBands(vals domainindicators.AggregatedValues, labelStyle, warmingStyle lipgloss.Style) string {
+    if vals.BBMiddle == 0 {
+        return fmt.Sprintf("%s %s",
+            labelStyle.Render("BB:"),
+            warmingStyle.Render("calentando..."))
     }
 
-    return lipgloss.JoinVertical(lipgloss.Left, rsiLine, smaLine, emaLine)
+    bands := fmt.Sprintf("%s %s",
+        labelStyle.Render("BB:"),
+        lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Render(fmt.Sprintf("%.2f-%.2f", vals.BBLower, vals.BBUpper)))
+
+    posStyle := lipgloss.NewStyle().Bold(true)
+    position := "dentro"
+    switch {
+    case vals.BBPercentB > 1:
+        posStyle = posStyle.Foreground(redColor)
+        position = "sobre banda ⚠"
+    case vals.BBPercentB < 0:
+        posStyle = posStyle.Foreground(blueColor)
+        position = "bajo banda ⚠"
+    default:
+        posStyle = posStyle.Foreground(lipgloss.Color("#FFFFFF"))
+    }
+    percentB := fmt.Sprintf("%s %s %s",
+        labelStyle.Render("%B:"),
+        posStyle.Render(fmt.Sprintf("%.2f", vals.BBPercentB)),
+        labelStyle.Render(position))
+
+    return lipgloss.JoinVertical(lipgloss.Left, bands, percentB)
+}
+
+// renderMACD shows the MACD and signal lines with the histogram colored by sign.
+func (p Panel) renderMACD(vals domainindicators.AggregatedValues, labelStyle, warmingStyle lipgloss.Style) string {
+    // Signal needs slow+signal periods of data, longer than the other indicators
+    if vals.MACD == 0 && vals.MACDSignal == 0 {
+        return fmt.Sprintf("%s %s",
+            labelStyle.Render("MACD:"),
+            warmingStyle.Render("calentando..."))
+    }
+
+    histStyle := lipgloss.NewStyle().Bold(true).Foreground(greenColor)
+    arrow := "▲"
+    if vals.MACDHistogram < 0 {
+        histStyle = histStyle.Foreground(redColor)
+        arrow = "▼"
+    }
+
+    return fmt.Sprintf("%s %s %s",
+        labelStyle.Render("MACD:"),
+        lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Render(fmt.Sprintf("%.2f/%.2f", vals.MACD, vals.MACDSignal)),
+        histStyle.Render(fmt.Sprintf("%s%.2f", arrow, vals.MACDHistogram)))
+}
+
+// renderOscillators shows the ATR, Stochastic %K/%D and Williams %R, marking
+// overbought and oversold readings like the RSI.
+func (p Panel) renderOscillators(vals domainindicators.AggregatedValues, labelStyle lipgloss.Style) string {
+    valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+
+    stochStyle := valueStyle
+    switch {
+    case vals.StochK >= 80:
+        stochStyle = stochStyle.Foreground(redColor)
+    case vals.StochK <= 20:
+        stochStyle = stochStyle.Foreground(blueColor)
+    }
+    willRStyle := valueStyle
+    switch {
+    case vals.WilliamsR >= -20:
+        willRStyle = willRStyle.Foreground(redColor)
+    case vals.WilliamsR <= -80:
+        willRStyle = willRStyle.Foreground(blueColor)
+    }
+
+    atrLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("ATR:"),
+        valueStyle.Render(fmt.Sprintf("%.2f", vals.ATR)))
+    stochLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("Stoch:"),
+        stochStyle.Render(fmt.Sprintf("%.1f/%.1f", vals.StochK, vals.StochD)))
+    willRLine := fmt.Sprintf("%s %s",
+        labelStyle.Render("%R:"),
+        willRStyle.Render(fmt.Sprintf("%.1f", vals.WilliamsR)))
+
+    return lipgloss.JoinVertical(lipgloss.Left, atrLine, stochLine, willRLine)
 }
 
 func (p Panel) renderHistory() string {
@@ -137,7 +375,7 @@ func (p Panel) renderHistory() string {
         Bold(true).
         Foreground(dimText)
     
-    header := headerStyle.Render(fmt.Sprintf("Historial (%d velas)", len(p.history.RSI)))
+    header := headerStyle.Render(fmt.Sprintf("Historial (%d velas %s)", len(p.history.RSI), p.interval))
     
     tableHeader := lipgloss.NewStyle().
         Foreground(dimText).